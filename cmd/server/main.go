@@ -2,20 +2,59 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/tombombadilom/liveops/internal/api"
+	"github.com/tombombadilom/liveops/internal/audit"
 	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/clock"
 	"github.com/tombombadilom/liveops/internal/config"
 	"github.com/tombombadilom/liveops/internal/db"
+	"github.com/tombombadilom/liveops/internal/db/postgres"
+	"github.com/tombombadilom/liveops/internal/idempotency"
+	"github.com/tombombadilom/liveops/internal/outbox"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
 	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/store/memory"
+	"github.com/tombombadilom/liveops/internal/webhook"
+)
+
+const (
+	// statusSchedulerInterval is how often event statuses are advanced
+	statusSchedulerInterval = 30 * time.Second
+	// webhookDispatchInterval is how often due webhook retries are looked for
+	webhookDispatchInterval = 5 * time.Second
+	// outboxDispatchInterval is how often the outbox is polled for changes
+	// committed by other replicas and for due retries
+	outboxDispatchInterval = time.Second
+	// changeFeedInterval is how often the outbox is read for changes to send
+	// to watchers, made by other replicas
+	changeFeedInterval = time.Second
+	// rateLimitRefreshInterval is how often rate limit overrides are
+	// reloaded to pick up those changed through other replicas
+	rateLimitRefreshInterval = 30 * time.Second
+	// idempotencyPurgeInterval is how often expired idempotency keys are
+	// removed
+	idempotencyPurgeInterval = time.Hour
 )
 
 func main() {
+	// Run a subcommand instead of the server when requested
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		}
+	}
+
 	// Load configuration
 	cfg := config.New()
 	cfg.ParseFlags()
@@ -31,25 +70,71 @@ func main() {
 	// Handle graceful shutdown
 	setupSignalHandler(cancel)
 
-	// Initialize database
-	database, err := db.New(cfg.DBPath)
+	// Initialize storage
+	repos, closeStore, err := openStore(cfg)
 	if err != nil {
-		log.Fatal().Err(err).Str("path", cfg.DBPath).Msg("Failed to initialize database")
+		log.Fatal().Err(err).Str("driver", cfg.DBDriver).Msg("Failed to initialize storage")
 	}
-	defer database.Close()
-	log.Info().Str("path", cfg.DBPath).Msg("Database initialized")
-
-	// Create repositories
-	eventRepo := db.NewEventRepository(database)
-	userRepo := db.NewUserRepository(database)
-	apiKeyRepo := db.NewAPIKeyRepository(database)
+	defer closeStore()
 
 	// Create services
-	eventService := service.NewEventService(eventRepo)
-	authService := auth.NewAuthService(userRepo, apiKeyRepo)
+	auditService := audit.NewAuditService(repos.Audit)
+	rewardService := service.NewRewardService(repos.RewardCatalog, auditService)
+	webhookService := webhook.NewService(repos.Webhooks, auditService, clock.System, nil)
+	sinks, closeSinks, err := outboxSinks(cfg, webhookService)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up outbox sinks")
+	}
+	defer closeSinks()
+	outboxService := outbox.NewService(repos.Outbox, sinks, auditService, clock.System)
+	eventService := service.NewEventService(repos.Events, repos.EventRevisions, repos.Outbox, repos.Transactor, rewardService, auditService, clock.System, outboxService)
+	authService := auth.NewAuthService(repos.Users, repos.APIKeys, auditService, cfg.APIKeyPepper)
+	idempotencyService := idempotency.NewService(repos.Idempotency, clock.System, cfg.IdempotencyTTL)
+
+	// Hash API keys stored before hashing was introduced
+	if cfg.APIKeyPepper == "" {
+		log.Warn().Msg("LIVEOPS_API_KEY_PEPPER is not set; API key hashes are not protected by a secret")
+	}
+	if count, err := authService.HashLegacyKeys(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to hash legacy API keys")
+	} else if count > 0 {
+		log.Info().Int("count", count).Msg("Hashed legacy API keys")
+	}
+
+	// Load rate limit overrides
+	limiter := ratelimit.NewLimiter(repos.RateLimits, auditService, cfg.RateLimitPerMin)
+	if err := limiter.Load(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load rate limit overrides")
+	}
+	if !limiter.Enabled() {
+		log.Warn().Msg("Rate limiting is disabled")
+	}
+	go limiter.RunRefresher(ctx, rateLimitRefreshInterval)
+
+	// Verify player tokens for the public API
+	var playerTokens *auth.PlayerTokenVerifier
+	if cfg.PlayerTokenSecret != "" {
+		playerTokens = auth.NewPlayerTokenVerifier(cfg.PlayerTokenSecret, cfg.PlayerTokenMaxTTL)
+	} else {
+		log.Warn().Msg("LIVEOPS_PLAYER_TOKEN_SECRET is not set; the public player API is disabled")
+	}
+
+	// Move published events through their start and end times
+	go eventService.RunStatusScheduler(ctx, statusSchedulerInterval)
+
+	// Send event changes to watchers, including those made by other replicas
+	go eventService.RunChangeFeed(ctx, changeFeedInterval)
+
+	// Send event changes from the outbox to the sinks, and post those queued
+	// for webhooks
+	go outboxService.RunDispatcher(ctx, outboxDispatchInterval)
+	go webhookService.RunDispatcher(ctx, webhookDispatchInterval)
+
+	// Forget the responses of idempotent requests once they expire
+	go idempotencyService.RunPurger(ctx, idempotencyPurgeInterval)
 
 	// Create and start server
-	server := api.NewServer(cfg.Port, eventService, authService)
+	server := api.NewServer(cfg.Port, eventService, authService, auditService, rewardService, limiter, playerTokens, webhookService, outboxService, idempotencyService, cfg.GRPCRequestAPIKey)
 	go func() {
 		if err := server.Start(); err != nil {
 			log.Fatal().Err(err).Msg("Server failed to start")
@@ -64,6 +149,73 @@ func main() {
 	server.Stop()
 }
 
+// openStore opens the storage backend selected by the configuration and
+// returns its repositories along with a function releasing it
+func openStore(cfg *config.Config) (*store.Store, func(), error) {
+	switch cfg.DBDriver {
+	case "sqlite":
+		database, err := db.New(cfg.DBPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Info().Str("path", cfg.DBPath).Msg("Database initialized")
+		return db.NewStore(database, clock.System), func() { database.Close() }, nil
+	case "postgres":
+		if cfg.DBDSN == "" {
+			return nil, nil, errors.New("LIVEOPS_DB_DSN is required for the postgres driver")
+		}
+		database, err := postgres.New(cfg.DBDSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Info().Msg("Database initialized")
+		return postgres.NewStore(database, clock.System), func() { database.Close() }, nil
+	case "memory":
+		log.Warn().Msg("Using the in-memory store; all data is lost when the server exits")
+		return memory.New(), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver %q", cfg.DBDriver)
+	}
+}
+
+// outboxSinks creates the outbox sinks selected by the configuration and
+// returns them along with a function releasing them
+func outboxSinks(cfg *config.Config, webhookService *webhook.Service) ([]outbox.Sink, func(), error) {
+	var sinks []outbox.Sink
+	var closers []func() error
+	closeAll := func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+
+	for _, name := range cfg.OutboxSinks {
+		switch name {
+		case "log":
+			sinks = append(sinks, outbox.LogSink{})
+		case "webhook":
+			sinks = append(sinks, outbox.NewWebhookSink(webhookService))
+		case "file":
+			sink, err := outbox.NewFileSink(cfg.OutboxFile)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			sinks = append(sinks, sink)
+			closers = append(closers, sink.Close)
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+
+	if len(sinks) == 0 {
+		log.Warn().Msg("No outbox sinks are configured; event changes are only recorded in the outbox")
+	}
+
+	return sinks, closeAll, nil
+}
+
 // configureLogging sets up the logger with the specified log level
 func configureLogging(level string) {
 	// Set up pretty console logging
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/config"
+	"github.com/tombombadilom/liveops/internal/db"
+	"github.com/tombombadilom/liveops/internal/db/postgres"
+)
+
+const migrateUsage = `Usage: liveops migrate [flags] <command>
+
+Migrates the SQLite database at -db, or with -store=postgres the PostgreSQL
+database at LIVEOPS_DB_DSN.
+
+Commands:
+  up            apply all pending migrations
+  down          roll back the most recent migration
+  status        list migrations and whether they are applied
+  to <version>  migrate up or down to the given version
+
+Flags:
+`
+
+// runMigrate implements the migrate subcommand and returns the exit code
+func runMigrate(args []string) int {
+	cfg := config.New()
+
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.StringVar(&cfg.DBDriver, "store", cfg.DBDriver, "Database driver (sqlite, postgres)")
+	fs.StringVar(&cfg.DBPath, "db", cfg.DBPath, "SQLite database path")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level (debug, info, warn, error)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), migrateUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	configureLogging(cfg.LogLevel)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	migrator, closeDB, err := openMigrator(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	defer closeDB()
+
+	switch command := fs.Arg(0); command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "to":
+		if fs.NArg() != 2 {
+			fs.Usage()
+			return 2
+		}
+		version, convErr := strconv.Atoi(fs.Arg(1))
+		if convErr != nil {
+			fmt.Fprintf(os.Stderr, "invalid version %q\n", fs.Arg(1))
+			return 2
+		}
+		err = migrator.MigrateTo(version)
+	case "status":
+		err = printMigrationStatus(migrator)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n", command)
+		fs.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+		return 1
+	}
+
+	if fs.Arg(0) != "status" {
+		version, err := migrator.Version()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read schema version: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Schema is at version %d (latest %d)\n", version, migrator.LatestVersion())
+	}
+
+	return 0
+}
+
+// openMigrator opens the database selected by the configuration and returns
+// the migrator of its driver along with a function closing the database
+func openMigrator(cfg *config.Config) (*db.Migrator, func(), error) {
+	var conn *sql.DB
+	var newMigrator func() (*db.Migrator, error)
+
+	switch cfg.DBDriver {
+	case "sqlite":
+		database, err := db.Open(cfg.DBPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		conn = database.DB
+		newMigrator = func() (*db.Migrator, error) { return db.NewMigrator(database) }
+	case "postgres":
+		if cfg.DBDSN == "" {
+			return nil, nil, errors.New("LIVEOPS_DB_DSN is required for the postgres driver")
+		}
+		database, err := postgres.Open(cfg.DBDSN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		conn = database.DB
+		newMigrator = func() (*db.Migrator, error) { return postgres.NewMigrator(database) }
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver %q", cfg.DBDriver)
+	}
+
+	migrator, err := newMigrator()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return migrator, func() { conn.Close() }, nil
+}
+
+// printMigrationStatus writes a table of migrations to stdout
+func printMigrationStatus(migrator *db.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+			if s.Modified {
+				state = "modified"
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,434 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/service"
+)
+
+// maxImportBytes bounds the size of import bodies
+const maxImportBytes = 32 << 20
+
+// Import and export formats
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// formatContentTypes maps the formats to their media types
+var formatContentTypes = map[string]string{
+	formatJSON:   "application/json",
+	formatNDJSON: "application/x-ndjson",
+	formatCSV:    "text/csv",
+}
+
+// csvColumns lists the columns of CSV exports, in order. Rewards are
+// embedded as JSON. Imports accept the columns in any order, match events
+// without an external ID by their ID, and ignore the status and version,
+// which they cannot set.
+var csvColumns = []string{"id", "external_id", "title", "description", "start_time", "end_time", "rewards", "recurrence", "targeting", "status", "version"}
+
+// importEvents handles POST /api/events/import. The body is a JSON array,
+// NDJSON or CSV, chosen by the format query parameter or the content type.
+func (s *HTTPServer) importEvents(c *gin.Context) {
+	// Get user from context
+	user := c.MustGet("user").(*models.User)
+
+	// Rows may create or update events
+	for _, action := range []string{"create", "update"} {
+		if err := s.authService.CheckPermission(user, currentAPIKey(c), action); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+	}
+
+	// Parse query parameters
+	var query struct {
+		Format string             `form:"format"`
+		Mode   service.ImportMode `form:"mode"`
+		DryRun bool               `form:"dry_run"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Mode != "" && !query.Mode.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import mode"})
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = formatFromContentType(c.ContentType())
+	}
+	if _, ok := formatContentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import format"})
+		return
+	}
+
+	// Decode rows
+	rows, err := decodeImportRows(format, http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// Import events
+	report, err := s.eventService.ImportEvents(c.Request.Context(), rows, service.ImportOptions{Mode: query.Mode, DryRun: query.DryRun})
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyImportRows) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// An atomic import with failing rows changed nothing
+	if !report.Committed && !report.DryRun {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// formatFromContentType returns the import format of a media type, JSON
+// unless it is NDJSON or CSV
+func formatFromContentType(contentType string) string {
+	switch contentType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return formatNDJSON
+	case "text/csv":
+		return formatCSV
+	default:
+		return formatJSON
+	}
+}
+
+// decodeImportRows reads the rows of an import. Rows that cannot be decoded
+// carry their error, while a body that cannot be read as a whole fails.
+func decodeImportRows(format string, r io.Reader) ([]service.ImportRow, error) {
+	switch format {
+	case formatNDJSON:
+		return decodeNDJSONRows(r)
+	case formatCSV:
+		return decodeCSVRows(r)
+	default:
+		return decodeJSONRows(r)
+	}
+}
+
+// decodeJSONRows reads a JSON array of events shaped like creation requests
+func decodeJSONRows(r io.Reader) ([]service.ImportRow, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid JSON array: %w", err)
+	}
+
+	rows := make([]service.ImportRow, len(items))
+	for i, item := range items {
+		rows[i] = decodeJSONRow(item)
+	}
+
+	return rows, nil
+}
+
+// decodeNDJSONRows reads an event shaped like a creation request from every
+// non-blank line
+func decodeNDJSONRows(r io.Reader) ([]service.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxImportBytes)
+
+	var rows []service.ImportRow
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		rows = append(rows, decodeJSONRow(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+
+	return rows, nil
+}
+
+// decodeJSONRow decodes a single JSON event. Like CSV, rewards are read from
+// the rewards JSON, so that exported events, which also carry the structured
+// rewards derived from it, import unchanged; structured rewards are only
+// used without it.
+func decodeJSONRow(data []byte) service.ImportRow {
+	var req struct {
+		eventRequest
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return service.ImportRow{Err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	if req.Rewards != "" {
+		req.StructuredRewards = nil
+	}
+
+	id, err := parseImportID(req.ID)
+	return service.ImportRow{ID: id, Fields: req.fields(), Err: err}
+}
+
+// parseImportID parses the event ID of an import row, which may be empty
+func parseImportID(raw string) (uuid.UUID, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %q", models.ErrInvalidID, raw)
+	}
+	return id, nil
+}
+
+// decodeCSVRows reads events from CSV with a header row naming the columns
+func decodeCSVRows(r io.Reader) ([]service.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// A leading byte order mark, as spreadsheets write, is not part of
+		// the first column name
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"title", "start_time", "end_time"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	var rows []service.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(record) != len(header) {
+			rows = append(rows, service.ImportRow{Err: fmt.Errorf("row has %d fields, want %d", len(record), len(header))})
+			continue
+		}
+
+		rows = append(rows, decodeCSVRow(record, columns))
+	}
+
+	return rows, nil
+}
+
+// decodeCSVRow decodes a single CSV record
+func decodeCSVRow(record []string, columns map[string]int) service.ImportRow {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	fields := models.EventFields{
+		ExternalID:  value("external_id"),
+		Title:       value("title"),
+		Description: value("description"),
+		Rewards:     value("rewards"),
+		Recurrence:  value("recurrence"),
+		Targeting:   value("targeting"),
+	}
+
+	id, err := parseImportID(value("id"))
+	if err != nil {
+		return service.ImportRow{Fields: fields, Err: err}
+	}
+
+	for _, t := range []struct {
+		name string
+		dest *time.Time
+	}{
+		{"start_time", &fields.StartTime},
+		{"end_time", &fields.EndTime},
+	} {
+		raw := strings.TrimSpace(value(t.name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return service.ImportRow{ID: id, Fields: fields, Err: fmt.Errorf("invalid %s: %q is not an RFC 3339 time", t.name, raw)}
+		}
+		*t.dest = parsed
+	}
+
+	return service.ImportRow{ID: id, Fields: fields}
+}
+
+// exportEvents handles GET /api/events/export, writing the events matching
+// the query parameters, ordered by start time, as a JSON array, NDJSON or
+// CSV
+func (s *HTTPServer) exportEvents(c *gin.Context) {
+	// Parse query parameters
+	var query struct {
+		Format      string    `form:"format"`
+		Status      []string  `form:"status"`
+		StartsAfter time.Time `form:"starts_after" time_format:"2006-01-02T15:04:05Z07:00"`
+		EndsBefore  time.Time `form:"ends_before" time_format:"2006-01-02T15:04:05Z07:00"`
+		Title       string    `form:"title"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = formatJSON
+	}
+	contentType, ok := formatContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export format"})
+		return
+	}
+
+	filter := models.EventFilter{
+		StartsAfter: query.StartsAfter,
+		EndsBefore:  query.EndsBefore,
+		Title:       query.Title,
+	}
+	for _, value := range query.Status {
+		for _, status := range strings.Split(value, ",") {
+			if !models.EventStatus(status).IsValid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event status"})
+				return
+			}
+			filter.Statuses = append(filter.Statuses, models.EventStatus(status))
+		}
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "events." + format}))
+	c.Status(http.StatusOK)
+
+	// The status is sent with the first event, so later failures can only
+	// cut the export short
+	var err error
+	switch format {
+	case formatCSV:
+		err = exportCSV(c.Writer, s.eventService, filter)
+	case formatNDJSON:
+		err = exportNDJSON(c.Writer, s.eventService, filter)
+	default:
+		err = exportJSON(c.Writer, s.eventService, filter)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("format", format).Msg("Failed to export events")
+	}
+}
+
+// exportJSON writes the events matching the filter as a JSON array
+func exportJSON(w io.Writer, eventService *service.EventService, filter models.EventFilter) error {
+	separator := "["
+	err := eventService.ExportEvents(filter, func(event *models.LiveEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, separator); err != nil {
+			return err
+		}
+		separator = ","
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if separator == "[" {
+		_, err = io.WriteString(w, "[]\n")
+	} else {
+		_, err = io.WriteString(w, "]\n")
+	}
+	return err
+}
+
+// exportNDJSON writes the events matching the filter as a line of JSON each
+func exportNDJSON(w io.Writer, eventService *service.EventService, filter models.EventFilter) error {
+	encoder := json.NewEncoder(w)
+	return eventService.ExportEvents(filter, func(event *models.LiveEvent) error {
+		return encoder.Encode(event)
+	})
+}
+
+// exportCSV writes the events matching the filter as CSV with a header row
+func exportCSV(w io.Writer, eventService *service.EventService, filter models.EventFilter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+
+	err := eventService.ExportEvents(filter, func(event *models.LiveEvent) error {
+		return writer.Write([]string{
+			event.ID.String(),
+			event.ExternalID,
+			event.Title,
+			event.Description,
+			event.StartTime.UTC().Format(time.RFC3339Nano),
+			event.EndTime.UTC().Format(time.RFC3339Nano),
+			event.Rewards,
+			event.Recurrence,
+			event.Targeting,
+			string(event.Status),
+			strconv.FormatInt(event.Version, 10),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
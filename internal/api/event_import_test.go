@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/service"
+)
+
+func TestImportExportedEvents(t *testing.T) {
+	server := newTestServer(t, clock.System)
+	checkStatus(t, server.do(http.MethodPost, "/api/events", eventBody("Summer festival"), adminHeader), http.StatusCreated)
+
+	// Exports of events without an external ID import unchanged, in every
+	// format, rather than as new events
+	for _, format := range []string{formatJSON, formatNDJSON, formatCSV} {
+		export := server.do(http.MethodGet, "/api/events/export?format="+format, "", adminHeader)
+		checkStatus(t, export, http.StatusOK)
+
+		rec := server.do(http.MethodPost, "/api/events/import?format="+format, export.Body.String(), adminHeader)
+		checkStatus(t, rec, http.StatusOK)
+
+		var report service.ImportReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("%s: failed to decode report: %v", format, err)
+		}
+		if report.Unchanged != 1 || report.Created != 0 {
+			t.Errorf("%s: got report %+v, want the event unchanged", format, report)
+		}
+	}
+
+	events, _, err := server.eventService.ListEvents(models.EventFilter{}, models.DefaultEventSort, 0, "")
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("got %d events, want 1", len(events))
+	}
+
+	// Malformed IDs fail their row
+	rec := server.do(http.MethodPost, "/api/events/import?format=csv", "id,title,start_time,end_time\nnot-an-id,Winter festival,2030-01-01T00:00:00Z,2030-01-02T00:00:00Z\n", adminHeader)
+	checkStatus(t, rec, http.StatusUnprocessableEntity)
+}
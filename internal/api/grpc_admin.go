@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/models"
+	pb "github.com/tombombadilom/liveops/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// adminError converts user and key management errors to gRPC status errors
+func adminError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrInvalidID), errors.Is(err, models.ErrInvalidRole),
+		errors.Is(err, models.ErrInvalidScope), errors.Is(err, models.ErrEmptyUsername):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrUserNotFound), errors.Is(err, models.ErrAPIKeyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrUsernameExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, models.ErrLastAdmin):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// userToProto converts a user model to its protobuf representation
+func userToProto(user *models.User) *pb.User {
+	return &pb.User{
+		Id:        user.ID.String(),
+		Username:  user.Username,
+		Role:      string(user.Role),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}
+}
+
+// apiKeyToProto converts an API key model to its protobuf representation
+func apiKeyToProto(key *models.APIKey) *pb.APIKey {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return &pb.APIKey{
+		Id:        key.ID.String(),
+		UserId:    key.UserID.String(),
+		Prefix:    key.Prefix,
+		Key:       key.Key,
+		Scopes:    scopes,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+		ExpiresAt: timestamppb.New(key.ExpiresAt),
+		LastUsed:  timestamppb.New(key.LastUsed),
+	}
+}
+
+// auditEntryToProto converts an audit entry to its protobuf representation
+func auditEntryToProto(entry *models.AuditEntry) *pb.AuditEntry {
+	pbEntry := &pb.AuditEntry{
+		Id:         entry.ID.String(),
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetID,
+		Before:     string(entry.Before),
+		After:      string(entry.After),
+		RequestId:  entry.RequestID,
+		Transport:  entry.Transport,
+		CreatedAt:  timestamppb.New(entry.CreatedAt),
+	}
+	if entry.ActorUserID != nil {
+		pbEntry.ActorUserId = entry.ActorUserID.String()
+	}
+	if entry.ActorAPIKeyID != nil {
+		pbEntry.ActorApiKeyId = entry.ActorAPIKeyID.String()
+	}
+	return pbEntry
+}
+
+// ListAuditEntries implements the gRPC ListAuditEntries method
+func (s *GRPCServer) ListAuditEntries(ctx context.Context, req *pb.ListAuditEntriesRequest) (*pb.ListAuditEntriesResponse, error) {
+	// Build filter
+	filter := models.AuditFilter{
+		TargetType: req.TargetType,
+		TargetID:   req.TargetId,
+		Action:     req.Action,
+	}
+	if req.ActorUserId != "" {
+		id, err := uuid.Parse(req.ActorUserId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid actor user ID")
+		}
+		filter.ActorUserID = &id
+	}
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		filter.Until = req.Until.AsTime()
+	}
+
+	// Query audit log
+	entries, nextPageToken, err := s.auditService.ListEntries(filter, int(req.PageSize), req.PageToken)
+	if err != nil {
+		if errors.Is(err, audit.ErrInvalidPageToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Convert to protobuf response
+	pbEntries := make([]*pb.AuditEntry, len(entries))
+	for i, entry := range entries {
+		pbEntries[i] = auditEntryToProto(entry)
+	}
+
+	return &pb.ListAuditEntriesResponse{
+		Entries:       pbEntries,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// ListUsers implements the gRPC ListUsers method
+func (s *GRPCServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	users, err := s.authService.ListUsers()
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	// Convert to protobuf response
+	pbUsers := make([]*pb.User, len(users))
+	for i, user := range users {
+		pbUsers[i] = userToProto(user)
+	}
+
+	return &pb.ListUsersResponse{Users: pbUsers}, nil
+}
+
+// GetUser implements the gRPC GetUser method
+func (s *GRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := s.authService.GetUser(req.Id)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	return userToProto(user), nil
+}
+
+// CreateUser implements the gRPC CreateUser method
+func (s *GRPCServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	user, err := s.authService.CreateUser(ctx, req.Username, models.Role(req.Role))
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	return userToProto(user), nil
+}
+
+// UpdateUserRole implements the gRPC UpdateUserRole method
+func (s *GRPCServer) UpdateUserRole(ctx context.Context, req *pb.UpdateUserRoleRequest) (*pb.User, error) {
+	user, err := s.authService.UpdateUserRole(ctx, req.Id, models.Role(req.Role))
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	return userToProto(user), nil
+}
+
+// DeleteUser implements the gRPC DeleteUser method
+func (s *GRPCServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := s.authService.DeleteUser(ctx, req.Id); err != nil {
+		return nil, adminError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListAPIKeys implements the gRPC ListAPIKeys method
+func (s *GRPCServer) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	keys, err := s.authService.ListAPIKeys(req.UserId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	// Convert to protobuf response
+	pbKeys := make([]*pb.APIKey, len(keys))
+	for i, key := range keys {
+		pbKeys[i] = apiKeyToProto(key)
+	}
+
+	return &pb.ListAPIKeysResponse{Keys: pbKeys}, nil
+}
+
+// CreateAPIKey implements the gRPC CreateAPIKey method
+func (s *GRPCServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.APIKey, error) {
+	if req.ValidDays < 1 || req.ValidDays > 365 {
+		return nil, status.Error(codes.InvalidArgument, "valid_days must be between 1 and 365")
+	}
+
+	scopes := make([]models.Scope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = models.Scope(scope)
+	}
+
+	key, err := s.authService.CreateAPIKey(ctx, req.UserId, int(req.ValidDays), scopes)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	return apiKeyToProto(key), nil
+}
+
+// RevokeAPIKey implements the gRPC RevokeAPIKey method
+func (s *GRPCServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*emptypb.Empty, error) {
+	if err := s.authService.RevokeAPIKey(ctx, req.Id); err != nil {
+		return nil, adminError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
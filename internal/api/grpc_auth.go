@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/models"
+	pb "github.com/tombombadilom/liveops/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodPolicies maps every gRPC method to the action the caller needs
+// permission for. Methods missing from the table are denied.
+var methodPolicies = map[string]string{
+	pb.EventService_ListEvents_FullMethodName:         "read",
+	pb.EventService_ListEligibleEvents_FullMethodName: "read",
+	pb.EventService_GetEvent_FullMethodName:           "read",
+	pb.EventService_ListOccurrences_FullMethodName:    "read",
+	pb.EventService_WatchEvents_FullMethodName:        "read",
+	pb.EventService_ListEventRevisions_FullMethodName: "read",
+	pb.EventService_GetEventRevision_FullMethodName:   "read",
+	pb.EventService_DiffEventRevisions_FullMethodName: "read",
+	pb.EventService_CreateEvent_FullMethodName:        "create",
+	pb.EventService_UpdateEvent_FullMethodName:        "update",
+	pb.EventService_PublishEvent_FullMethodName:       "update",
+	pb.EventService_UnpublishEvent_FullMethodName:     "update",
+	pb.EventService_CancelEvent_FullMethodName:        "update",
+	pb.EventService_ArchiveEvent_FullMethodName:       "update",
+	pb.EventService_RevertEvent_FullMethodName:        "update",
+	pb.EventService_DeleteEvent_FullMethodName:        "delete",
+	pb.EventService_BatchCreateEvents_FullMethodName:  "create",
+	pb.EventService_BatchUpdateEvents_FullMethodName:  "update",
+	pb.EventService_BatchDeleteEvents_FullMethodName:  "delete",
+
+	pb.AdminService_ListAuditEntries_FullMethodName: "admin:audit",
+	pb.AdminService_ListUsers_FullMethodName:        "admin:users",
+	pb.AdminService_GetUser_FullMethodName:          "admin:users",
+	pb.AdminService_CreateUser_FullMethodName:       "admin:users",
+	pb.AdminService_UpdateUserRole_FullMethodName:   "admin:users",
+	pb.AdminService_DeleteUser_FullMethodName:       "admin:users",
+	pb.AdminService_ListAPIKeys_FullMethodName:      "admin:keys",
+	pb.AdminService_CreateAPIKey_FullMethodName:     "admin:keys",
+	pb.AdminService_RevokeAPIKey_FullMethodName:     "admin:keys",
+}
+
+// callerKey is the context key of the authenticated caller
+type callerKey struct{}
+
+// caller is the outcome of authenticating a call
+type caller struct {
+	user   *models.User
+	apiKey *models.APIKey
+	err    error
+}
+
+// callerFromContext returns the user and API key a call was authenticated
+// with, or the Unauthenticated error explaining why it was not
+func callerFromContext(ctx context.Context) (*models.User, *models.APIKey, error) {
+	c, ok := ctx.Value(callerKey{}).(*caller)
+	if !ok {
+		return nil, nil, status.Error(codes.Unauthenticated, "API key required")
+	}
+	return c.user, c.apiKey, c.err
+}
+
+// apiKeyRequest is implemented by requests carrying an api_key field
+type apiKeyRequest interface {
+	GetApiKey() string
+}
+
+// authenticateInterceptor resolves the caller of unary calls. Failures are
+// recorded rather than returned, so that rate limiting can fall back to the
+// client IP before authorizeInterceptor rejects the call.
+func (s *GRPCServer) authenticateInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(s.resolveCaller(ctx, req), req)
+}
+
+// authenticateStreamInterceptor resolves the caller of streaming calls from
+// their metadata
+func (s *GRPCServer) authenticateStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: s.resolveCaller(ss.Context(), nil)})
+}
+
+// authorizeInterceptor rejects unary calls whose caller is not authenticated
+// or lacks the permission the method policy requires
+func (s *GRPCServer) authorizeInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authorizeStreamInterceptor applies the method policy to streaming calls
+func (s *GRPCServer) authorizeStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize checks the caller in ctx against the policy of a method
+func (s *GRPCServer) authorize(ctx context.Context, method string) error {
+	action, ok := methodPolicies[method]
+	if !ok {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	user, key, err := callerFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authService.CheckPermission(user, key, action); err != nil {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return nil
+}
+
+// resolveCaller authenticates the API key of a call and stores the outcome
+// in the returned context, attributing subsequent operations to the caller
+func (s *GRPCServer) resolveCaller(ctx context.Context, req interface{}) context.Context {
+	apiKey := apiKeyFromMetadata(ctx)
+	if apiKey == "" && s.allowRequestAPIKey {
+		if r, ok := req.(apiKeyRequest); ok {
+			apiKey = r.GetApiKey()
+		}
+	}
+
+	if apiKey == "" {
+		return context.WithValue(ctx, callerKey{}, &caller{err: status.Error(codes.Unauthenticated, "API key required")})
+	}
+
+	// Leave the key unchecked once the client IP has used up its budget, so
+	// that the call is rejected by rate limiting before keys can be tried
+	if !s.limiter.CheckIP(peerIP(ctx)).Allowed {
+		return context.WithValue(ctx, callerKey{}, &caller{err: status.Error(codes.Unauthenticated, "API key not checked")})
+	}
+
+	user, key, err := s.authService.AuthenticateAPIKey(apiKey)
+	if err != nil {
+		return context.WithValue(ctx, callerKey{}, &caller{err: status.Error(codes.Unauthenticated, "invalid API key")})
+	}
+
+	ctx = context.WithValue(ctx, callerKey{}, &caller{user: user, apiKey: key})
+	return audit.WithActor(ctx, audit.NewActor(user, key, requestIDFromContext(ctx), models.TransportGRPC))
+}
+
+// apiKeyFromMetadata returns the API key of the x-api-key header, or else the
+// bearer token of the authorization header
+func apiKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get("x-api-key"); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+
+	const bearerPrefix = "bearer "
+	for _, value := range md.Get("authorization") {
+		if len(value) > len(bearerPrefix) && strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(value[len(bearerPrefix):])
+		}
+	}
+
+	return ""
+}
+
+// contextServerStream overrides the context of a server stream
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the overridden context
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/service"
+	pb "github.com/tombombadilom/liveops/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BatchCreateEvents implements the gRPC BatchCreateEvents method
+func (s *GRPCServer) BatchCreateEvents(ctx context.Context, req *pb.BatchCreateEventsRequest) (*pb.BatchEventsResponse, error) {
+	items := make([]models.EventFields, len(req.Events))
+	for i, event := range req.Events {
+		items[i] = createFieldsFromProto(event)
+	}
+
+	results, err := s.eventService.BatchCreateEvents(ctx, items, req.Atomic)
+	if err != nil {
+		return nil, batchError(err)
+	}
+	return batchResponse(results), nil
+}
+
+// BatchUpdateEvents implements the gRPC BatchUpdateEvents method
+func (s *GRPCServer) BatchUpdateEvents(ctx context.Context, req *pb.BatchUpdateEventsRequest) (*pb.BatchEventsResponse, error) {
+	items := make([]service.BatchUpdate, len(req.Events))
+	for i, event := range req.Events {
+		items[i] = service.BatchUpdate{ID: event.Id, Fields: updateFieldsFromProto(event), ExpectedVersion: event.ExpectedVersion}
+	}
+
+	results, err := s.eventService.BatchUpdateEvents(ctx, items, req.Atomic)
+	if err != nil {
+		return nil, batchError(err)
+	}
+	return batchResponse(results), nil
+}
+
+// BatchDeleteEvents implements the gRPC BatchDeleteEvents method
+func (s *GRPCServer) BatchDeleteEvents(ctx context.Context, req *pb.BatchDeleteEventsRequest) (*pb.BatchEventsResponse, error) {
+	items := make([]service.BatchDelete, len(req.Events))
+	for i, event := range req.Events {
+		items[i] = service.BatchDelete{ID: event.Id, ExpectedVersion: event.ExpectedVersion}
+	}
+
+	results, err := s.eventService.BatchDeleteEvents(ctx, items, req.Atomic)
+	if err != nil {
+		return nil, batchError(err)
+	}
+	return batchResponse(results), nil
+}
+
+// batchResponse converts the results of a batch to their protobuf
+// representation
+func batchResponse(results []service.BatchResult) *pb.BatchEventsResponse {
+	response := &pb.BatchEventsResponse{Results: make([]*pb.BatchEventResult, len(results))}
+	for i, result := range results {
+		if result.Err != nil {
+			st := batchItemStatus(result.Err)
+			response.Results[i] = &pb.BatchEventResult{Code: int32(st.Code()), Error: st.Message()}
+			continue
+		}
+		response.Results[i] = &pb.BatchEventResult{Event: eventToProto(result.Event)}
+	}
+	return response
+}
+
+// batchError converts the error failing a batch to a gRPC status, naming the
+// event that caused it
+func batchError(err error) error {
+	var batchErr *service.BatchError
+	switch {
+	case errors.As(err, &batchErr):
+		st := batchItemStatus(batchErr.Err)
+		return status.Errorf(st.Code(), "event %d: %s", batchErr.Index, st.Message())
+	case errors.Is(err, service.ErrTooManyBatchItems):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// batchItemStatus converts the error of an event of a batch to a gRPC status
+func batchItemStatus(err error) *status.Status {
+	if errors.Is(err, models.ErrVersionConflict) {
+		return status.New(codes.FailedPrecondition, err.Error())
+	}
+	return status.Convert(revisionError(err))
+}
@@ -2,16 +2,20 @@ package api
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/audit"
 	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/idempotency"
 	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
 	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/targeting"
 	pb "github.com/tombombadilom/liveops/pkg/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -20,27 +24,43 @@ import (
 // GRPCServer handles gRPC API requests
 type GRPCServer struct {
 	pb.UnimplementedEventServiceServer
+	pb.UnimplementedAdminServiceServer
 	eventService *service.EventService
 	authService  *auth.AuthService
+	auditService *audit.AuditService
+	limiter      *ratelimit.Limiter
+	idempotency  *idempotency.Service
+	// allowRequestAPIKey accepts the api_key request field from clients that
+	// cannot set metadata
+	allowRequestAPIKey bool
 }
 
-// NewGRPCServer creates a new gRPC server
-func NewGRPCServer(eventService *service.EventService, authService *auth.AuthService) *GRPCServer {
+// NewGRPCServer creates a new gRPC server. Callers authenticate with API key
+// metadata, or with the api_key request field when allowRequestAPIKey is set.
+func NewGRPCServer(eventService *service.EventService, authService *auth.AuthService, auditService *audit.AuditService, limiter *ratelimit.Limiter, idempotencyService *idempotency.Service, allowRequestAPIKey bool) *GRPCServer {
 	return &GRPCServer{
-		eventService: eventService,
-		authService:  authService,
+		eventService:       eventService,
+		authService:        authService,
+		auditService:       auditService,
+		limiter:            limiter,
+		idempotency:        idempotencyService,
+		allowRequestAPIKey: allowRequestAPIKey,
 	}
 }
 
 // Server returns a configured gRPC server
 func (s *GRPCServer) Server() *grpc.Server {
-	// Create gRPC server with interceptors
+	// Create gRPC server with interceptors. Callers are authenticated before
+	// rate limiting, which is per API key, and authorized after it; retries
+	// of authorized calls with an idempotency key are answered last.
 	server := grpc.NewServer(
-		grpc.UnaryInterceptor(s.loggingInterceptor),
+		grpc.ChainUnaryInterceptor(s.requestIDInterceptor, s.loggingInterceptor, s.authenticateInterceptor, s.rateLimitInterceptor, s.authorizeInterceptor, s.idempotencyInterceptor),
+		grpc.ChainStreamInterceptor(s.authenticateStreamInterceptor, s.rateLimitStreamInterceptor, s.authorizeStreamInterceptor),
 	)
 
 	// Register services
 	pb.RegisterEventServiceServer(server, s)
+	pb.RegisterAdminServiceServer(server, s)
 
 	return server
 }
@@ -61,6 +81,7 @@ func (s *GRPCServer) loggingInterceptor(ctx context.Context, req interface{}, in
 	}
 
 	log.Info().
+		Str("request_id", requestIDFromContext(ctx)).
 		Str("method", method).
 		Dur("latency", latency).
 		Str("status", status).
@@ -69,76 +90,163 @@ func (s *GRPCServer) loggingInterceptor(ctx context.Context, req interface{}, in
 	return resp, err
 }
 
-// authenticate checks the API key in the request
-func (s *GRPCServer) authenticate(ctx context.Context) (*models.User, error) {
-	// Get API key from metadata
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+// eventToProto converts an event model to its protobuf representation
+func eventToProto(event *models.LiveEvent) *pb.Event {
+	pbRewards := make([]*pb.Reward, len(event.StructuredRewards))
+	for i, reward := range event.StructuredRewards {
+		pbRewards[i] = &pb.Reward{
+			Type:      string(reward.Type),
+			Id:        reward.ID,
+			Quantity:  reward.Quantity,
+			Tier:      reward.Tier,
+			Threshold: reward.Threshold,
+		}
 	}
 
-	apiKeys := md.Get("x-api-key")
-	if len(apiKeys) == 0 {
-		return nil, status.Error(codes.Unauthenticated, "API key required")
+	return &pb.Event{
+		Id:                event.ID.String(),
+		ExternalId:        event.ExternalID,
+		Title:             event.Title,
+		Description:       event.Description,
+		StartTime:         timestamppb.New(event.StartTime),
+		EndTime:           timestamppb.New(event.EndTime),
+		Rewards:           event.Rewards,
+		Recurrence:        event.Recurrence,
+		Targeting:         event.Targeting,
+		Status:            string(event.Status),
+		StructuredRewards: pbRewards,
+		Version:           event.Version,
 	}
+}
 
-	// Authenticate API key
-	user, err := s.authService.AuthenticateAPIKey(apiKeys[0])
-	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+// rewardsFromProto converts protobuf rewards to the reward model
+func rewardsFromProto(pbRewards []*pb.Reward) []models.Reward {
+	if len(pbRewards) == 0 {
+		return nil
 	}
 
-	return user, nil
-}
-
-// checkPermission checks if the user has permission for an action
-func (s *GRPCServer) checkPermission(user *models.User, action string) error {
-	if err := s.authService.CheckPermission(user, action); err != nil {
-		return status.Error(codes.PermissionDenied, "permission denied")
+	rewards := make([]models.Reward, len(pbRewards))
+	for i, reward := range pbRewards {
+		rewards[i] = models.Reward{
+			Type:      models.RewardType(reward.Type),
+			ID:        reward.Id,
+			Quantity:  reward.Quantity,
+			Tier:      reward.Tier,
+			Threshold: reward.Threshold,
+		}
 	}
-	return nil
+	return rewards
 }
 
 // ListEvents implements the gRPC ListEvents method
 func (s *GRPCServer) ListEvents(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
-	// Authenticate request
-	_, err := s.authenticate(ctx)
+	// Build filter
+	filter := models.EventFilter{Title: req.Title}
+	if req.StartsAfter != nil {
+		filter.StartsAfter = req.StartsAfter.AsTime()
+	}
+	if req.EndsBefore != nil {
+		filter.EndsBefore = req.EndsBefore.AsTime()
+	}
+	if req.ActiveAt != nil {
+		filter.ActiveAt = req.ActiveAt.AsTime()
+	}
+	if req.AsOf != nil && !req.ActiveOnly {
+		return nil, status.Error(codes.InvalidArgument, "as_of only applies to active_only; use active_at")
+	}
+	asOf, err := s.previewTime(ctx, req.AsOf)
 	if err != nil {
 		return nil, err
 	}
+	if req.ActiveOnly {
+		active := s.eventService.ActiveFilter(asOf)
+		filter.Statuses, filter.ActiveAt = active.Statuses, active.ActiveAt
+	}
 
 	// Get events from service
-	events, err := s.eventService.ListEvents(req.ActiveOnly)
+	events, nextPageToken, err := s.eventService.ListEvents(filter, models.EventSort(req.Sort), int(req.PageSize), req.PageToken)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidSort) || errors.Is(err, models.ErrInvalidPageToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	// Convert to protobuf response
 	pbEvents := make([]*pb.Event, len(events))
 	for i, event := range events {
-		pbEvents[i] = &pb.Event{
-			Id:          event.ID.String(),
-			Title:       event.Title,
-			Description: event.Description,
-			StartTime:   timestamppb.New(event.StartTime),
-			EndTime:     timestamppb.New(event.EndTime),
-			Rewards:     event.Rewards,
-		}
+		pbEvents[i] = eventToProto(event)
 	}
 
 	return &pb.ListEventsResponse{
-		Events: pbEvents,
+		Events:        pbEvents,
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
-// GetEvent implements the gRPC GetEvent method
-func (s *GRPCServer) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.Event, error) {
-	// Authenticate request
-	_, err := s.authenticate(ctx)
+// previewTime returns the as_of instant of a request, or the zero time for
+// now. Only callers allowed to preview the schedule may set it.
+func (s *GRPCServer) previewTime(ctx context.Context, asOf *timestamppb.Timestamp) (time.Time, error) {
+	if asOf == nil {
+		return time.Time{}, nil
+	}
+
+	user, key, err := callerFromContext(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.authService.CheckPermission(user, key, "preview"); err != nil {
+		return time.Time{}, status.Error(codes.PermissionDenied, "as_of requires the editor or admin role")
+	}
+	if err := asOf.CheckValid(); err != nil {
+		return time.Time{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return asOf.AsTime(), nil
+}
+
+// ListEligibleEvents implements the gRPC ListEligibleEvents method
+func (s *GRPCServer) ListEligibleEvents(ctx context.Context, req *pb.ListEligibleEventsRequest) (*pb.ListEventsResponse, error) {
+	// Build player context
+	player := &targeting.Player{}
+	if req.Player != nil {
+		player = &targeting.Player{
+			Level:      int(req.Player.Level),
+			Region:     req.Player.Region,
+			Platform:   req.Player.Platform,
+			AppVersion: req.Player.AppVersion,
+			Cohorts:    req.Player.Cohorts,
+		}
+	}
+
+	asOf, err := s.previewTime(ctx, req.AsOf)
 	if err != nil {
 		return nil, err
 	}
 
+	// Get events from service
+	events, nextPageToken, err := s.eventService.ListEligibleEvents(player, asOf, int(req.PageSize), req.PageToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPageToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Convert to protobuf response
+	pbEvents := make([]*pb.Event, len(events))
+	for i, event := range events {
+		pbEvents[i] = eventToProto(event)
+	}
+
+	return &pb.ListEventsResponse{
+		Events:        pbEvents,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// GetEvent implements the gRPC GetEvent method
+func (s *GRPCServer) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.Event, error) {
 	// Get event from service
 	event, err := s.eventService.GetEvent(req.Id)
 	if err != nil {
@@ -149,113 +257,338 @@ func (s *GRPCServer) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb
 	}
 
 	// Convert to protobuf response
-	return &pb.Event{
-		Id:          event.ID.String(),
-		Title:       event.Title,
-		Description: event.Description,
-		StartTime:   timestamppb.New(event.StartTime),
-		EndTime:     timestamppb.New(event.EndTime),
-		Rewards:     event.Rewards,
-	}, nil
+	return eventToProto(event), nil
 }
 
-// CreateEvent implements the gRPC CreateEvent method
-func (s *GRPCServer) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.Event, error) {
-	// Authenticate request
-	user, err := s.authenticate(ctx)
-	if err != nil {
-		return nil, err
+// createFieldsFromProto returns the event fields of a create request
+func createFieldsFromProto(req *pb.CreateEventRequest) models.EventFields {
+	return models.EventFields{
+		ExternalID:        req.ExternalId,
+		Title:             req.Title,
+		Description:       req.Description,
+		StartTime:         req.StartTime.AsTime(),
+		EndTime:           req.EndTime.AsTime(),
+		Rewards:           req.Rewards,
+		StructuredRewards: rewardsFromProto(req.StructuredRewards),
+		Recurrence:        req.Recurrence,
+		Targeting:         req.Targeting,
 	}
+}
 
-	// Check permission
-	if err := s.checkPermission(user, "create"); err != nil {
-		return nil, err
+// updateFieldsFromProto returns the event fields of an update request
+func updateFieldsFromProto(req *pb.UpdateEventRequest) models.EventFields {
+	return models.EventFields{
+		ExternalID:        req.ExternalId,
+		Title:             req.Title,
+		Description:       req.Description,
+		StartTime:         req.StartTime.AsTime(),
+		EndTime:           req.EndTime.AsTime(),
+		Rewards:           req.Rewards,
+		StructuredRewards: rewardsFromProto(req.StructuredRewards),
+		Recurrence:        req.Recurrence,
+		Targeting:         req.Targeting,
 	}
+}
 
+// CreateEvent implements the gRPC CreateEvent method
+func (s *GRPCServer) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.Event, error) {
 	// Create event
-	event, err := s.eventService.CreateEvent(
-		req.Title,
-		req.Description,
-		req.StartTime.AsTime(),
-		req.EndTime.AsTime(),
-		req.Rewards,
-	)
+	event, err := s.eventService.CreateEvent(ctx, createFieldsFromProto(req))
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		switch {
+		case errors.Is(err, models.ErrExternalIDExists):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case invalidEventInput(err):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
 	}
 
 	// Convert to protobuf response
-	return &pb.Event{
-		Id:          event.ID.String(),
-		Title:       event.Title,
-		Description: event.Description,
-		StartTime:   timestamppb.New(event.StartTime),
-		EndTime:     timestamppb.New(event.EndTime),
-		Rewards:     event.Rewards,
-	}, nil
+	return eventToProto(event), nil
 }
 
 // UpdateEvent implements the gRPC UpdateEvent method
 func (s *GRPCServer) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.Event, error) {
-	// Authenticate request
-	user, err := s.authenticate(ctx)
+	// Update event
+	event, err := s.eventService.UpdateEvent(ctx, req.Id, updateFieldsFromProto(req), req.ExpectedVersion)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
+			return nil, status.Error(codes.NotFound, "event not found")
+		case errors.Is(err, models.ErrVersionConflict):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, models.ErrExternalIDExists):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case invalidEventInput(err):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
 	}
 
-	// Check permission
-	if err := s.checkPermission(user, "update"); err != nil {
-		return nil, err
+	// Convert to protobuf response
+	return eventToProto(event), nil
+}
+
+// DeleteEvent implements the gRPC DeleteEvent method
+func (s *GRPCServer) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*emptypb.Empty, error) {
+	// Delete event
+	err := s.eventService.DeleteEvent(ctx, req.Id, req.ExpectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
+			return nil, status.Error(codes.NotFound, "event not found")
+		case errors.Is(err, models.ErrInvalidID):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, models.ErrVersionConflict):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
 	}
 
-	// Update event
-	event, err := s.eventService.UpdateEvent(
-		req.Id,
-		req.Title,
-		req.Description,
-		req.StartTime.AsTime(),
-		req.EndTime.AsTime(),
-		req.Rewards,
-	)
+	return &emptypb.Empty{}, nil
+}
+
+// PublishEvent implements the gRPC PublishEvent method
+func (s *GRPCServer) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*pb.Event, error) {
+	return s.transitionEvent(ctx, req.Id, s.eventService.PublishEvent)
+}
+
+// UnpublishEvent implements the gRPC UnpublishEvent method
+func (s *GRPCServer) UnpublishEvent(ctx context.Context, req *pb.UnpublishEventRequest) (*pb.Event, error) {
+	return s.transitionEvent(ctx, req.Id, s.eventService.UnpublishEvent)
+}
+
+// CancelEvent implements the gRPC CancelEvent method
+func (s *GRPCServer) CancelEvent(ctx context.Context, req *pb.CancelEventRequest) (*pb.Event, error) {
+	return s.transitionEvent(ctx, req.Id, s.eventService.CancelEvent)
+}
+
+// ArchiveEvent implements the gRPC ArchiveEvent method
+func (s *GRPCServer) ArchiveEvent(ctx context.Context, req *pb.ArchiveEventRequest) (*pb.Event, error) {
+	return s.transitionEvent(ctx, req.Id, s.eventService.ArchiveEvent)
+}
+
+// transitionEvent applies a lifecycle transition
+func (s *GRPCServer) transitionEvent(ctx context.Context, id string, transition func(context.Context, string) (*models.LiveEvent, error)) (*pb.Event, error) {
+	// Apply transition
+	event, err := transition(ctx, id)
 	if err != nil {
-		if err == models.ErrEventNotFound {
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
+			return nil, status.Error(codes.NotFound, "event not found")
+		case errors.Is(err, models.ErrInvalidID):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, models.ErrInvalidTransition):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return eventToProto(event), nil
+}
+
+// ListOccurrences implements the gRPC ListOccurrences method
+func (s *GRPCServer) ListOccurrences(ctx context.Context, req *pb.ListOccurrencesRequest) (*pb.ListOccurrencesResponse, error) {
+	// Resolve the expansion window
+	from := s.eventService.Now()
+	if req.From != nil {
+		from = req.From.AsTime()
+	}
+	to := from.AddDate(1, 0, 0)
+	if req.To != nil {
+		to = req.To.AsTime()
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxOccurrenceLimit {
+		limit = maxOccurrenceLimit
+	}
+
+	// Expand occurrences
+	occurrences, err := s.eventService.ListOccurrences(req.Id, from, to, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
 			return nil, status.Error(codes.NotFound, "event not found")
+		case errors.Is(err, models.ErrInvalidID), errors.Is(err, models.ErrInvalidTimeRange), errors.Is(err, models.ErrInvalidRecurrence):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
 		}
-		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// Convert to protobuf response
-	return &pb.Event{
-		Id:          event.ID.String(),
-		Title:       event.Title,
-		Description: event.Description,
-		StartTime:   timestamppb.New(event.StartTime),
-		EndTime:     timestamppb.New(event.EndTime),
-		Rewards:     event.Rewards,
+	pbOccurrences := make([]*pb.Occurrence, len(occurrences))
+	for i, occurrence := range occurrences {
+		pbOccurrences[i] = &pb.Occurrence{
+			StartTime: timestamppb.New(occurrence.StartTime),
+			EndTime:   timestamppb.New(occurrence.EndTime),
+		}
+	}
+
+	return &pb.ListOccurrencesResponse{
+		Occurrences: pbOccurrences,
 	}, nil
 }
 
-// DeleteEvent implements the gRPC DeleteEvent method
-func (s *GRPCServer) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*emptypb.Empty, error) {
-	// Authenticate request
-	user, err := s.authenticate(ctx)
+// revisionToProto converts an event revision to its protobuf representation
+func revisionToProto(revision *models.EventRevision) *pb.EventRevision {
+	pbRevision := &pb.EventRevision{
+		EventId:      revision.EventID.String(),
+		Revision:     int32(revision.Revision),
+		Action:       revision.Action,
+		Event:        eventToProto(&revision.Event),
+		RevertedFrom: int32(revision.RevertedFrom),
+		CreatedAt:    timestamppb.New(revision.CreatedAt),
+	}
+	if revision.AuthorUserID != nil {
+		pbRevision.AuthorUserId = revision.AuthorUserID.String()
+	}
+	if revision.AuthorAPIKeyID != nil {
+		pbRevision.AuthorApiKeyId = revision.AuthorAPIKeyID.String()
+	}
+	return pbRevision
+}
+
+// revisionError converts the error of a revision operation to a gRPC status.
+// Errors other than missing events or revisions come from invalid input.
+func revisionError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrEventNotFound):
+		return status.Error(codes.NotFound, "event not found")
+	case errors.Is(err, models.ErrRevisionNotFound):
+		return status.Error(codes.NotFound, "revision not found")
+	case invalidEventInput(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrExternalIDExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// ListEventRevisions implements the gRPC ListEventRevisions method
+func (s *GRPCServer) ListEventRevisions(ctx context.Context, req *pb.ListEventRevisionsRequest) (*pb.ListEventRevisionsResponse, error) {
+	revisions, nextPageToken, err := s.eventService.ListEventRevisions(req.Id, int(req.PageSize), req.PageToken)
 	if err != nil {
-		return nil, err
+		return nil, revisionError(err)
 	}
 
-	// Check permission
-	if err := s.checkPermission(user, "delete"); err != nil {
-		return nil, err
+	// Convert to protobuf response
+	pbRevisions := make([]*pb.EventRevision, len(revisions))
+	for i, revision := range revisions {
+		pbRevisions[i] = revisionToProto(revision)
 	}
 
-	// Delete event
-	err = s.eventService.DeleteEvent(req.Id)
+	return &pb.ListEventRevisionsResponse{
+		Revisions:     pbRevisions,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// GetEventRevision implements the gRPC GetEventRevision method
+func (s *GRPCServer) GetEventRevision(ctx context.Context, req *pb.GetEventRevisionRequest) (*pb.EventRevision, error) {
+	if req.Revision <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "revision must be positive")
+	}
+
+	revision, err := s.eventService.GetEventRevision(req.Id, int(req.Revision))
 	if err != nil {
-		if err == models.ErrEventNotFound {
-			return nil, status.Error(codes.NotFound, "event not found")
+		return nil, revisionError(err)
+	}
+
+	return revisionToProto(revision), nil
+}
+
+// DiffEventRevisions implements the gRPC DiffEventRevisions method
+func (s *GRPCServer) DiffEventRevisions(ctx context.Context, req *pb.DiffEventRevisionsRequest) (*pb.DiffEventRevisionsResponse, error) {
+	if req.From <= 0 || req.To < 0 {
+		return nil, status.Error(codes.InvalidArgument, "from must be positive and to must not be negative")
+	}
+
+	diff, err := s.eventService.DiffEventRevisions(req.Id, int(req.From), int(req.To))
+	if err != nil {
+		return nil, revisionError(err)
+	}
+
+	// Convert to protobuf response
+	pbChanges := make([]*pb.FieldChange, len(diff.Changes))
+	for i, change := range diff.Changes {
+		pbChanges[i] = &pb.FieldChange{
+			Field: change.Field,
+			From:  string(change.From),
+			To:    string(change.To),
 		}
-		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	return &emptypb.Empty{}, nil
+	return &pb.DiffEventRevisionsResponse{
+		From:    int32(diff.From),
+		To:      int32(diff.To),
+		Changes: pbChanges,
+	}, nil
+}
+
+// RevertEvent implements the gRPC RevertEvent method
+func (s *GRPCServer) RevertEvent(ctx context.Context, req *pb.RevertEventRequest) (*pb.Event, error) {
+	if req.Revision <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "revision must be positive")
+	}
+
+	event, err := s.eventService.RevertEvent(ctx, req.Id, int(req.Revision))
+	if err != nil {
+		return nil, revisionError(err)
+	}
+
+	return eventToProto(event), nil
+}
+
+// changeToProto converts an event change to its protobuf representation
+func changeToProto(change models.EventChange) *pb.EventChange {
+	pbChange := &pb.EventChange{
+		Sequence: change.Seq,
+		Type:     string(change.Type),
+		EventId:  change.EventID,
+		Time:     timestamppb.New(change.Time),
+	}
+	if change.Event != nil {
+		pbChange.Event = eventToProto(change.Event)
+	}
+	return pbChange
+}
+
+// WatchEvents implements the gRPC WatchEvents method
+func (s *GRPCServer) WatchEvents(req *pb.WatchEventsRequest, stream pb.EventService_WatchEventsServer) error {
+	ctx := stream.Context()
+
+	// Start watching
+	watch, err := s.eventService.WatchEvents(req.SinceSequence)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer watch.Close()
+
+	// Send the snapshot or the missed changes, then follow new changes
+	for _, change := range watch.Initial {
+		if err := stream.Send(changeToProto(change)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case change, ok := <-watch.Changes:
+			if !ok {
+				return status.Error(codes.Unavailable, "watcher fell behind, resume from the last received sequence")
+			}
+			if err := stream.Send(changeToProto(change)); err != nil {
+				return err
+			}
+		}
+	}
 }
@@ -1,40 +1,73 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/audit"
 	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/idempotency"
 	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/outbox"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
 	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/targeting"
+	"github.com/tombombadilom/liveops/internal/webhook"
 )
 
 // HTTPServer handles HTTP API requests
 type HTTPServer struct {
-	router       *gin.Engine
-	eventService *service.EventService
-	authService  *auth.AuthService
+	router        *gin.Engine
+	eventService  *service.EventService
+	authService   *auth.AuthService
+	auditService  *audit.AuditService
+	rewardService *service.RewardService
+	limiter       *ratelimit.Limiter
+	playerTokens  *auth.PlayerTokenVerifier
+	webhooks      *webhook.Service
+	outbox        *outbox.Service
+	idempotency   *idempotency.Service
 }
 
-// NewHTTPServer creates a new HTTP server
-func NewHTTPServer(eventService *service.EventService, authService *auth.AuthService) *HTTPServer {
+// NewHTTPServer creates a new HTTP server. The public player API is only
+// served when a player token verifier is given.
+func NewHTTPServer(eventService *service.EventService, authService *auth.AuthService, auditService *audit.AuditService, rewardService *service.RewardService, limiter *ratelimit.Limiter, playerTokens *auth.PlayerTokenVerifier, webhooks *webhook.Service, outboxService *outbox.Service, idempotencyService *idempotency.Service) *HTTPServer {
 	// Create router
 	router := gin.New()
 
 	// Use middleware
 	router.Use(gin.Recovery())
+	router.Use(requestIDMiddleware())
 	router.Use(loggerMiddleware())
 
 	server := &HTTPServer{
-		router:       router,
-		eventService: eventService,
-		authService:  authService,
+		router:        router,
+		eventService:  eventService,
+		authService:   authService,
+		auditService:  auditService,
+		rewardService: rewardService,
+		limiter:       limiter,
+		playerTokens:  playerTokens,
+		webhooks:      webhooks,
+		outbox:        outboxService,
+		idempotency:   idempotencyService,
 	}
 
 	// Register routes
 	server.registerRoutes()
+	if playerTokens != nil {
+		server.registerPublicRoutes()
+	}
 
 	return server
 }
@@ -47,36 +80,85 @@ func (s *HTTPServer) Handler() http.Handler {
 // registerRoutes sets up all API routes
 func (s *HTTPServer) registerRoutes() {
 	// Public routes
-	s.router.GET("/health", s.healthCheck)
+	s.router.GET("/health", s.rateLimitMiddleware(), s.healthCheck)
 
 	// API routes (require authentication)
 	api := s.router.Group("/api")
-	api.Use(s.authMiddleware())
+	api.Use(s.authLimitMiddleware(), s.authMiddleware(), s.rateLimitMiddleware())
 	{
 		// Events
 		events := api.Group("/events")
 		{
-			events.GET("", s.listEvents)
-			events.GET("/active", s.listActiveEvents)
-			events.GET("/:id", s.getEvent)
-			events.POST("", s.createEvent)
-			events.PUT("/:id", s.updateEvent)
-			events.DELETE("/:id", s.deleteEvent)
+			events.GET("", s.readMiddleware(), s.listEvents)
+			events.GET("/active", s.readMiddleware(), s.listActiveEvents)
+			events.GET("/stream", s.readMiddleware(), s.streamEvents)
+			events.GET("/export", s.readMiddleware(), s.exportEvents)
+			events.GET("/eligible", s.readMiddleware(), s.listEligibleEvents)
+			events.GET("/:id", s.readMiddleware(), s.getEvent)
+			events.GET("/:id/occurrences", s.readMiddleware(), s.listOccurrences)
+			events.GET("/:id/revisions", s.readMiddleware(), s.listEventRevisions)
+			events.GET("/:id/revisions/:revision", s.readMiddleware(), s.getEventRevision)
+			events.GET("/:id/diff", s.readMiddleware(), s.diffEventRevisions)
+			events.POST("", s.idempotencyMiddleware(), s.createEvent)
+			events.POST("/import", s.idempotencyMiddleware(), s.importEvents)
+			events.PUT("/:id", s.idempotencyMiddleware(), s.updateEvent)
+			events.DELETE("/:id", s.idempotencyMiddleware(), s.deleteEvent)
+			events.POST("/:id/publish", s.idempotencyMiddleware(), s.transitionEvent(s.eventService.PublishEvent))
+			events.POST("/:id/unpublish", s.idempotencyMiddleware(), s.transitionEvent(s.eventService.UnpublishEvent))
+			events.POST("/:id/cancel", s.idempotencyMiddleware(), s.transitionEvent(s.eventService.CancelEvent))
+			events.POST("/:id/archive", s.idempotencyMiddleware(), s.transitionEvent(s.eventService.ArchiveEvent))
+			events.POST("/:id/revert", s.idempotencyMiddleware(), s.revertEvent)
 		}
 
+		// Reward catalog
+		api.GET("/rewards", s.readMiddleware(), s.listRewardCatalog)
+
 		// Admin routes (require admin role)
 		admin := api.Group("/admin")
-		admin.Use(s.adminMiddleware())
 		{
 			// Users
-			admin.GET("/users", s.listUsers)
-			admin.POST("/users", s.createUser)
-			admin.GET("/users/:id", s.getUser)
+			users := admin.Group("", s.adminMiddleware("admin:users"))
+			users.GET("/users", s.listUsers)
+			users.POST("/users", s.createUser)
+			users.GET("/users/:id", s.getUser)
+			users.PUT("/users/:id/role", s.updateUserRole)
+			users.DELETE("/users/:id", s.deleteUser)
 
 			// API Keys
-			admin.GET("/users/:id/keys", s.listAPIKeys)
-			admin.POST("/users/:id/keys", s.createAPIKey)
-			admin.DELETE("/keys/:id", s.revokeAPIKey)
+			keys := admin.Group("", s.adminMiddleware("admin:keys"))
+			keys.GET("/users/:id/keys", s.listAPIKeys)
+			keys.POST("/users/:id/keys", s.createAPIKey)
+			keys.DELETE("/keys/:id", s.revokeAPIKey)
+
+			// Audit log
+			admin.GET("/audit", s.adminMiddleware("admin:audit"), s.listAuditEntries)
+
+			// Reward catalog
+			rewards := admin.Group("", s.adminMiddleware("admin:rewards"))
+			rewards.POST("/rewards", s.addRewardCatalogItem)
+			rewards.DELETE("/rewards/:id", s.removeRewardCatalogItem)
+
+			// Rate limit overrides
+			rateLimits := admin.Group("", s.adminMiddleware("admin:keys"))
+			rateLimits.GET("/rate-limits", s.listRateLimitOverrides)
+			rateLimits.PUT("/rate-limits/:type/:subject", s.setRateLimitOverride)
+			rateLimits.DELETE("/rate-limits/:type/:subject", s.deleteRateLimitOverride)
+
+			// Webhooks
+			webhooks := admin.Group("", s.adminMiddleware("admin:webhooks"))
+			webhooks.GET("/webhooks", s.listWebhooks)
+			webhooks.POST("/webhooks", s.createWebhook)
+			webhooks.GET("/webhooks/:id", s.getWebhook)
+			webhooks.DELETE("/webhooks/:id", s.deleteWebhook)
+			webhooks.GET("/webhooks/:id/deliveries", s.listWebhookDeliveries)
+			webhooks.GET("/webhook-deliveries/:id", s.getWebhookDelivery)
+			webhooks.POST("/webhook-deliveries/:id/redeliver", s.redeliverWebhook)
+
+			// Outbox
+			outboxEntries := admin.Group("", s.adminMiddleware("admin:outbox"))
+			outboxEntries.GET("/outbox", s.listOutboxEntries)
+			outboxEntries.GET("/outbox/:seq", s.getOutboxEntry)
+			outboxEntries.POST("/outbox/:seq/retry", s.retryOutboxEntry)
 		}
 	}
 }
@@ -95,6 +177,7 @@ func loggerMiddleware() gin.HandlerFunc {
 		status := c.Writer.Status()
 
 		log.Info().
+			Str("request_id", requestIDFromContext(c.Request.Context())).
 			Str("method", c.Request.Method).
 			Str("path", path).
 			Int("status", status).
@@ -109,6 +192,7 @@ func (s *HTTPServer) authMiddleware() gin.HandlerFunc {
 		// Get API key from header
 		apiKey := c.GetHeader("X-API-Key")
 		if apiKey == "" {
+			s.chargeAuthFailure(c)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "API key required",
 			})
@@ -116,22 +200,33 @@ func (s *HTTPServer) authMiddleware() gin.HandlerFunc {
 		}
 
 		// Authenticate API key
-		user, err := s.authService.AuthenticateAPIKey(apiKey)
+		user, key, err := s.authService.AuthenticateAPIKey(apiKey)
 		if err != nil {
+			s.chargeAuthFailure(c)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid API key",
 			})
 			return
 		}
 
-		// Store user in context
+		// Store user and key in context, and attribute operations to them
 		c.Set("user", user)
+		c.Set("api_key", key)
+		ctx := c.Request.Context()
+		c.Request = c.Request.WithContext(audit.WithActor(ctx, audit.NewActor(user, key, requestIDFromContext(ctx), models.TransportHTTP)))
 		c.Next()
 	}
 }
 
-// adminMiddleware ensures the user has admin role
-func (s *HTTPServer) adminMiddleware() gin.HandlerFunc {
+// currentAPIKey returns the API key the request was authenticated with
+func currentAPIKey(c *gin.Context) *models.APIKey {
+	key, _ := c.Get("api_key")
+	apiKey, _ := key.(*models.APIKey)
+	return apiKey
+}
+
+// adminMiddleware ensures the user has admin role and the key allows the admin action
+func (s *HTTPServer) adminMiddleware(action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user from context
 		user, exists := c.Get("user")
@@ -143,7 +238,7 @@ func (s *HTTPServer) adminMiddleware() gin.HandlerFunc {
 		}
 
 		// Check if user has admin role
-		if err := s.authService.CheckPermission(user.(*models.User), "admin"); err != nil {
+		if err := s.authService.CheckPermission(user.(*models.User), currentAPIKey(c), action); err != nil {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": "Admin access required",
 			})
@@ -162,26 +257,224 @@ func (s *HTTPServer) healthCheck(c *gin.Context) {
 	})
 }
 
+// readMiddleware ensures the user and key may read events
+func (s *HTTPServer) readMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(*models.User)
+
+		if err := s.authService.CheckPermission(user, currentAPIKey(c), "read"); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // listEvents handles GET /api/events
 func (s *HTTPServer) listEvents(c *gin.Context) {
-	events, err := s.eventService.ListEvents(false)
+	s.respondEventPage(c, false)
+}
+
+// listActiveEvents handles GET /api/events/active
+func (s *HTTPServer) listActiveEvents(c *gin.Context) {
+	s.respondEventPage(c, true)
+}
+
+// respondEventPage lists a page of events according to the query parameters,
+// restricted to published events active now, or at the as_of instant, when
+// activeOnly is set
+func (s *HTTPServer) respondEventPage(c *gin.Context, activeOnly bool) {
+	// Parse query parameters
+	var query struct {
+		StartsAfter time.Time `form:"starts_after" time_format:"2006-01-02T15:04:05Z07:00"`
+		EndsBefore  time.Time `form:"ends_before" time_format:"2006-01-02T15:04:05Z07:00"`
+		ActiveAt    time.Time `form:"active_at" time_format:"2006-01-02T15:04:05Z07:00"`
+		AsOf        time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"`
+		Title       string    `form:"title"`
+		Sort        string    `form:"sort"`
+		PageSize    int       `form:"page_size" binding:"min=0"`
+		PageToken   string    `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !query.AsOf.IsZero() && !activeOnly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of only applies to active events; use active_at"})
+		return
+	}
+	if !s.allowPreview(c, query.AsOf) {
+		return
+	}
+
+	filter := models.EventFilter{
+		StartsAfter: query.StartsAfter,
+		EndsBefore:  query.EndsBefore,
+		ActiveAt:    query.ActiveAt,
+		Title:       query.Title,
+	}
+	if activeOnly {
+		active := s.eventService.ActiveFilter(query.AsOf)
+		filter.Statuses, filter.ActiveAt = active.Statuses, active.ActiveAt
+	}
+
+	// Get events from service
+	events, nextPageToken, err := s.eventService.ListEvents(filter, models.EventSort(query.Sort), query.PageSize, query.PageToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, models.ErrInvalidSort) || errors.Is(err, models.ErrInvalidPageToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	if events == nil {
+		events = []*models.LiveEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":          events,
+		"next_page_token": nextPageToken,
+	})
 }
 
-// listActiveEvents handles GET /api/events/active
-func (s *HTTPServer) listActiveEvents(c *gin.Context) {
-	events, err := s.eventService.ListEvents(true)
+// listEligibleEvents handles GET /api/events/eligible, listing the published
+// events active now, or at the as_of instant, that target the player
+// described by the query parameters
+func (s *HTTPServer) listEligibleEvents(c *gin.Context) {
+	// Parse query parameters
+	var query struct {
+		targeting.Player
+		AsOf      time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"`
+		PageSize  int       `form:"page_size" binding:"min=0"`
+		PageToken string    `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.allowPreview(c, query.AsOf) {
+		return
+	}
+
+	// Get events from service
+	events, nextPageToken, err := s.eventService.ListEligibleEvents(&query.Player, query.AsOf, query.PageSize, query.PageToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPageToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if events == nil {
+		events = []*models.LiveEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":          events,
+		"next_page_token": nextPageToken,
+	})
+}
+
+// allowPreview checks that the caller may evaluate the schedule at the as_of
+// instant of the request, if any, and responds with an error otherwise
+func (s *HTTPServer) allowPreview(c *gin.Context, asOf time.Time) bool {
+	if asOf.IsZero() {
+		return true
+	}
+
+	user := c.MustGet("user").(*models.User)
+	if err := s.authService.CheckPermission(user, currentAPIKey(c), "preview"); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "as_of requires the editor or admin role"})
+		return false
+	}
+
+	return true
+}
+
+// streamEvents handles GET /api/events/stream, sending a snapshot of all
+// events followed by their changes as server-sent events. Reconnecting
+// clients resume through the Last-Event-ID header or the since parameter.
+func (s *HTTPServer) streamEvents(c *gin.Context) {
+	// Parse resume position
+	since := c.GetHeader("Last-Event-ID")
+	if since == "" {
+		since = c.Query("since")
+	}
+
+	var sinceSeq int64
+	if since != "" {
+		var err error
+		sinceSeq, err = strconv.ParseInt(since, 10, 64)
+		if err != nil || sinceSeq < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sequence number"})
+			return
+		}
+	}
+
+	// Start watching
+	watch, err := s.eventService.WatchEvents(sinceSeq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	defer watch.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
 
-	c.JSON(http.StatusOK, events)
+	// Send the snapshot or the missed changes, then follow new changes
+	for _, change := range watch.Initial {
+		if err := writeChangeEvent(c.Writer, change); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-watch.Changes:
+			if !ok {
+				// The client fell behind; it reconnects and resumes
+				return
+			}
+			if err := writeChangeEvent(c.Writer, change); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeChangeEvent writes an event change as a server-sent event
+func writeChangeEvent(w io.Writer, change models.EventChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, change.Type, data)
+	return err
 }
 
 // getEvent handles GET /api/events/:id
@@ -198,27 +491,164 @@ func (s *HTTPServer) getEvent(c *gin.Context) {
 		return
 	}
 
+	setETag(c, event)
 	c.JSON(http.StatusOK, event)
 }
 
-// createEvent handles POST /api/events
-func (s *HTTPServer) createEvent(c *gin.Context) {
+// setETag sets the ETag header of a response to the version of an event
+func setETag(c *gin.Context, event *models.LiveEvent) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, event.Version))
+}
+
+// ifMatchVersion returns the event version required by the If-Match header
+// of the request, zero when there is none or it is "*". It fails when the
+// header holds anything but a single strong ETag issued by setETag, which
+// can never match.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil || !strings.HasPrefix(header, `"`) {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// listOccurrences handles GET /api/events/:id/occurrences
+func (s *HTTPServer) listOccurrences(c *gin.Context) {
+	id := c.Param("id")
+
+	// Parse query parameters
+	var query struct {
+		From  time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+		To    time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+		Limit int       `form:"limit" binding:"min=0"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from := query.From
+	if from.IsZero() {
+		from = s.eventService.Now()
+	}
+	to := query.To
+	if to.IsZero() {
+		to = from.AddDate(1, 0, 0)
+	}
+	limit := query.Limit
+	if limit == 0 || limit > maxOccurrenceLimit {
+		limit = maxOccurrenceLimit
+	}
+
+	// Expand occurrences
+	occurrences, err := s.eventService.ListOccurrences(id, from, to, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		case errors.Is(err, models.ErrInvalidID), errors.Is(err, models.ErrInvalidTimeRange), errors.Is(err, models.ErrInvalidRecurrence):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrences)
+}
+
+// listEventRevisions handles GET /api/events/:id/revisions, listing the
+// revisions of an event newest first
+func (s *HTTPServer) listEventRevisions(c *gin.Context) {
+	// Parse query parameters
+	var query struct {
+		PageSize  int    `form:"page_size" binding:"min=0"`
+		PageToken string `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revisions, nextPageToken, err := s.eventService.ListEventRevisions(c.Param("id"), query.PageSize, query.PageToken)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions":       revisions,
+		"next_page_token": nextPageToken,
+	})
+}
+
+// getEventRevision handles GET /api/events/:id/revisions/:revision
+func (s *HTTPServer) getEventRevision(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || number <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	revision, err := s.eventService.GetEventRevision(c.Param("id"), number)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// diffEventRevisions handles GET /api/events/:id/diff, comparing revision from
+// with revision to, or with the latest revision when to is omitted
+func (s *HTTPServer) diffEventRevisions(c *gin.Context) {
+	// Parse query parameters
+	var query struct {
+		From int `form:"from" binding:"required,min=1"`
+		To   int `form:"to" binding:"min=0"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	diff, err := s.eventService.DiffEventRevisions(c.Param("id"), query.From, query.To)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// revertEvent handles POST /api/events/:id/revert, restoring the fields of
+// an event from one of its revisions
+func (s *HTTPServer) revertEvent(c *gin.Context) {
 	// Get user from context
 	user := c.MustGet("user").(*models.User)
 
 	// Check permission
-	if err := s.authService.CheckPermission(user, "create"); err != nil {
+	if err := s.authService.CheckPermission(user, currentAPIKey(c), "update"); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
 		return
 	}
 
 	// Parse request
 	var req struct {
-		Title       string    `json:"title" binding:"required"`
-		Description string    `json:"description"`
-		StartTime   time.Time `json:"start_time" binding:"required"`
-		EndTime     time.Time `json:"end_time" binding:"required"`
-		Rewards     string    `json:"rewards"`
+		Revision int `json:"revision" binding:"required,min=1"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -226,13 +656,110 @@ func (s *HTTPServer) createEvent(c *gin.Context) {
 		return
 	}
 
-	// Create event
-	event, err := s.eventService.CreateEvent(req.Title, req.Description, req.StartTime, req.EndTime, req.Rewards)
+	// Revert event
+	event, err := s.eventService.RevertEvent(c.Request.Context(), c.Param("id"), req.Revision)
 	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	setETag(c, event)
+	c.JSON(http.StatusOK, event)
+}
+
+// respondRevisionError writes the response for a failed revision operation.
+// Errors other than missing events or revisions come from invalid input.
+func respondRevisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+	case errors.Is(err, models.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+	case invalidEventInput(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrExternalIDExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// invalidEventInput reports whether an event operation failed because of
+// invalid input rather than a storage failure
+func invalidEventInput(err error) bool {
+	switch {
+	case errors.Is(err, models.ErrInvalidID), errors.Is(err, models.ErrInvalidPageToken), errors.Is(err, models.ErrEmptyTitle),
+		errors.Is(err, models.ErrInvalidTimeRange), errors.Is(err, models.ErrInvalidRewardsJSON), errors.Is(err, models.ErrInvalidRewards),
+		errors.Is(err, models.ErrUnknownReward), errors.Is(err, models.ErrInvalidRecurrence), errors.Is(err, models.ErrInvalidTargeting),
+		errors.Is(err, models.ErrInvalidExternalID):
+		return true
+	default:
+		return false
+	}
+}
+
+// eventRequest is the body of event creation and update requests
+type eventRequest struct {
+	ExternalID        string          `json:"external_id"`
+	Title             string          `json:"title" binding:"required"`
+	Description       string          `json:"description"`
+	StartTime         time.Time       `json:"start_time" binding:"required"`
+	EndTime           time.Time       `json:"end_time" binding:"required"`
+	Rewards           string          `json:"rewards"`
+	StructuredRewards []models.Reward `json:"structured_rewards"`
+	Recurrence        string          `json:"recurrence"`
+	Targeting         string          `json:"targeting"`
+}
+
+// fields converts the request to event fields
+func (r *eventRequest) fields() models.EventFields {
+	return models.EventFields{
+		ExternalID:        r.ExternalID,
+		Title:             r.Title,
+		Description:       r.Description,
+		StartTime:         r.StartTime,
+		EndTime:           r.EndTime,
+		Rewards:           r.Rewards,
+		StructuredRewards: r.StructuredRewards,
+		Recurrence:        r.Recurrence,
+		Targeting:         r.Targeting,
+	}
+}
+
+// createEvent handles POST /api/events
+func (s *HTTPServer) createEvent(c *gin.Context) {
+	// Get user from context
+	user := c.MustGet("user").(*models.User)
+
+	// Check permission
+	if err := s.authService.CheckPermission(user, currentAPIKey(c), "create"); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	// Parse request
+	var req eventRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Create event
+	event, err := s.eventService.CreateEvent(c.Request.Context(), req.fields())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrExternalIDExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case invalidEventInput(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	setETag(c, event)
 	c.JSON(http.StatusCreated, event)
 }
 
@@ -242,7 +769,7 @@ func (s *HTTPServer) updateEvent(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
 	// Check permission
-	if err := s.authService.CheckPermission(user, "update"); err != nil {
+	if err := s.authService.CheckPermission(user, currentAPIKey(c), "update"); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
 		return
 	}
@@ -251,30 +778,38 @@ func (s *HTTPServer) updateEvent(c *gin.Context) {
 	id := c.Param("id")
 
 	// Parse request
-	var req struct {
-		Title       string    `json:"title" binding:"required"`
-		Description string    `json:"description"`
-		StartTime   time.Time `json:"start_time" binding:"required"`
-		EndTime     time.Time `json:"end_time" binding:"required"`
-		Rewards     string    `json:"rewards"`
-	}
+	var req eventRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": models.ErrVersionConflict.Error()})
+		return
+	}
+
 	// Update event
-	event, err := s.eventService.UpdateEvent(id, req.Title, req.Description, req.StartTime, req.EndTime, req.Rewards)
+	event, err := s.eventService.UpdateEvent(c.Request.Context(), id, req.fields(), version)
 	if err != nil {
-		if err == models.ErrEventNotFound {
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		} else {
+		case errors.Is(err, models.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrExternalIDExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case invalidEventInput(err):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		}
 		return
 	}
 
+	setETag(c, event)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -284,7 +819,7 @@ func (s *HTTPServer) deleteEvent(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
 	// Check permission
-	if err := s.authService.CheckPermission(user, "delete"); err != nil {
+	if err := s.authService.CheckPermission(user, currentAPIKey(c), "delete"); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
 		return
 	}
@@ -292,12 +827,23 @@ func (s *HTTPServer) deleteEvent(c *gin.Context) {
 	// Get event ID
 	id := c.Param("id")
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": models.ErrVersionConflict.Error()})
+		return
+	}
+
 	// Delete event
-	err := s.eventService.DeleteEvent(id)
+	err := s.eventService.DeleteEvent(c.Request.Context(), id, version)
 	if err != nil {
-		if err == models.ErrEventNotFound {
+		switch {
+		case errors.Is(err, models.ErrEventNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		} else {
+		case errors.Is(err, models.ErrInvalidID):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
 		return
@@ -306,6 +852,39 @@ func (s *HTTPServer) deleteEvent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// transitionEvent handles POST /api/events/:id/{publish,unpublish,cancel,archive}
+func (s *HTTPServer) transitionEvent(transition func(context.Context, string) (*models.LiveEvent, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user from context
+		user := c.MustGet("user").(*models.User)
+
+		// Check permission
+		if err := s.authService.CheckPermission(user, currentAPIKey(c), "update"); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+
+		// Apply transition
+		event, err := transition(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrEventNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			case errors.Is(err, models.ErrInvalidID):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrInvalidTransition):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		setETag(c, event)
+		c.JSON(http.StatusOK, event)
+	}
+}
+
 // listUsers handles GET /api/admin/users
 func (s *HTTPServer) listUsers(c *gin.Context) {
 	users, err := s.authService.ListUsers()
@@ -331,9 +910,13 @@ func (s *HTTPServer) createUser(c *gin.Context) {
 	}
 
 	// Create user
-	user, err := s.authService.CreateUser(req.Username, req.Role)
+	user, err := s.authService.CreateUser(c.Request.Context(), req.Username, req.Role)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err == models.ErrUsernameExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -357,6 +940,63 @@ func (s *HTTPServer) getUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// updateUserRole handles PUT /api/admin/users/:id/role
+func (s *HTTPServer) updateUserRole(c *gin.Context) {
+	id := c.Param("id")
+
+	// Parse request
+	var req struct {
+		Role models.Role `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Update role
+	user, err := s.authService.UpdateUserRole(c.Request.Context(), id, req.Role)
+	if err != nil {
+		switch err {
+		case models.ErrInvalidID:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		case models.ErrInvalidRole:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case models.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case models.ErrLastAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// deleteUser handles DELETE /api/admin/users/:id
+func (s *HTTPServer) deleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	err := s.authService.DeleteUser(c.Request.Context(), id)
+	if err != nil {
+		switch err {
+		case models.ErrInvalidID:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		case models.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case models.ErrLastAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // listAPIKeys handles GET /api/admin/users/:id/keys
 func (s *HTTPServer) listAPIKeys(c *gin.Context) {
 	id := c.Param("id")
@@ -380,7 +1020,8 @@ func (s *HTTPServer) createAPIKey(c *gin.Context) {
 
 	// Parse request
 	var req struct {
-		ValidDays int `json:"valid_days" binding:"required,min=1,max=365"`
+		ValidDays int            `json:"valid_days" binding:"required,min=1,max=365"`
+		Scopes    []models.Scope `json:"scopes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -389,10 +1030,14 @@ func (s *HTTPServer) createAPIKey(c *gin.Context) {
 	}
 
 	// Create API key
-	key, err := s.authService.CreateAPIKey(id, req.ValidDays)
+	key, err := s.authService.CreateAPIKey(c.Request.Context(), id, req.ValidDays, req.Scopes)
 	if err != nil {
 		if err == models.ErrInvalidID {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		} else if err == models.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		} else if errors.Is(err, models.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
@@ -406,10 +1051,173 @@ func (s *HTTPServer) createAPIKey(c *gin.Context) {
 func (s *HTTPServer) revokeAPIKey(c *gin.Context) {
 	id := c.Param("id")
 
-	err := s.authService.RevokeAPIKey(id)
+	err := s.authService.RevokeAPIKey(c.Request.Context(), id)
 	if err != nil {
 		if err == models.ErrInvalidID {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		} else if err == models.ErrAPIKeyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// listAuditEntries handles GET /api/admin/audit
+func (s *HTTPServer) listAuditEntries(c *gin.Context) {
+	// Parse query parameters
+	var query struct {
+		Actor      string    `form:"actor"`
+		TargetType string    `form:"target_type"`
+		TargetID   string    `form:"target_id"`
+		Action     string    `form:"action"`
+		Since      time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+		Until      time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+		PageSize   int       `form:"page_size" binding:"min=0"`
+		PageToken  string    `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := models.AuditFilter{
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+		Action:     query.Action,
+		Since:      query.Since,
+		Until:      query.Until,
+	}
+	if query.Actor != "" {
+		id, err := uuid.Parse(query.Actor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID"})
+			return
+		}
+		filter.ActorUserID = &id
+	}
+
+	// Query audit log
+	entries, nextPageToken, err := s.auditService.ListEntries(filter, query.PageSize, query.PageToken)
+	if err != nil {
+		if errors.Is(err, audit.ErrInvalidPageToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":         entries,
+		"next_page_token": nextPageToken,
+	})
+}
+
+// listRateLimitOverrides handles GET /api/admin/rate-limits
+func (s *HTTPServer) listRateLimitOverrides(c *gin.Context) {
+	overrides, err := s.limiter.ListOverrides()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// setRateLimitOverride handles PUT /api/admin/rate-limits/:type/:subject
+func (s *HTTPServer) setRateLimitOverride(c *gin.Context) {
+	// Parse request
+	var req struct {
+		RequestsPerMin int `json:"requests_per_min" binding:"required,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set override
+	override, err := s.limiter.SetOverride(c.Request.Context(), models.RateLimitSubject(c.Param("type")), c.Param("subject"), req.RequestsPerMin)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRateLimit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// deleteRateLimitOverride handles DELETE /api/admin/rate-limits/:type/:subject
+func (s *HTTPServer) deleteRateLimitOverride(c *gin.Context) {
+	err := s.limiter.DeleteOverride(c.Request.Context(), models.RateLimitSubject(c.Param("type")), c.Param("subject"))
+	if err != nil {
+		if err == models.ErrRateLimitNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rate limit override not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// listRewardCatalog handles GET /api/rewards
+func (s *HTTPServer) listRewardCatalog(c *gin.Context) {
+	items, err := s.rewardService.ListCatalog()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// addRewardCatalogItem handles POST /api/admin/rewards
+func (s *HTTPServer) addRewardCatalogItem(c *gin.Context) {
+	// Parse request
+	var req struct {
+		ID   string            `json:"id" binding:"required"`
+		Type models.RewardType `json:"type" binding:"required"`
+		Name string            `json:"name"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Add catalog item
+	item, err := s.rewardService.AddCatalogItem(c.Request.Context(), req.ID, req.Type, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidRewards):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrRewardExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// removeRewardCatalogItem handles DELETE /api/admin/rewards/:id
+func (s *HTTPServer) removeRewardCatalogItem(c *gin.Context) {
+	err := s.rewardService.RemoveCatalogItem(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == models.ErrUnknownReward {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reward not found"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
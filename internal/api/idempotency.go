@@ -0,0 +1,289 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/idempotency"
+	"github.com/tombombadilom/liveops/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Idempotency headers. Replayed responses carry the replayed header, and its
+// lowercase form as gRPC header metadata.
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers stored along with the responses
+// to idempotent requests
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// idempotencyMiddleware replays the stored response to retries of a request
+// made with an Idempotency-Key header, and stores the response otherwise.
+// Server errors are not stored, so that the request can be retried.
+func (s *HTTPServer) idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// Read the body to fingerprint it, then hand it on to the handler
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		apiKeyID := currentAPIKey(c).ID
+		fingerprint := idempotency.Fingerprint([]byte(c.Request.Method), []byte(c.Request.URL.RequestURI()),
+			[]byte(c.GetHeader("If-Match")), body)
+
+		record, err := s.idempotency.Begin(apiKeyID, key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrInvalidIdempotency):
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrIdempotencyReused), errors.Is(err, models.ErrIdempotencyPending):
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			}
+			return
+		}
+
+		// Replay the response to the first request
+		if record != nil {
+			for name, value := range record.Header {
+				c.Header(name, value)
+			}
+			c.Header(idempotencyReplayedHeader, "true")
+			c.Status(record.StatusCode)
+			c.Writer.Write(record.Body)
+			c.Abort()
+			return
+		}
+
+		// Release the key if the handler panics, before the panic is recovered
+		defer func() {
+			if r := recover(); r != nil {
+				s.releaseIdempotencyKey(apiKeyID, key)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			s.releaseIdempotencyKey(apiKeyID, key)
+			return
+		}
+
+		header := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		if err := s.idempotency.Complete(apiKeyID, key, recorder.Status(), header, recorder.body.Bytes()); err != nil {
+			log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to store idempotent response")
+		}
+	}
+}
+
+// releaseIdempotencyKey releases a key claimed by a request without storing
+// its response
+func (s *HTTPServer) releaseIdempotencyKey(apiKeyID uuid.UUID, key string) {
+	if err := s.idempotency.Release(apiKeyID, key); err != nil {
+		log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to release idempotency key")
+	}
+}
+
+// responseRecorder keeps a copy of the body written to a response
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes data to the response and the copy
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes s to the response and the copy
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyInterceptor replays the stored outcome to retries of mutating
+// calls made with idempotency-key metadata, and stores the outcome
+// otherwise. Outcomes the call may succeed after, such as internal errors,
+// are not stored.
+func (s *GRPCServer) idempotencyInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	key := idempotencyKeyFromMetadata(ctx)
+	msg, ok := req.(proto.Message)
+	if key == "" || !ok || !idempotentMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	_, apiKey, err := callerFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encode request")
+	}
+
+	record, err := s.idempotency.Begin(apiKey.ID, key, idempotency.Fingerprint([]byte(info.FullMethod), payload))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidIdempotency):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, models.ErrIdempotencyReused):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case errors.Is(err, models.ErrIdempotencyPending):
+			return nil, status.Error(codes.Aborted, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, "failed to check idempotency key")
+		}
+	}
+
+	if record != nil {
+		return replayGRPC(ctx, record)
+	}
+
+	resp, err := s.handleIdempotent(ctx, req, handler, apiKey.ID, key)
+	if err != nil {
+		code := status.Code(err)
+		if retryableCode(code) {
+			s.releaseIdempotencyKey(apiKey.ID, key)
+			return nil, err
+		}
+		s.completeIdempotencyKey(apiKey.ID, key, int(code), []byte(status.Convert(err).Message()))
+		return nil, err
+	}
+
+	outcome, encodeErr := anypb.New(resp.(proto.Message))
+	var body []byte
+	if encodeErr == nil {
+		body, encodeErr = proto.Marshal(outcome)
+	}
+	if encodeErr != nil {
+		log.Error().Err(encodeErr).Str("idempotency_key", key).Msg("Failed to encode idempotent response")
+		s.releaseIdempotencyKey(apiKey.ID, key)
+		return resp, nil
+	}
+	s.completeIdempotencyKey(apiKey.ID, key, int(codes.OK), body)
+
+	return resp, nil
+}
+
+// handleIdempotent calls the handler of a call that claimed a key, releasing
+// the key if the handler panics
+func (s *GRPCServer) handleIdempotent(ctx context.Context, req interface{}, handler grpc.UnaryHandler, apiKeyID uuid.UUID, key string) (interface{}, error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.releaseIdempotencyKey(apiKeyID, key)
+			panic(r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// completeIdempotencyKey stores the outcome of a call that claimed a key
+func (s *GRPCServer) completeIdempotencyKey(apiKeyID uuid.UUID, key string, code int, body []byte) {
+	if err := s.idempotency.Complete(apiKeyID, key, code, nil, body); err != nil {
+		log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to store idempotent response")
+	}
+}
+
+// releaseIdempotencyKey releases a key claimed by a call without storing its
+// outcome
+func (s *GRPCServer) releaseIdempotencyKey(apiKeyID uuid.UUID, key string) {
+	if err := s.idempotency.Release(apiKeyID, key); err != nil {
+		log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to release idempotency key")
+	}
+}
+
+// replayGRPC returns the stored outcome of a call
+func replayGRPC(ctx context.Context, record *models.IdempotencyRecord) (interface{}, error) {
+	grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+
+	if code := codes.Code(record.StatusCode); code != codes.OK {
+		return nil, status.Error(code, string(record.Body))
+	}
+
+	var outcome anypb.Any
+	if err := proto.Unmarshal(record.Body, &outcome); err != nil {
+		return nil, status.Error(codes.Internal, "failed to decode stored response")
+	}
+	resp, err := outcome.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to decode stored response")
+	}
+
+	return resp, nil
+}
+
+// idempotencyKeyFromMetadata returns the idempotency key of a call
+func idempotencyKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get("idempotency-key"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// idempotentMethod reports whether the outcome of a method is stored for
+// calls made with an idempotency key, which is the case for the methods
+// changing events
+func idempotentMethod(method string) bool {
+	switch methodPolicies[method] {
+	case "create", "update", "delete":
+		return true
+	default:
+		return false
+	}
+}
+
+// retryableCode reports whether a call failing with code may succeed when
+// retried, so that its outcome is not stored
+func retryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded,
+		codes.Canceled, codes.ResourceExhausted, codes.Aborted, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
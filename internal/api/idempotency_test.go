@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/idempotency"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+	pb "github.com/tombombadilom/liveops/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// eventBody returns the body of a request creating an event
+func eventBody(title string) string {
+	start := time.Now().Add(time.Hour).UTC()
+	body, _ := json.Marshal(map[string]any{
+		"title":      title,
+		"start_time": start.Truncate(time.Hour),
+		"end_time":   start.Truncate(time.Hour).Add(time.Hour),
+	})
+	return string(body)
+}
+
+// withIdempotencyKey returns the admin header along with an idempotency key
+func withIdempotencyKey(key string) map[string]string {
+	return map[string]string{"X-API-Key": store.AdminAPIKey, idempotencyKeyHeader: key}
+}
+
+func TestIdempotencyMiddlewareReplaysResponses(t *testing.T) {
+	server := newTestServer(t, clock.System)
+	body := eventBody("Summer festival")
+
+	first := server.do(http.MethodPost, "/api/events", body, withIdempotencyKey("create-1"))
+	checkStatus(t, first, http.StatusCreated)
+	if first.Header().Get(idempotencyReplayedHeader) != "" {
+		t.Errorf("first response is marked replayed")
+	}
+
+	retry := server.do(http.MethodPost, "/api/events", body, withIdempotencyKey("create-1"))
+	checkStatus(t, retry, http.StatusCreated)
+	if retry.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("retry is not marked replayed")
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("retry got %s, want %s", retry.Body.String(), first.Body.String())
+	}
+
+	// The event was only created once
+	events, _, err := server.eventService.ListEvents(models.EventFilter{}, models.DefaultEventSort, 0, "")
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("got %d events, want 1", len(events))
+	}
+
+	// A different request with the key is refused
+	checkStatus(t, server.do(http.MethodPost, "/api/events", eventBody("Winter festival"), withIdempotencyKey("create-1")), http.StatusConflict)
+
+	// Client errors are stored too
+	invalid := `{"title": "No times"}`
+	checkStatus(t, server.do(http.MethodPost, "/api/events", invalid, withIdempotencyKey("create-2")), http.StatusBadRequest)
+	retry = server.do(http.MethodPost, "/api/events", invalid, withIdempotencyKey("create-2"))
+	checkStatus(t, retry, http.StatusBadRequest)
+	if retry.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("retry of a client error is not marked replayed")
+	}
+
+	// Invalid keys are refused
+	checkStatus(t, server.do(http.MethodPost, "/api/events", body, withIdempotencyKey("with space")), http.StatusBadRequest)
+}
+
+func TestIdempotencyMiddlewareRefusesPendingKeys(t *testing.T) {
+	server := newTestServer(t, clock.System)
+	body := eventBody("Summer festival")
+
+	// Claim the key as a request in progress would
+	apiKey := adminAPIKey(t, server)
+	fingerprint := idempotency.Fingerprint([]byte(http.MethodPost), []byte("/api/events"), nil, []byte(body))
+	if _, err := server.idempotency.Begin(apiKey.ID, "create-1", fingerprint); err != nil {
+		t.Fatalf("failed to claim key: %v", err)
+	}
+
+	checkStatus(t, server.do(http.MethodPost, "/api/events", body, withIdempotencyKey("create-1")), http.StatusConflict)
+}
+
+func TestIdempotencyMiddlewareReleasesKeysOnServerErrors(t *testing.T) {
+	server := newTestServer(t, clock.System)
+
+	// Fail the first call with a server error and panic in the second
+	calls := 0
+	server.router.POST("/test/flaky", server.authMiddleware(), server.idempotencyMiddleware(), func(c *gin.Context) {
+		calls++
+		switch calls {
+		case 1:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database is locked"})
+		case 2:
+			panic("handler failed")
+		default:
+			c.JSON(http.StatusOK, gin.H{"calls": calls})
+		}
+	})
+
+	checkStatus(t, server.do(http.MethodPost, "/test/flaky", "{}", withIdempotencyKey("flaky-1")), http.StatusInternalServerError)
+	checkStatus(t, server.do(http.MethodPost, "/test/flaky", "{}", withIdempotencyKey("flaky-1")), http.StatusInternalServerError)
+	checkStatus(t, server.do(http.MethodPost, "/test/flaky", "{}", withIdempotencyKey("flaky-1")), http.StatusOK)
+
+	retry := server.do(http.MethodPost, "/test/flaky", "{}", withIdempotencyKey("flaky-1"))
+	checkStatus(t, retry, http.StatusOK)
+	if retry.Header().Get(idempotencyReplayedHeader) != "true" || calls != 3 {
+		t.Errorf("retry after success was handled again: %d calls", calls)
+	}
+}
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	server := newTestServer(t, clock.System)
+	ctx := idempotentCallContext(t, server, "call-1")
+	info := &grpc.UnaryServerInfo{FullMethod: pb.EventService_CreateEvent_FullMethodName}
+	req := &pb.CreateEventRequest{Title: "Summer festival"}
+
+	// Retryable failures and panics release the key
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, status.Error(codes.Internal, "database is locked")
+		case 2:
+			panic("handler failed")
+		default:
+			return &pb.Event{Id: "event-1", Title: req.(*pb.CreateEventRequest).Title}, nil
+		}
+	}
+
+	if _, err := server.grpc.idempotencyInterceptor(ctx, req, info, handler); status.Code(err) != codes.Internal {
+		t.Fatalf("first call returned %v, want Internal", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("second call did not panic")
+			}
+		}()
+		server.grpc.idempotencyInterceptor(ctx, req, info, handler)
+	}()
+	first, err := server.grpc.idempotencyInterceptor(ctx, req, info, handler)
+	if err != nil {
+		t.Fatalf("third call failed: %v", err)
+	}
+
+	// Retries get the stored response
+	retry, err := server.grpc.idempotencyInterceptor(ctx, req, info, handler)
+	if err != nil || !proto.Equal(retry.(proto.Message), first.(proto.Message)) || calls != 3 {
+		t.Fatalf("retry returned %v, %v after %d calls, want %v", retry, err, calls, first)
+	}
+
+	// A different request with the key is refused
+	other := &pb.CreateEventRequest{Title: "Winter festival"}
+	if _, err := server.grpc.idempotencyInterceptor(ctx, other, info, handler); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("call with a reused key returned %v, want AlreadyExists", err)
+	}
+
+	// Errors other than retryable ones are stored
+	ctx = idempotentCallContext(t, server, "call-2")
+	invalid := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, status.Error(codes.InvalidArgument, "title cannot be empty")
+	}
+	for range 2 {
+		if _, err := server.grpc.idempotencyInterceptor(ctx, other, info, invalid); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("invalid call returned %v, want InvalidArgument", err)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("retry of an invalid call was handled again")
+	}
+
+	// Calls still in progress are refused
+	ctx = idempotentCallContext(t, server, "call-3")
+	nested := func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, err := server.grpc.idempotencyInterceptor(ctx, req, info, handler)
+		return nil, err
+	}
+	if _, err := server.grpc.idempotencyInterceptor(ctx, other, info, nested); status.Code(err) != codes.Aborted {
+		t.Errorf("call during another with its key returned %v, want Aborted", err)
+	}
+}
+
+// adminAPIKey authenticates the seeded admin key
+func adminAPIKey(t *testing.T, server *testServer) *models.APIKey {
+	t.Helper()
+
+	_, apiKey, err := server.authService.AuthenticateAPIKey(store.AdminAPIKey)
+	if err != nil {
+		t.Fatalf("failed to authenticate admin key: %v", err)
+	}
+	return apiKey
+}
+
+// idempotentCallContext returns the context of a gRPC call authenticated
+// with the admin key and carrying an idempotency key
+func idempotentCallContext(t *testing.T, server *testServer, key string) context.Context {
+	t.Helper()
+
+	user, apiKey, err := server.authService.AuthenticateAPIKey(store.AdminAPIKey)
+	if err != nil {
+		t.Fatalf("failed to authenticate admin key: %v", err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("idempotency-key", key))
+	return context.WithValue(ctx, callerKey{}, &caller{user: user, apiKey: apiKey})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/outbox"
+)
+
+// listOutboxEntries handles GET /api/admin/outbox
+func (s *HTTPServer) listOutboxEntries(c *gin.Context) {
+	// Parse query parameters
+	var query struct {
+		Status    models.OutboxStatus `form:"status"`
+		EventID   string              `form:"event_id"`
+		PageSize  int                 `form:"page_size" binding:"min=0"`
+		PageToken string              `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Status != "" && !query.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid outbox status"})
+		return
+	}
+
+	filter := models.OutboxFilter{Status: query.Status}
+	if query.EventID != "" {
+		id, err := uuid.Parse(query.EventID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+			return
+		}
+		filter.EventID = &id
+	}
+
+	entries, nextPageToken, err := s.outbox.ListEntries(filter, query.PageSize, query.PageToken)
+	if err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []*models.OutboxEntry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":         entries,
+		"next_page_token": nextPageToken,
+	})
+}
+
+// getOutboxEntry handles GET /api/admin/outbox/:seq
+func (s *HTTPServer) getOutboxEntry(c *gin.Context) {
+	seq, err := strconv.ParseInt(c.Param("seq"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid outbox sequence number"})
+		return
+	}
+
+	entry, err := s.outbox.GetEntry(seq)
+	if err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// retryOutboxEntry handles POST /api/admin/outbox/:seq/retry
+func (s *HTTPServer) retryOutboxEntry(c *gin.Context) {
+	seq, err := strconv.ParseInt(c.Param("seq"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid outbox sequence number"})
+		return
+	}
+
+	entry, err := s.outbox.RetryEntry(c.Request.Context(), seq)
+	if err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, entry)
+}
+
+// respondOutboxError writes the response for an error from the outbox
+// service
+func respondOutboxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrOutboxNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Outbox entry not found"})
+	case errors.Is(err, models.ErrOutboxNotFailed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, outbox.ErrInvalidPageToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// registerPublicRoutes sets up the read-only player API. Players authenticate
+// with tokens minted by the game backend instead of API keys.
+func (s *HTTPServer) registerPublicRoutes() {
+	public := s.router.Group("/public/v1")
+	public.Use(s.authLimitMiddleware(), s.playerAuthMiddleware(), s.rateLimitMiddleware())
+	{
+		public.GET("/events/active", s.listPublicActiveEvents)
+		public.GET("/events/eligible", s.listPublicEligibleEvents)
+		public.GET("/events/:id", s.getPublicEvent)
+	}
+}
+
+// playerAuthMiddleware authenticates players with a bearer player token
+func (s *HTTPServer) playerAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get token from header
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			s.chargeAuthFailure(c)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Player token required",
+			})
+			return
+		}
+
+		// Verify token
+		claims, err := s.playerTokens.Verify(token)
+		if err != nil {
+			s.chargeAuthFailure(c)
+			message := "Invalid player token"
+			if errors.Is(err, models.ErrPlayerTokenExpired) {
+				message = "Player token expired"
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": message,
+			})
+			return
+		}
+
+		// Store claims in context
+		c.Set("player", claims)
+		c.Next()
+	}
+}
+
+// currentPlayer returns the claims of the player token the request was
+// authenticated with
+func currentPlayer(c *gin.Context) *auth.PlayerClaims {
+	value, _ := c.Get("player")
+	claims, _ := value.(*auth.PlayerClaims)
+	return claims
+}
+
+// listPublicActiveEvents handles GET /public/v1/events/active. Players only
+// ever see the events targeting them, so this lists the same events as
+// /public/v1/events/eligible.
+func (s *HTTPServer) listPublicActiveEvents(c *gin.Context) {
+	filter := s.eventService.ActiveFilter(time.Time{})
+	filter.Player = currentPlayer(c).Player()
+
+	s.respondPublicEventPage(c, func(pageSize int, pageToken string) ([]*models.LiveEvent, string, error) {
+		return s.eventService.ListEvents(filter, models.DefaultEventSort, pageSize, pageToken)
+	})
+}
+
+// listPublicEligibleEvents handles GET /public/v1/events/eligible, listing the
+// active events targeting the player described by the token
+func (s *HTTPServer) listPublicEligibleEvents(c *gin.Context) {
+	player := currentPlayer(c).Player()
+
+	s.respondPublicEventPage(c, func(pageSize int, pageToken string) ([]*models.LiveEvent, string, error) {
+		return s.eventService.ListEligibleEvents(player, time.Time{}, pageSize, pageToken)
+	})
+}
+
+// respondPublicEventPage lists a page of events in their player view
+func (s *HTTPServer) respondPublicEventPage(c *gin.Context, list func(pageSize int, pageToken string) ([]*models.LiveEvent, string, error)) {
+	// Parse query parameters
+	var query struct {
+		PageSize  int    `form:"page_size" binding:"min=0"`
+		PageToken string `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get events from service
+	events, nextPageToken, err := list(query.PageSize, query.PageToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPageToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	// Convert to player view
+	publicEvents := make([]*models.PublicEvent, len(events))
+	for i, event := range events {
+		publicEvents[i] = event.Public()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":          publicEvents,
+		"next_page_token": nextPageToken,
+	})
+}
+
+// getPublicEvent handles GET /public/v1/events/:id. Events that are not
+// published, or whose targeting excludes the player described by the token,
+// are reported as not found.
+func (s *HTTPServer) getPublicEvent(c *gin.Context) {
+	event, err := s.eventService.GetEvent(c.Param("id"))
+	if err == nil && (!event.Status.IsPublished() || !event.TargetsPlayer(currentPlayer(c).Player())) {
+		err = models.ErrEventNotFound
+	}
+	if err != nil {
+		if err == models.ErrEventNotFound || err == models.ErrInvalidID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, event.Public())
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// TestPublicEventsHonorTargeting checks that players neither list nor get the
+// events whose targeting excludes them
+func TestPublicEventsHonorTargeting(t *testing.T) {
+	server := newTestServer(t, clock.System)
+	now := time.Now()
+
+	publish := func(title, targeting string) *models.LiveEvent {
+		t.Helper()
+		event, err := server.eventService.CreateEvent(context.Background(), models.EventFields{
+			Title:     title,
+			StartTime: now.Add(-time.Hour),
+			EndTime:   now.Add(time.Hour),
+			Targeting: targeting,
+		})
+		if err != nil {
+			t.Fatalf("failed to create event: %v", err)
+		}
+		if event, err = server.eventService.PublishEvent(context.Background(), event.ID.String()); err != nil {
+			t.Fatalf("failed to publish event: %v", err)
+		}
+		return event
+	}
+	included := publish("EU event", `region == "EU"`)
+	excluded := publish("US event", `region == "US"`)
+
+	header := playerHeader(t, auth.PlayerClaims{Subject: "player-1", Level: 10, Region: "EU", Platform: "ios"})
+
+	for _, path := range []string{"/public/v1/events/active", "/public/v1/events/eligible"} {
+		rec := server.do(http.MethodGet, path, "", header)
+		checkStatus(t, rec, http.StatusOK)
+
+		var page struct {
+			Events []struct {
+				ID string `json:"id"`
+			} `json:"events"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", path, err)
+		}
+		if len(page.Events) != 1 || page.Events[0].ID != included.ID.String() {
+			t.Errorf("%s: got %s, want only event %s", path, rec.Body.String(), included.ID)
+		}
+	}
+
+	checkStatus(t, server.do(http.MethodGet, "/public/v1/events/"+included.ID.String(), "", header), http.StatusOK)
+	checkStatus(t, server.do(http.MethodGet, "/public/v1/events/"+excluded.ID.String(), "", header), http.StatusNotFound)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Rate limit headers, following the IETF RateLimit header fields draft
+const (
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// rateLimitHeaders returns the headers describing a rate limit result
+func rateLimitHeaders(result ratelimit.Result) map[string]string {
+	headers := map[string]string{
+		rateLimitLimitHeader:     strconv.Itoa(result.Limit),
+		rateLimitRemainingHeader: strconv.Itoa(result.Remaining),
+		rateLimitResetHeader:     ceilSeconds(result.Reset),
+	}
+	if !result.Allowed {
+		headers[retryAfterHeader] = ceilSeconds(result.RetryAfter)
+	}
+	return headers
+}
+
+// ceilSeconds formats a duration as a whole number of seconds, rounding up
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// rateLimitMiddleware limits requests per API key or player, or per client IP
+// when the request is not authenticated
+func (s *HTTPServer) rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.limiter.Enabled() {
+			c.Next()
+			return
+		}
+
+		var result ratelimit.Result
+		if key := currentAPIKey(c); key != nil {
+			result = s.limiter.AllowKey(c.MustGet("user").(*models.User), key)
+		} else if player := currentPlayer(c); player != nil {
+			result = s.limiter.AllowPlayer(player.Subject)
+		} else {
+			result = s.limiter.AllowIP(c.ClientIP())
+		}
+
+		if !applyRateLimit(c, result) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authLimitMiddleware rejects requests from a client IP whose budget is used
+// up before their credentials are checked. Authentication failures are
+// charged to the client IP by chargeAuthFailure, so that guessing API keys or
+// player tokens is limited like unauthenticated requests.
+func (s *HTTPServer) authLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if result := s.limiter.CheckIP(c.ClientIP()); !result.Allowed {
+			applyRateLimit(c, result)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// chargeAuthFailure takes a token from the bucket of the client IP of a
+// request that failed authentication
+func (s *HTTPServer) chargeAuthFailure(c *gin.Context) {
+	s.limiter.AllowIP(c.ClientIP())
+}
+
+// applyRateLimit reports a rate limit result in the response headers, and
+// aborts the request and returns false when it was denied
+func applyRateLimit(c *gin.Context, result ratelimit.Result) bool {
+	for name, value := range rateLimitHeaders(result) {
+		c.Header(name, value)
+	}
+
+	if !result.Allowed {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "Rate limit exceeded",
+		})
+		return false
+	}
+
+	return true
+}
+
+// rateLimitInterceptor applies the rate limit to unary calls
+func (s *GRPCServer) rateLimitInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.checkRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// rateLimitStreamInterceptor applies the rate limit to the opening of streams
+func (s *GRPCServer) rateLimitStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.checkRateLimit(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// checkRateLimit takes a token for the caller's API key, or for the peer IP
+// when the request carries no valid API key, and reports the outcome in the
+// response headers
+func (s *GRPCServer) checkRateLimit(ctx context.Context) error {
+	if !s.limiter.Enabled() {
+		return nil
+	}
+
+	var result ratelimit.Result
+	if user, key, err := callerFromContext(ctx); err == nil {
+		result = s.limiter.AllowKey(user, key)
+	} else {
+		result = s.limiter.AllowIP(peerIP(ctx))
+	}
+
+	md := metadata.MD{}
+	for name, value := range rateLimitHeaders(result) {
+		md.Set(name, value)
+	}
+	grpc.SetHeader(ctx, md)
+
+	if !result.Allowed {
+		st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(result.RetryAfter),
+		})
+		if err != nil {
+			return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		return st.Err()
+	}
+
+	return nil
+}
+
+// peerIP returns the IP address of the gRPC client
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -4,13 +4,25 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/soheilhy/cmux"
+	"github.com/tombombadilom/liveops/internal/audit"
 	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/idempotency"
+	"github.com/tombombadilom/liveops/internal/outbox"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
 	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/webhook"
 )
 
+// maxOccurrenceLimit caps the number of occurrences expanded per request
+const maxOccurrenceLimit = 500
+
+// sseHeartbeatInterval is how often idle event streams send a keepalive
+const sseHeartbeatInterval = 15 * time.Second
+
 // Server represents the API server that handles both HTTP and gRPC
 type Server struct {
 	httpServer *HTTPServer
@@ -20,10 +32,10 @@ type Server struct {
 }
 
 // NewServer creates a new API server
-func NewServer(port int, eventService *service.EventService, authService *auth.AuthService) *Server {
+func NewServer(port int, eventService *service.EventService, authService *auth.AuthService, auditService *audit.AuditService, rewardService *service.RewardService, limiter *ratelimit.Limiter, playerTokens *auth.PlayerTokenVerifier, webhooks *webhook.Service, outboxService *outbox.Service, idempotencyService *idempotency.Service, allowRequestAPIKey bool) *Server {
 	return &Server{
-		httpServer: NewHTTPServer(eventService, authService),
-		grpcServer: NewGRPCServer(eventService, authService),
+		httpServer: NewHTTPServer(eventService, authService, auditService, rewardService, limiter, playerTokens, webhooks, outboxService, idempotencyService),
+		grpcServer: NewGRPCServer(eventService, authService, auditService, limiter, idempotencyService, allowRequestAPIKey),
 		port:       port,
 	}
 }
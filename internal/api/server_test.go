@@ -0,0 +1,100 @@
+package api
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/auth"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/idempotency"
+	"github.com/tombombadilom/liveops/internal/outbox"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
+	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/store/memory"
+	"github.com/tombombadilom/liveops/internal/webhook"
+)
+
+// testPlayerTokenSecret signs the player tokens of the test server
+const testPlayerTokenSecret = "test-player-token-secret"
+
+// testServer is an HTTP server on a fresh memory store, without rate limits,
+// along with the gRPC server sharing its services
+type testServer struct {
+	*HTTPServer
+	grpc *GRPCServer
+}
+
+// newTestServer creates a test server telling the time by clk
+func newTestServer(t *testing.T, clk clock.Clock) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repos := memory.New()
+	auditService := audit.NewAuditService(repos.Audit)
+	rewardService := service.NewRewardService(repos.RewardCatalog, auditService)
+	webhookService := webhook.NewService(repos.Webhooks, auditService, clk, nil)
+	outboxService := outbox.NewService(repos.Outbox, nil, auditService, clk)
+	eventService := service.NewEventService(repos.Events, repos.EventRevisions, repos.Outbox, repos.Transactor, rewardService, auditService, clk, outboxService)
+	authService := auth.NewAuthService(repos.Users, repos.APIKeys, auditService, "")
+	idempotencyService := idempotency.NewService(repos.Idempotency, clk, time.Hour)
+	if _, err := authService.HashLegacyKeys(); err != nil {
+		t.Fatalf("failed to hash legacy API keys: %v", err)
+	}
+	limiter := ratelimit.NewLimiter(repos.RateLimits, auditService, 0)
+	playerTokens := auth.NewPlayerTokenVerifier(testPlayerTokenSecret, time.Hour)
+
+	return &testServer{
+		HTTPServer: NewHTTPServer(eventService, authService, auditService, rewardService, limiter, playerTokens, webhookService, outboxService, idempotencyService),
+		grpc:       NewGRPCServer(eventService, authService, auditService, limiter, idempotencyService, false),
+	}
+}
+
+// do sends a request to the server with the given headers and returns the
+// response
+func (s *testServer) do(method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// adminHeader authenticates requests with the seeded admin key
+var adminHeader = map[string]string{"X-API-Key": store.AdminAPIKey}
+
+// playerHeader returns the header authenticating requests as a player
+func playerHeader(t *testing.T, claims auth.PlayerClaims) map[string]string {
+	t.Helper()
+
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(10 * time.Minute).Unix()
+	token, err := auth.SignPlayerToken(testPlayerTokenSecret, &claims)
+	if err != nil {
+		t.Fatalf("failed to sign player token: %v", err)
+	}
+
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// checkStatus fails the test unless a response has the wanted status
+func checkStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, want, rec.Body.String())
+	}
+}
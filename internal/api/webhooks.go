@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/webhook"
+)
+
+// listWebhooks handles GET /api/admin/webhooks
+func (s *HTTPServer) listWebhooks(c *gin.Context) {
+	subscriptions, err := s.webhooks.ListSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []*models.WebhookSubscription{}
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// createWebhook handles POST /api/admin/webhooks. The signing secret is
+// only returned in this response.
+func (s *HTTPServer) createWebhook(c *gin.Context) {
+	// Parse request
+	var req struct {
+		URL        string              `json:"url" binding:"required"`
+		EventTypes []models.ChangeType `json:"event_types"`
+		Secret     string              `json:"secret"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Create subscription
+	subscription, err := s.webhooks.CreateSubscription(c.Request.Context(), req.URL, req.EventTypes, req.Secret)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidWebhook) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"subscription": subscription,
+		"secret":       subscription.Secret,
+	})
+}
+
+// getWebhook handles GET /api/admin/webhooks/:id
+func (s *HTTPServer) getWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	subscription, err := s.webhooks.GetSubscription(id)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// deleteWebhook handles DELETE /api/admin/webhooks/:id
+func (s *HTTPServer) deleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	if err := s.webhooks.DeleteSubscription(c.Request.Context(), id); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// listWebhookDeliveries handles GET /api/admin/webhooks/:id/deliveries
+func (s *HTTPServer) listWebhookDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	// Parse query parameters
+	var query struct {
+		Status    models.WebhookDeliveryStatus `form:"status"`
+		EventID   string                       `form:"event_id"`
+		PageSize  int                          `form:"page_size" binding:"min=0"`
+		PageToken string                       `form:"page_token"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Status != "" && !query.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery status"})
+		return
+	}
+
+	// Report unknown subscriptions rather than an empty log
+	if _, err := s.webhooks.GetSubscription(id); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	filter := models.WebhookDeliveryFilter{
+		SubscriptionID: &id,
+		Status:         query.Status,
+		EventID:        query.EventID,
+	}
+
+	deliveries, nextPageToken, err := s.webhooks.ListDeliveries(filter, query.PageSize, query.PageToken)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries":      deliveries,
+		"next_page_token": nextPageToken,
+	})
+}
+
+// getWebhookDelivery handles GET /api/admin/webhook-deliveries/:id
+func (s *HTTPServer) getWebhookDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, attempts, err := s.webhooks.GetDelivery(id)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	if attempts == nil {
+		attempts = []*models.WebhookAttempt{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+		"attempts": attempts,
+	})
+}
+
+// redeliverWebhook handles POST /api/admin/webhook-deliveries/:id/redeliver
+func (s *HTTPServer) redeliverWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := s.webhooks.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// respondWebhookError writes the response for an error from the webhook
+// service
+func respondWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, models.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+	case errors.Is(err, webhook.ErrInvalidPageToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// Page size limits for ListEntries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ErrInvalidPageToken is returned for malformed pagination cursors
+var ErrInvalidPageToken = models.ErrInvalidPageToken
+
+// Actor identifies who performed an operation and through which request
+type Actor struct {
+	UserID    *uuid.UUID
+	APIKeyID  *uuid.UUID
+	RequestID string
+	Transport string
+}
+
+// actorKey is the context key for the current Actor
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor of the current request
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in the context. Operations
+// without one are attributed to the system.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Transport: models.TransportSystem}
+}
+
+// NewActor builds an actor for a user authenticated through an API key
+func NewActor(user *models.User, apiKey *models.APIKey, requestID, transport string) Actor {
+	actor := Actor{RequestID: requestID, Transport: transport}
+	if user != nil {
+		id := user.ID
+		actor.UserID = &id
+	}
+	if apiKey != nil {
+		id := apiKey.ID
+		actor.APIKeyID = &id
+	}
+	return actor
+}
+
+// AuditService records and queries the audit log
+type AuditService struct {
+	auditRepo store.AuditRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo store.AuditRepository) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record appends an entry for a mutation on a target. Before and after are
+// the target's state around the change, nil for creations and deletions;
+// only fields that differ are kept for updates. Failures are logged rather
+// than returned, since the mutation itself has already been applied.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, before, after interface{}) {
+	if s == nil {
+		return
+	}
+
+	beforeJSON, afterJSON, err := diff(before, after)
+	if err != nil {
+		log.Error().Err(err).Str("target_type", targetType).Str("target_id", targetID).Msg("Failed to encode audit snapshot")
+		return
+	}
+
+	actor := ActorFromContext(ctx)
+	entry := &models.AuditEntry{
+		ID:            uuid.New(),
+		ActorUserID:   actor.UserID,
+		ActorAPIKeyID: actor.APIKeyID,
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		Before:        beforeJSON,
+		After:         afterJSON,
+		RequestID:     actor.RequestID,
+		Transport:     actor.Transport,
+		CreatedAt:     time.Now(),
+	}
+
+	if err := s.auditRepo.Create(entry); err != nil {
+		log.Error().Err(err).Str("target_type", targetType).Str("target_id", targetID).Msg("Failed to record audit entry")
+	}
+}
+
+// ListEntries returns a page of audit entries matching the filter, newest
+// first, and the token for the next page (empty on the last page)
+func (s *AuditService) ListEntries(filter models.AuditFilter, pageSize int, pageToken string) ([]*models.AuditEntry, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var beforeSeq int64
+	if pageToken != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", ErrInvalidPageToken
+		}
+		beforeSeq, err = strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || beforeSeq <= 0 {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	// Fetch one extra entry to know whether another page exists
+	entries, err := s.auditRepo.List(filter, beforeSeq, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	var nextPageToken string
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		last := entries[len(entries)-1].Seq
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(last, 10)))
+	}
+
+	return entries, nextPageToken, nil
+}
+
+// diff encodes the before and after snapshots of a target. When both are
+// present, only top-level fields whose values differ are kept.
+func diff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalFields(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := marshalFields(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
+
+// toFields converts a value to its JSON object fields
+func toFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// marshalFields encodes fields, returning nil for a missing snapshot
+func marshalFields(fields map[string]interface{}) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
@@ -1,45 +1,69 @@
 package auth
 
 import (
-	"errors"
+	"context"
 	"strings"
 
 	"github.com/google/uuid"
-	"github.com/tombombadilom/liveops/internal/db"
+	"github.com/tombombadilom/liveops/internal/audit"
 	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
 )
 
 // AuthService handles authentication and authorization
 type AuthService struct {
-	userRepo   *db.UserRepository
-	apiKeyRepo *db.APIKeyRepository
+	userRepo     store.UserRepository
+	apiKeyRepo   store.APIKeyRepository
+	auditService *audit.AuditService
+	pepper       []byte
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(userRepo *db.UserRepository, apiKeyRepo *db.APIKeyRepository) *AuthService {
+// NewAuthService creates a new authentication service. The pepper keys the
+// hash under which API keys are stored; changing it invalidates every key.
+func NewAuthService(userRepo store.UserRepository, apiKeyRepo store.APIKeyRepository, auditService *audit.AuditService, pepper string) *AuthService {
 	return &AuthService{
-		userRepo:   userRepo,
-		apiKeyRepo: apiKeyRepo,
+		userRepo:     userRepo,
+		apiKeyRepo:   apiKeyRepo,
+		auditService: auditService,
+		pepper:       []byte(pepper),
 	}
 }
 
+// HashLegacyKeys replaces API keys still stored in plaintext with their hash.
+// It returns the number of keys that were converted.
+func (s *AuthService) HashLegacyKeys() (int, error) {
+	keys, err := s.apiKeyRepo.ListLegacyAPIKeys()
+	if err != nil {
+		return 0, err
+	}
+
+	for id, key := range keys {
+		if err := s.apiKeyRepo.SetAPIKeyHash(id, models.KeyPrefix(key), hashAPIKey(s.pepper, key)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
 // AuthenticateAPIKey validates an API key and returns the associated user
-func (s *AuthService) AuthenticateAPIKey(apiKey string) (*models.User, error) {
+// along with the stored key, whose scopes restrict the user's permissions
+func (s *AuthService) AuthenticateAPIKey(apiKey string) (*models.User, *models.APIKey, error) {
 	// Validate API key format
 	apiKey = strings.TrimSpace(apiKey)
 	if apiKey == "" {
-		return nil, models.ErrInvalidAPIKey
+		return nil, nil, models.ErrInvalidAPIKey
 	}
 
 	// Get API key from database
-	key, err := s.apiKeyRepo.GetAPIKeyByKey(apiKey)
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(hashAPIKey(s.pepper, apiKey))
 	if err != nil {
-		return nil, models.ErrInvalidAPIKey
+		return nil, nil, models.ErrInvalidAPIKey
 	}
 
 	// Check if key is expired
 	if key.IsExpired() {
-		return nil, models.ErrInvalidAPIKey
+		return nil, nil, models.ErrInvalidAPIKey
 	}
 
 	// Update last used timestamp
@@ -51,27 +75,31 @@ func (s *AuthService) AuthenticateAPIKey(apiKey string) (*models.User, error) {
 	// Get user associated with the API key
 	user, err := s.userRepo.GetUserByID(key.UserID)
 	if err != nil {
-		return nil, models.ErrUnauthorized
+		return nil, nil, models.ErrUnauthorized
 	}
 
-	return user, nil
+	return user, key, nil
 }
 
-// CheckPermission checks if a user has permission for an action
-func (s *AuthService) CheckPermission(user *models.User, action string) error {
+// CheckPermission checks if a user, acting through the given API key, has
+// permission for an action. The key's scopes can only narrow what the
+// user's role allows; a nil key applies the role alone.
+func (s *AuthService) CheckPermission(user *models.User, apiKey *models.APIKey, action string) error {
 	if user == nil {
 		return models.ErrUnauthorized
 	}
 
-	if !models.HasPermission(user.Role, action) {
+	if !models.HasPermission(user.Role, action) || !apiKey.Allows(action) {
 		return models.ErrForbidden
 	}
 
 	return nil
 }
 
-// CreateAPIKey creates a new API key for a user
-func (s *AuthService) CreateAPIKey(userID string, validDays int) (*models.APIKey, error) {
+// CreateAPIKey creates a new API key for a user, optionally restricted to
+// the given scopes. The returned key carries the full secret, which is not
+// stored and cannot be retrieved again.
+func (s *AuthService) CreateAPIKey(ctx context.Context, userID string, validDays int, scopes []models.Scope) (*models.APIKey, error) {
 	// Parse UUID
 	uid, err := uuid.Parse(userID)
 	if err != nil {
@@ -81,47 +109,79 @@ func (s *AuthService) CreateAPIKey(userID string, validDays int) (*models.APIKey
 	// Check if user exists
 	user, err := s.userRepo.GetUserByID(uid)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, models.ErrUserNotFound
+	}
+
+	// Validate scopes against the user's role
+	if err := models.ValidateScopes(user.Role, scopes); err != nil {
+		return nil, err
 	}
 
 	// Generate API key
-	apiKey, err := models.GenerateAPIKey(user.ID, validDays)
+	apiKey, err := models.GenerateAPIKey(user.ID, validDays, scopes)
 	if err != nil {
 		return nil, err
 	}
 
 	// Save to database
+	apiKey.KeyHash = hashAPIKey(s.pepper, apiKey.Key)
 	if err := s.apiKeyRepo.CreateAPIKey(apiKey); err != nil {
 		return nil, err
 	}
 
+	s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetAPIKey, apiKey.ID.String(), nil, redactAPIKey(apiKey))
+
 	return apiKey, nil
 }
 
 // RevokeAPIKey revokes an API key
-func (s *AuthService) RevokeAPIKey(apiKeyID string) error {
+func (s *AuthService) RevokeAPIKey(ctx context.Context, apiKeyID string) error {
 	// Parse UUID
 	id, err := uuid.Parse(apiKeyID)
 	if err != nil {
 		return models.ErrInvalidID
 	}
 
+	// Get existing key for the audit log
+	apiKey, err := s.apiKeyRepo.GetAPIKeyByID(id)
+	if err != nil {
+		return err
+	}
+
 	// Delete from database
-	return s.apiKeyRepo.DeleteAPIKey(id)
+	if err := s.apiKeyRepo.DeleteAPIKey(id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetAPIKey, apiKey.ID.String(), redactAPIKey(apiKey), nil)
+
+	return nil
+}
+
+// redactAPIKey returns a copy of the key without its secret
+func redactAPIKey(apiKey *models.APIKey) *models.APIKey {
+	redacted := *apiKey
+	redacted.Key = ""
+	return &redacted
 }
 
 // CreateUser creates a new user
-func (s *AuthService) CreateUser(username string, role models.Role) (*models.User, error) {
+func (s *AuthService) CreateUser(ctx context.Context, username string, role models.Role) (*models.User, error) {
 	// Validate username
 	username = strings.TrimSpace(username)
 	if username == "" {
-		return nil, errors.New("username cannot be empty")
+		return nil, models.ErrEmptyUsername
+	}
+
+	// Validate role
+	if !role.IsValid() {
+		return nil, models.ErrInvalidRole
 	}
 
 	// Check if username already exists
 	_, err := s.userRepo.GetUserByUsername(username)
 	if err == nil {
-		return nil, errors.New("username already exists")
+		return nil, models.ErrUsernameExists
 	}
 
 	// Create user
@@ -132,9 +192,96 @@ func (s *AuthService) CreateUser(username string, role models.Role) (*models.Use
 		return nil, err
 	}
 
+	s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetUser, user.ID.String(), nil, user)
+
+	return user, nil
+}
+
+// UpdateUserRole changes the role of a user. The last admin cannot be
+// demoted, so that the system always stays manageable.
+func (s *AuthService) UpdateUserRole(ctx context.Context, userID string, role models.Role) (*models.User, error) {
+	// Parse UUID
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, models.ErrInvalidID
+	}
+
+	// Validate role
+	if !role.IsValid() {
+		return nil, models.ErrInvalidRole
+	}
+
+	// Get existing user
+	user, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == role {
+		return user, nil
+	}
+	if err := s.checkNotLastAdmin(user); err != nil {
+		return nil, err
+	}
+
+	// Update database
+	if err := s.userRepo.UpdateUserRole(id, role); err != nil {
+		return nil, err
+	}
+
+	before := *user
+	user.Role = role
+	s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetUser, user.ID.String(), &before, user)
+
 	return user, nil
 }
 
+// DeleteUser removes a user along with their API keys. The last admin cannot
+// be deleted.
+func (s *AuthService) DeleteUser(ctx context.Context, userID string) error {
+	// Parse UUID
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return models.ErrInvalidID
+	}
+
+	// Get existing user for the audit log
+	user, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkNotLastAdmin(user); err != nil {
+		return err
+	}
+
+	// Delete from database
+	if err := s.userRepo.DeleteUser(id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetUser, user.ID.String(), user, nil)
+
+	return nil
+}
+
+// checkNotLastAdmin fails if the user is the only admin left
+func (s *AuthService) checkNotLastAdmin(user *models.User) error {
+	if user.Role != models.RoleAdmin {
+		return nil
+	}
+
+	count, err := s.userRepo.CountUsersByRole(models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return models.ErrLastAdmin
+	}
+
+	return nil
+}
+
 // GetUser retrieves a user by ID
 func (s *AuthService) GetUser(userID string) (*models.User, error) {
 	// Parse UUID
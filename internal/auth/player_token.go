@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/targeting"
+)
+
+// playerTokenHeader is the JWT header of the tokens minted by SignPlayerToken
+var playerTokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// PlayerClaims are the claims of a player token. Besides the player ID and
+// the validity window, the token carries the player attributes events are
+// targeted on, so that players cannot pick the segment they fall in.
+type PlayerClaims struct {
+	Subject   string   `json:"sub"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+	Level     int      `json:"level,omitempty"`
+	Region    string   `json:"region,omitempty"`
+	Platform  string   `json:"platform,omitempty"`
+	Cohorts   []string `json:"cohorts,omitempty"`
+	// AppVersion is the version of the game client
+	AppVersion string `json:"app_version,omitempty"`
+}
+
+// Player returns the targeting attributes of the player
+func (c *PlayerClaims) Player() *targeting.Player {
+	return &targeting.Player{
+		Level:      c.Level,
+		Region:     c.Region,
+		Platform:   c.Platform,
+		AppVersion: c.AppVersion,
+		Cohorts:    c.Cohorts,
+	}
+}
+
+// PlayerTokenVerifier checks the tokens game backends mint for their players.
+// Tokens are HS256 JSON Web Tokens signed with a secret shared with the game
+// backend.
+type PlayerTokenVerifier struct {
+	secret []byte
+	maxTTL time.Duration
+}
+
+// NewPlayerTokenVerifier creates a verifier for tokens signed with the secret
+// and valid for at most maxTTL
+func NewPlayerTokenVerifier(secret string, maxTTL time.Duration) *PlayerTokenVerifier {
+	return &PlayerTokenVerifier{
+		secret: []byte(secret),
+		maxTTL: maxTTL,
+	}
+}
+
+// Verify checks the signature and validity window of a token and returns its
+// claims
+func (v *PlayerTokenVerifier) Verify(token string) (*PlayerClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, models.ErrInvalidPlayerToken
+	}
+
+	// Only HS256 is accepted, whatever else the header says
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(headerJSON, &header) != nil || header.Algorithm != "HS256" {
+		return nil, models.ErrInvalidPlayerToken
+	}
+
+	// Check signature
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, signPlayerToken(v.secret, parts[0]+"."+parts[1])) {
+		return nil, models.ErrInvalidPlayerToken
+	}
+
+	// Parse claims
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, models.ErrInvalidPlayerToken
+	}
+
+	var claims PlayerClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, models.ErrInvalidPlayerToken
+	}
+	if claims.Subject == "" || claims.IssuedAt == 0 || claims.ExpiresAt == 0 {
+		return nil, fmt.Errorf("%w: sub, iat and exp are required", models.ErrInvalidPlayerToken)
+	}
+
+	// Check validity window, allowing for a little clock skew between the
+	// game backend and this server
+	const clockSkew = time.Minute
+	now := time.Now()
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+
+	if expiresAt.Sub(issuedAt) > v.maxTTL {
+		return nil, fmt.Errorf("%w: lifetime exceeds %s", models.ErrInvalidPlayerToken, v.maxTTL)
+	}
+	if issuedAt.After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: issued in the future", models.ErrInvalidPlayerToken)
+	}
+	if !now.Before(expiresAt.Add(clockSkew)) {
+		return nil, models.ErrPlayerTokenExpired
+	}
+
+	return &claims, nil
+}
+
+// SignPlayerToken mints a player token for the claims. Game backends do this
+// on their side with any JWT library; it is provided for tooling.
+func SignPlayerToken(secret string, claims *PlayerClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode player token claims: %w", err)
+	}
+
+	unsigned := playerTokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := signPlayerToken([]byte(secret), unsigned)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// signPlayerToken returns the HMAC-SHA256 of the signed part of a token
+func signPlayerToken(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,30 @@
+// Package clock abstracts the current time, so that event schedules can be
+// evaluated at an arbitrary instant and time-dependent code can be driven by
+// a controlled clock.
+package clock
+
+import "time"
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the wall clock
+var System Clock = systemClock{}
+
+// systemClock reads the time from the operating system
+type systemClock struct{}
+
+// Now returns the current wall clock time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock stopped at an instant
+type Fixed time.Time
+
+// Now returns the instant of the clock
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
@@ -4,6 +4,8 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
@@ -11,8 +13,13 @@ type Config struct {
 	// Server configuration
 	Port int
 
-	// Database configuration
-	DBPath string
+	// Storage configuration. DBDriver selects the backend: "sqlite" persists
+	// to DBPath, "postgres" to the database at DBDSN, and "memory" keeps
+	// everything in memory until the server exits. The DSN may hold a
+	// password, so like the pepper it is only read from the environment.
+	DBDriver string
+	DBPath   string
+	DBDSN    string
 
 	// Logging configuration
 	LogLevel string
@@ -20,16 +27,44 @@ type Config struct {
 	// API configuration
 	APIKeyExpireDays int
 	RateLimitPerMin  int
+	// APIKeyPepper keys the hash under which API keys are stored. It is only
+	// read from the environment so that it does not show up in process lists.
+	APIKeyPepper string
+
+	// GRPCRequestAPIKey accepts the api_key field of gRPC requests from
+	// clients that cannot set metadata
+	GRPCRequestAPIKey bool
+
+	// PlayerTokenSecret signs the tokens game backends mint for players of
+	// the public API, which is disabled while it is empty. Like the pepper it
+	// is only read from the environment.
+	PlayerTokenSecret string
+	// PlayerTokenMaxTTL bounds the lifetime of accepted player tokens
+	PlayerTokenMaxTTL time.Duration
+
+	// OutboxSinks lists the sinks event changes are sent to from the outbox:
+	// "log", "webhook" and "file", which appends them to OutboxFile
+	OutboxSinks []string
+	OutboxFile  string
+
+	// IdempotencyTTL is how long the responses to requests made with an
+	// idempotency key are replayed to retries
+	IdempotencyTTL time.Duration
 }
 
 // New creates a new configuration with values from environment variables or flags
 func New() *Config {
 	cfg := &Config{
-		Port:             8080,
-		DBPath:           "./liveops.db",
-		LogLevel:         "info",
-		APIKeyExpireDays: 30,
-		RateLimitPerMin:  60,
+		Port:              8080,
+		DBDriver:          "sqlite",
+		DBPath:            "./liveops.db",
+		LogLevel:          "info",
+		APIKeyExpireDays:  30,
+		RateLimitPerMin:   60,
+		PlayerTokenMaxTTL: time.Hour,
+		OutboxSinks:       []string{"webhook"},
+		OutboxFile:        "./outbox.ndjson",
+		IdempotencyTTL:    24 * time.Hour,
 	}
 
 	// Override with environment variables if present
@@ -37,6 +72,10 @@ func New() *Config {
 		cfg.Port = port
 	}
 
+	if driver := os.Getenv("LIVEOPS_DB_DRIVER"); driver != "" {
+		cfg.DBDriver = driver
+	}
+
 	if dbPath := os.Getenv("LIVEOPS_DB_PATH"); dbPath != "" {
 		cfg.DBPath = dbPath
 	}
@@ -49,20 +88,64 @@ func New() *Config {
 		cfg.APIKeyExpireDays = days
 	}
 
-	if rate, err := strconv.Atoi(os.Getenv("LIVEOPS_RATE_LIMIT")); err == nil && rate > 0 {
+	if rate, err := strconv.Atoi(os.Getenv("LIVEOPS_RATE_LIMIT")); err == nil && rate >= 0 {
 		cfg.RateLimitPerMin = rate
 	}
 
+	if allow, err := strconv.ParseBool(os.Getenv("LIVEOPS_GRPC_REQUEST_API_KEY")); err == nil {
+		cfg.GRPCRequestAPIKey = allow
+	}
+
+	cfg.DBDSN = os.Getenv("LIVEOPS_DB_DSN")
+	cfg.APIKeyPepper = os.Getenv("LIVEOPS_API_KEY_PEPPER")
+	cfg.PlayerTokenSecret = os.Getenv("LIVEOPS_PLAYER_TOKEN_SECRET")
+
+	if ttl, err := time.ParseDuration(os.Getenv("LIVEOPS_PLAYER_TOKEN_MAX_TTL")); err == nil && ttl > 0 {
+		cfg.PlayerTokenMaxTTL = ttl
+	}
+
+	if sinks, ok := os.LookupEnv("LIVEOPS_OUTBOX_SINKS"); ok {
+		cfg.OutboxSinks = splitList(sinks)
+	}
+
+	if outboxFile := os.Getenv("LIVEOPS_OUTBOX_FILE"); outboxFile != "" {
+		cfg.OutboxFile = outboxFile
+	}
+
+	if ttl, err := time.ParseDuration(os.Getenv("LIVEOPS_IDEMPOTENCY_TTL")); err == nil && ttl > 0 {
+		cfg.IdempotencyTTL = ttl
+	}
+
 	return cfg
 }
 
 // ParseFlags parses command line flags and updates the configuration
 func (c *Config) ParseFlags() {
 	flag.IntVar(&c.Port, "port", c.Port, "Server port")
+	flag.StringVar(&c.DBDriver, "store", c.DBDriver, "Storage backend (sqlite, postgres, memory)")
 	flag.StringVar(&c.DBPath, "db", c.DBPath, "SQLite database path")
 	flag.StringVar(&c.LogLevel, "log-level", c.LogLevel, "Log level (debug, info, warn, error)")
 	flag.IntVar(&c.APIKeyExpireDays, "api-key-expire", c.APIKeyExpireDays, "API key expiration in days")
-	flag.IntVar(&c.RateLimitPerMin, "rate-limit", c.RateLimitPerMin, "Rate limit per minute")
+	flag.IntVar(&c.RateLimitPerMin, "rate-limit", c.RateLimitPerMin, "Rate limit per API key or client IP per minute (0 disables)")
+	flag.BoolVar(&c.GRPCRequestAPIKey, "grpc-request-api-key", c.GRPCRequestAPIKey, "Accept the api_key field of gRPC requests")
+	flag.DurationVar(&c.PlayerTokenMaxTTL, "player-token-max-ttl", c.PlayerTokenMaxTTL, "Maximum lifetime of player tokens")
+	flag.Func("outbox-sinks", "Comma-separated outbox sinks (log, webhook, file)", func(value string) error {
+		c.OutboxSinks = splitList(value)
+		return nil
+	})
+	flag.StringVar(&c.OutboxFile, "outbox-file", c.OutboxFile, "File the file outbox sink appends changes to")
+	flag.DurationVar(&c.IdempotencyTTL, "idempotency-ttl", c.IdempotencyTTL, "How long responses to requests with an idempotency key are replayed")
 
 	flag.Parse()
 }
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
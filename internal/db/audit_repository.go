@@ -0,0 +1,170 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// AuditRepository handles database operations for the audit log
+type AuditRepository struct {
+	db querier
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create appends an entry to the audit log
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	result, err := r.db.Exec(`
+		INSERT INTO audit_log (id, actor_user_id, actor_api_key_id, action, target_type, target_id, before_json, after_json, request_id, transport, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ID.String(), nullableUUID(entry.ActorUserID), nullableUUID(entry.ActorAPIKeyID),
+		entry.Action, entry.TargetType, entry.TargetID, nullableJSON(entry.Before), nullableJSON(entry.After),
+		entry.RequestID, entry.Transport, formatTimestamp(entry.CreatedAt))
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	entry.Seq, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit entry sequence: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves audit entries matching the filter, newest first. Only
+// entries older than beforeSeq are returned when it is positive.
+func (r *AuditRepository) List(filter models.AuditFilter, beforeSeq int64, limit int) ([]*models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ActorUserID != nil {
+		conditions = append(conditions, "actor_user_id = ?")
+		args = append(args, filter.ActorUserID.String())
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, formatTimestamp(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, formatTimestamp(filter.Until))
+	}
+	if beforeSeq > 0 {
+		conditions = append(conditions, "seq < ?")
+		args = append(args, beforeSeq)
+	}
+
+	query := `
+		SELECT seq, id, actor_user_id, actor_api_key_id, action, target_type, target_id, before_json, after_json, request_id, transport, created_at
+		FROM audit_log
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var idStr, createdAt string
+		var actorUserID, actorAPIKeyID, before, after sql.NullString
+
+		if err := rows.Scan(&entry.Seq, &idStr, &actorUserID, &actorAPIKeyID, &entry.Action, &entry.TargetType, &entry.TargetID,
+			&before, &after, &entry.RequestID, &entry.Transport, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit row: %w", err)
+		}
+
+		// Parse UUIDs
+		entry.ID, err = uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit entry ID in database: %w", err)
+		}
+
+		if entry.ActorUserID, err = parseNullableUUID(actorUserID); err != nil {
+			return nil, fmt.Errorf("invalid actor user ID in database: %w", err)
+		}
+
+		if entry.ActorAPIKeyID, err = parseNullableUUID(actorAPIKeyID); err != nil {
+			return nil, fmt.Errorf("invalid actor API key ID in database: %w", err)
+		}
+
+		// Copy snapshots
+		if before.Valid {
+			entry.Before = []byte(before.String)
+		}
+		if after.Valid {
+			entry.After = []byte(after.String)
+		}
+
+		// Parse timestamp
+		entry.CreatedAt, err = parseTimestamp(createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullableUUID converts an optional UUID to a column value
+func nullableUUID(id *uuid.UUID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+// parseNullableUUID converts a nullable column value to an optional UUID
+func parseNullableUUID(value sql.NullString) (*uuid.UUID, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// nullableJSON converts an optional JSON document to a column value
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -2,49 +2,68 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/tombombadilom/liveops/internal/clock"
 	"github.com/tombombadilom/liveops/internal/models"
 )
 
+// eventColumns lists the columns read by scanEvent, in order
+const eventColumns = "id, external_id, title, description, start_time, end_time, rewards, structured_rewards, recurrence, targeting, status, version"
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // EventRepository handles database operations for events
 type EventRepository struct {
-	db *DB
+	db    querier
+	clock clock.Clock
 }
 
-// NewEventRepository creates a new event repository
-func NewEventRepository(db *DB) *EventRepository {
-	return &EventRepository{db: db}
+// NewEventRepository creates a new event repository stamping modifications
+// with the time of clk
+func NewEventRepository(db *DB, clk clock.Clock) *EventRepository {
+	return &EventRepository{db: db, clock: clk}
 }
 
 // Create adds a new event to the database
 func (r *EventRepository) Create(event *models.LiveEvent) error {
-	_, err := r.db.Exec(`
-		INSERT INTO events (id, title, description, start_time, end_time, rewards, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
-	`, event.ID.String(), event.Title, event.Description, event.StartTime, event.EndTime, event.Rewards)
+	structuredRewards, err := encodeRewards(event.StructuredRewards)
+	if err != nil {
+		return err
+	}
+
+	now := formatTimestamp(r.clock.Now())
+	_, err = r.db.Exec(`
+		INSERT INTO events (id, external_id, title, description, start_time, end_time, rewards, structured_rewards, recurrence, targeting, status, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?)
+	`, event.ID.String(), event.ExternalID, event.Title, event.Description, formatTimestamp(event.StartTime), formatTimestamp(event.EndTime),
+		event.Rewards, structuredRewards, event.Recurrence, event.Targeting, string(event.Status), now, now)
 
 	if err != nil {
+		if isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return models.ErrExternalIDExists
+		}
 		return fmt.Errorf("failed to create event: %w", err)
 	}
 
+	event.Version = 1
 	return nil
 }
 
 // GetByID retrieves an event by its ID
 func (r *EventRepository) GetByID(id uuid.UUID) (*models.LiveEvent, error) {
-	var event models.LiveEvent
-	var idStr string
-	var startTime, endTime string
-
-	err := r.db.QueryRow(`
-		SELECT id, title, description, start_time, end_time, rewards
+	row := r.db.QueryRow(`
+		SELECT `+eventColumns+`
 		FROM events
 		WHERE id = ?
-	`, id.String()).Scan(&idStr, &event.Title, &event.Description, &startTime, &endTime, &event.Rewards)
+	`, id.String())
 
+	event, err := scanEvent(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrEventNotFound
@@ -52,124 +71,260 @@ func (r *EventRepository) GetByID(id uuid.UUID) (*models.LiveEvent, error) {
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
 
-	// Parse UUID
-	event.ID, err = uuid.Parse(idStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid event ID in database: %w", err)
-	}
+	return event, nil
+}
 
-	// Parse timestamps
-	event.StartTime, err = time.Parse(time.RFC3339, startTime)
-	if err != nil {
-		return nil, fmt.Errorf("invalid start time in database: %w", err)
+// GetByExternalID retrieves the event with a non-empty external ID
+func (r *EventRepository) GetByExternalID(externalID string) (*models.LiveEvent, error) {
+	if externalID == "" {
+		return nil, models.ErrEventNotFound
 	}
 
-	event.EndTime, err = time.Parse(time.RFC3339, endTime)
+	row := r.db.QueryRow(`
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE external_id = ?
+	`, externalID)
+
+	event, err := scanEvent(row)
 	if err != nil {
-		return nil, fmt.Errorf("invalid end time in database: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, models.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
 
-	return &event, nil
+	return event, nil
 }
 
-// Update updates an existing event
+// Update updates an existing event if it is still at the version of event,
+// and increments its version. The status is left untouched; use UpdateStatus
+// to move an event through its lifecycle.
 func (r *EventRepository) Update(event *models.LiveEvent) error {
+	structuredRewards, err := encodeRewards(event.StructuredRewards)
+	if err != nil {
+		return err
+	}
+
 	result, err := r.db.Exec(`
 		UPDATE events
-		SET title = ?, description = ?, start_time = ?, end_time = ?, rewards = ?, updated_at = datetime('now')
-		WHERE id = ?
-	`, event.Title, event.Description, event.StartTime, event.EndTime, event.Rewards, event.ID.String())
+		SET external_id = ?, title = ?, description = ?, start_time = ?, end_time = ?, rewards = ?, structured_rewards = ?, recurrence = ?, targeting = ?,
+			version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?
+	`, event.ExternalID, event.Title, event.Description, formatTimestamp(event.StartTime), formatTimestamp(event.EndTime),
+		event.Rewards, structuredRewards, event.Recurrence, event.Targeting, formatTimestamp(r.clock.Now()), event.ID.String(), event.Version)
 
 	if err != nil {
+		if isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return models.ErrExternalIDExists
+		}
 		return fmt.Errorf("failed to update event: %w", err)
 	}
 
+	if err := r.checkVersioned(result, event.ID); err != nil {
+		return err
+	}
+
+	event.Version++
+	return nil
+}
+
+// checkVersioned tells why a write conditioned on the version of an event
+// affected no rows: either the event is gone or its version has changed
+func (r *EventRepository) checkVersioned(result sql.Result, id uuid.UUID) error {
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	if rowsAffected == 0 {
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists int
+	err = r.db.QueryRow("SELECT COUNT(*) FROM events WHERE id = ?", id.String()).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check event: %w", err)
+	}
+	if exists == 0 {
 		return models.ErrEventNotFound
 	}
 
-	return nil
+	return models.ErrVersionConflict
 }
 
-// Delete removes an event by its ID
-func (r *EventRepository) Delete(id uuid.UUID) error {
-	result, err := r.db.Exec("DELETE FROM events WHERE id = ?", id.String())
+// UpdateStatus moves an event from one status to another and returns its new
+// version. The update only applies if the event is still in the expected
+// status, so concurrent transitions cannot overwrite each other.
+func (r *EventRepository) UpdateStatus(id uuid.UUID, from, to models.EventStatus) (int64, error) {
+	var version int64
+	err := r.db.QueryRow(`
+		UPDATE events
+		SET status = ?, version = version + 1, updated_at = ?
+		WHERE id = ? AND status = ?
+		RETURNING version
+	`, string(to), formatTimestamp(r.clock.Now()), id.String(), string(from)).Scan(&version)
+
 	if err != nil {
-		return fmt.Errorf("failed to delete event: %w", err)
+		if err == sql.ErrNoRows {
+			return 0, models.ErrInvalidTransition
+		}
+		return 0, fmt.Errorf("failed to update event status: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return version, nil
+}
+
+// Delete removes an event by its ID if it is still at the given version
+func (r *EventRepository) Delete(id uuid.UUID, version int64) error {
+	result, err := r.db.Exec("DELETE FROM events WHERE id = ? AND version = ?", id.String(), version)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to delete event: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return models.ErrEventNotFound
+	return r.checkVersioned(result, id)
+}
+
+// List retrieves all events ordered by start time
+func (r *EventRepository) List() ([]*models.LiveEvent, error) {
+	return r.queryEvents(`
+		SELECT ` + eventColumns + `
+		FROM events
+		ORDER BY start_time
+	`)
+}
+
+// ListByStatus retrieves all events in any of the given statuses
+func (r *EventRepository) ListByStatus(statuses ...models.EventStatus) ([]*models.LiveEvent, error) {
+	if len(statuses) == 0 {
+		return nil, nil
 	}
 
-	return nil
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args[i] = string(status)
+	}
+
+	return r.queryEvents(`
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE status IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY start_time
+	`, args...)
 }
 
-// List retrieves all events, optionally filtered by active status
-func (r *EventRepository) List(activeOnly bool) ([]*models.LiveEvent, error) {
-	var query string
+// ListPage retrieves up to limit events matching the filter in the given
+// order, starting after the cursor when it is set. It also returns the
+// cursor of each event, to continue from on a later call.
+//
+// Time filters are applied in SQL to the stored start and end times only, so
+// recurring events are returned as candidates whenever they could match and
+// must still be checked with EventFilter.Matches, as must player targeting.
+func (r *EventRepository) ListPage(filter models.EventFilter, sort models.EventSort, after *models.EventCursor, limit int) ([]*models.LiveEvent, []models.EventCursor, error) {
+	if !sort.IsValid() {
+		return nil, nil, fmt.Errorf("invalid sort order %q", sort)
+	}
+
+	var conditions []string
 	var args []interface{}
 
-	if activeOnly {
-		query = `
-			SELECT id, title, description, start_time, end_time, rewards
-			FROM events
-			WHERE datetime('now') BETWEEN start_time AND end_time
-			ORDER BY start_time
-		`
-	} else {
-		query = `
-			SELECT id, title, description, start_time, end_time, rewards
-			FROM events
-			ORDER BY start_time
-		`
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if !filter.StartsAfter.IsZero() {
+		conditions = append(conditions, "start_time >= ?")
+		args = append(args, formatTimestamp(filter.StartsAfter))
+	}
+	if !filter.EndsBefore.IsZero() {
+		conditions = append(conditions, "(recurrence != '' OR end_time <= ?)")
+		args = append(args, formatTimestamp(filter.EndsBefore))
+	}
+	if !filter.ActiveAt.IsZero() {
+		conditions = append(conditions, "start_time <= ? AND (recurrence != '' OR end_time >= ?)")
+		args = append(args, formatTimestamp(filter.ActiveAt), formatTimestamp(filter.ActiveAt))
+	}
+	if filter.Title != "" {
+		// LIKE only folds the case of ASCII letters, see models.FoldTitle
+		conditions = append(conditions, `title LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(filter.Title)+"%")
+	}
+
+	// Keyset pagination on the sort column, with the ID breaking ties. Times
+	// are stored as fixed-width text, so comparing and ordering the bare
+	// columns matches their chronological order, and comparing them as a row
+	// value lets start time pages be read from idx_events_time.
+	column := sort.Field()
+	direction, comparison := "ASC", ">"
+	if sort.Descending() {
+		direction, comparison = "DESC", "<"
+	}
+	if after != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison))
+		args = append(args, after.Value, after.ID)
 	}
 
+	// The raw sort value is selected as text, rather than parsed by the driver,
+	// so that cursors compare exactly against what is stored
+	query := `SELECT ` + eventColumns + `, CAST(` + column + ` AS TEXT) FROM events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", column, direction, direction)
+	args = append(args, limit)
+
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query events: %w", err)
+		return nil, nil, fmt.Errorf("failed to query events: %w", err)
 	}
 	defer rows.Close()
 
 	var events []*models.LiveEvent
+	var cursors []models.EventCursor
 
 	for rows.Next() {
-		var event models.LiveEvent
-		var idStr string
-		var startTime, endTime string
-
-		if err := rows.Scan(&idStr, &event.Title, &event.Description, &startTime, &endTime, &event.Rewards); err != nil {
-			return nil, fmt.Errorf("failed to scan event row: %w", err)
-		}
-
-		// Parse UUID
-		event.ID, err = uuid.Parse(idStr)
+		var sortValue string
+		event, err := scanEvent(rowScannerFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &sortValue)...)
+		}))
 		if err != nil {
-			return nil, fmt.Errorf("invalid event ID in database: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan event row: %w", err)
 		}
 
-		// Parse timestamps
-		event.StartTime, err = time.Parse(time.RFC3339, startTime)
-		if err != nil {
-			return nil, fmt.Errorf("invalid start time in database: %w", err)
-		}
+		events = append(events, event)
+		cursors = append(cursors, models.EventCursor{Value: sortValue, ID: event.ID.String()})
+	}
 
-		event.EndTime, err = time.Parse(time.RFC3339, endTime)
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating event rows: %w", err)
+	}
+
+	return events, cursors, nil
+}
+
+// queryEvents runs a query selecting eventColumns and scans every row
+func (r *EventRepository) queryEvents(query string, args ...interface{}) ([]*models.LiveEvent, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.LiveEvent
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
 		if err != nil {
-			return nil, fmt.Errorf("invalid end time in database: %w", err)
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
 		}
 
-		events = append(events, &event)
+		events = append(events, event)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -178,3 +333,71 @@ func (r *EventRepository) List(activeOnly bool) ([]*models.LiveEvent, error) {
 
 	return events, nil
 }
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// rowScannerFunc adapts a function to the rowScanner interface
+type rowScannerFunc func(dest ...interface{}) error
+
+// Scan calls f(dest...)
+func (f rowScannerFunc) Scan(dest ...interface{}) error {
+	return f(dest...)
+}
+
+// scanEvent reads a row selected with eventColumns into an event
+func scanEvent(row rowScanner) (*models.LiveEvent, error) {
+	var event models.LiveEvent
+	var idStr, status, structuredRewards string
+	var startTime, endTime string
+
+	if err := row.Scan(&idStr, &event.ExternalID, &event.Title, &event.Description, &startTime, &endTime, &event.Rewards, &structuredRewards, &event.Recurrence, &event.Targeting, &status, &event.Version); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	// Parse UUID
+	event.ID, err = uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event ID in database: %w", err)
+	}
+
+	// Parse status and rewards
+	event.Status = models.EventStatus(status)
+
+	if structuredRewards != "" {
+		if err := json.Unmarshal([]byte(structuredRewards), &event.StructuredRewards); err != nil {
+			return nil, fmt.Errorf("invalid structured rewards in database: %w", err)
+		}
+	}
+
+	// Parse timestamps
+	event.StartTime, err = parseTimestamp(startTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time in database: %w", err)
+	}
+
+	event.EndTime, err = parseTimestamp(endTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end time in database: %w", err)
+	}
+
+	return &event, nil
+}
+
+// encodeRewards converts structured rewards to a column value
+func encodeRewards(rewards []models.Reward) (string, error) {
+	if len(rewards) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(rewards)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode rewards: %w", err)
+	}
+
+	return string(data), nil
+}
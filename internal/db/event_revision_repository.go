@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// eventRevisionColumns lists the columns read by scanEventRevision, in order
+const eventRevisionColumns = "event_id, revision, action, snapshot, author_user_id, author_api_key_id, reverted_from, created_at"
+
+// EventRevisionRepository handles database operations for event revisions
+type EventRevisionRepository struct {
+	db querier
+}
+
+// NewEventRevisionRepository creates a new event revision repository
+func NewEventRevisionRepository(db *DB) *EventRevisionRepository {
+	return &EventRevisionRepository{db: db}
+}
+
+// Create appends a revision, numbering it after the latest revision of its
+// event in the same statement
+func (r *EventRevisionRepository) Create(revision *models.EventRevision) error {
+	snapshot, err := json.Marshal(revision.Event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event snapshot: %w", err)
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO event_revisions (event_id, revision, action, snapshot, author_user_id, author_api_key_id, reverted_from, created_at)
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?, ?, ?
+		FROM event_revisions
+		WHERE event_id = ?
+		RETURNING revision
+	`, revision.EventID.String(), revision.Action, string(snapshot), nullableUUID(revision.AuthorUserID), nullableUUID(revision.AuthorAPIKeyID),
+		revision.RevertedFrom, formatTimestamp(revision.CreatedAt), revision.EventID.String()).Scan(&revision.Revision)
+
+	if err != nil {
+		return fmt.Errorf("failed to create event revision: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a revision of an event
+func (r *EventRevisionRepository) Get(eventID uuid.UUID, revision int) (*models.EventRevision, error) {
+	row := r.db.QueryRow(`
+		SELECT `+eventRevisionColumns+`
+		FROM event_revisions
+		WHERE event_id = ? AND revision = ?
+	`, eventID.String(), revision)
+
+	result, err := scanEventRevision(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to get event revision: %w", err)
+	}
+
+	return result, nil
+}
+
+// List retrieves revisions of an event, newest first. Only revisions older
+// than before are returned when it is positive.
+func (r *EventRevisionRepository) List(eventID uuid.UUID, before, limit int) ([]*models.EventRevision, error) {
+	query := `
+		SELECT ` + eventRevisionColumns + `
+		FROM event_revisions
+		WHERE event_id = ?
+	`
+	args := []interface{}{eventID.String()}
+	if before > 0 {
+		query += " AND revision < ?"
+		args = append(args, before)
+	}
+	query += " ORDER BY revision DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.EventRevision
+
+	for rows.Next() {
+		revision, err := scanEventRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event revision row: %w", err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// scanEventRevision reads a row selected with eventRevisionColumns into a
+// revision
+func scanEventRevision(row rowScanner) (*models.EventRevision, error) {
+	var revision models.EventRevision
+	var eventID, snapshot, createdAt string
+	var authorUserID, authorAPIKeyID sql.NullString
+
+	if err := row.Scan(&eventID, &revision.Revision, &revision.Action, &snapshot, &authorUserID, &authorAPIKeyID,
+		&revision.RevertedFrom, &createdAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	// Parse UUIDs
+	revision.EventID, err = uuid.Parse(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event ID in database: %w", err)
+	}
+
+	if revision.AuthorUserID, err = parseNullableUUID(authorUserID); err != nil {
+		return nil, fmt.Errorf("invalid author user ID in database: %w", err)
+	}
+
+	if revision.AuthorAPIKeyID, err = parseNullableUUID(authorAPIKeyID); err != nil {
+		return nil, fmt.Errorf("invalid author API key ID in database: %w", err)
+	}
+
+	// Decode snapshot
+	if err := json.Unmarshal([]byte(snapshot), &revision.Event); err != nil {
+		return nil, fmt.Errorf("invalid event snapshot in database: %w", err)
+	}
+
+	// Parse timestamp
+	revision.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// idempotencyColumns are the columns scanIdempotencyRecord reads, in order
+const idempotencyColumns = "api_key_id, key, fingerprint, completed, status_code, header, body, created_at, expires_at"
+
+// IdempotencyRepository handles database operations for idempotency records
+type IdempotencyRepository struct {
+	db querier
+}
+
+// Create adds a record unless its key is taken
+func (r *IdempotencyRepository) Create(record *models.IdempotencyRecord) error {
+	header, err := encodeIdempotencyHeader(record.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO idempotency_keys (`+idempotencyColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, record.APIKeyID.String(), record.Key, record.Fingerprint, record.Completed, record.StatusCode, header,
+		idempotencyBody(record.Body), formatTimestamp(record.CreatedAt), formatTimestamp(record.ExpiresAt))
+
+	if err != nil {
+		if isConstraintError(err, sqlite3.ErrConstraintPrimaryKey) || isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return models.ErrIdempotencyExists
+		}
+		return fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the record of a key of an API key
+func (r *IdempotencyRepository) Get(apiKeyID uuid.UUID, key string) (*models.IdempotencyRecord, error) {
+	row := r.db.QueryRow(`
+		SELECT `+idempotencyColumns+`
+		FROM idempotency_keys
+		WHERE api_key_id = ? AND key = ?
+	`, apiKeyID.String(), key)
+
+	record, err := scanIdempotencyRecord(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrIdempotencyMissing
+		}
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	return record, nil
+}
+
+// Complete saves the response of a record
+func (r *IdempotencyRepository) Complete(record *models.IdempotencyRecord) error {
+	header, err := encodeIdempotencyHeader(record.Header)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE idempotency_keys
+		SET completed = 1, status_code = ?, header = ?, body = ?, expires_at = ?
+		WHERE api_key_id = ? AND key = ?
+	`, record.StatusCode, header, idempotencyBody(record.Body), formatTimestamp(record.ExpiresAt), record.APIKeyID.String(), record.Key)
+
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrIdempotencyMissing
+	}
+
+	return nil
+}
+
+// Delete removes the record of a key of an API key
+func (r *IdempotencyRepository) Delete(apiKeyID uuid.UUID, key string) error {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE api_key_id = ? AND key = ?", apiKeyID.String(), key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrIdempotencyMissing
+	}
+
+	return nil
+}
+
+// PurgeExpired removes the records that expired before the given time
+func (r *IdempotencyRepository) PurgeExpired(before time.Time) (int, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE expires_at < ?", formatTimestamp(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency records: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// encodeIdempotencyHeader encodes the response headers of a record as JSON
+func encodeIdempotencyHeader(header map[string]string) (string, error) {
+	if header == nil {
+		header = map[string]string{}
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode idempotency header: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// idempotencyBody returns the response body of a record, never nil so that
+// it is not stored as NULL
+func idempotencyBody(body []byte) []byte {
+	if body == nil {
+		return []byte{}
+	}
+	return body
+}
+
+// scanIdempotencyRecord reads a record row
+func scanIdempotencyRecord(row rowScanner) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var apiKeyID, header, createdAt, expiresAt string
+
+	if err := row.Scan(&apiKeyID, &record.Key, &record.Fingerprint, &record.Completed, &record.StatusCode,
+		&header, &record.Body, &createdAt, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	record.APIKeyID, err = uuid.Parse(apiKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid idempotency API key ID in database: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(header), &record.Header); err != nil {
+		return nil, fmt.Errorf("invalid idempotency header in database: %w", err)
+	}
+
+	// Parse timestamps
+	record.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	}
+
+	record.ExpiresAt, err = parseTimestamp(expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires_at time in database: %w", err)
+	}
+
+	return &record, nil
+}
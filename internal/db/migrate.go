@@ -0,0 +1,430 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFilePattern matches names such as 0002_add_event_recurrence.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrChecksumMismatch is returned when an applied migration was modified
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// Migration is a numbered schema change with its up and down SQL
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum returns the SHA-256 of the up SQL, used to detect edited migrations
+func (m *Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Dialect adapts the migrator to a database engine
+type Dialect struct {
+	// Rebind converts a query written with ? placeholders to the placeholder
+	// syntax of the engine
+	Rebind func(query string) string
+	// TableExists reports whether a table is present in the database
+	TableExists func(conn *sql.DB, table string) (bool, error)
+	// LegacyVersion infers the schema version of a database created before
+	// migrations were tracked. It may be nil if there are no such databases.
+	LegacyVersion func(conn *sql.DB) (int, error)
+}
+
+// sqliteDialect is the dialect of the SQLite database
+var sqliteDialect = Dialect{
+	Rebind: func(query string) string { return query },
+	TableExists: func(conn *sql.DB, table string) (bool, error) {
+		return (&DB{conn}).tableExists(table)
+	},
+	LegacyVersion: func(conn *sql.DB) (int, error) {
+		return detectLegacyVersion(&DB{conn})
+	},
+}
+
+// Migrator applies and rolls back a set of migrations
+type Migrator struct {
+	conn       *sql.DB
+	dialect    Dialect
+	migrations []*Migration
+}
+
+// NewMigrator creates a migrator for the embedded SQLite migration set
+func NewMigrator(db *DB) (*Migrator, error) {
+	return NewDialectMigrator(db.DB, sqliteDialect, migrationFiles, "migrations")
+}
+
+// NewDialectMigrator creates a migrator applying the migrations found in a
+// directory of fsys to a database of another engine
+func NewDialectMigrator(conn *sql.DB, dialect Dialect, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{conn: conn, dialect: dialect, migrations: migrations}, nil
+}
+
+// loadMigrations reads and pairs up/down files from a directory
+func loadMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous from 1, found %d at position %d", m.Version, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// LatestVersion returns the highest available migration version
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the currently applied schema version
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := m.conn.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	return m.MigrateTo(m.LatestVersion())
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down() error {
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+
+	return m.MigrateTo(current - 1)
+}
+
+// MigrateTo applies or rolls back migrations until the schema is at target
+func (m *Migrator) MigrateTo(target int) error {
+	if target < 0 || target > m.LatestVersion() {
+		return fmt.Errorf("unknown migration version %d (latest is %d)", target, m.LatestVersion())
+	}
+
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+
+	if err := m.Verify(); err != nil {
+		return err
+	}
+
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	for current < target {
+		migration := m.migrations[current]
+		if err := m.apply(migration, true); err != nil {
+			return err
+		}
+		current++
+	}
+
+	for current > target {
+		migration := m.migrations[current-1]
+		if err := m.apply(migration, false); err != nil {
+			return err
+		}
+		current--
+	}
+
+	return nil
+}
+
+// Verify checks that applied migrations still match the embedded files
+func (m *Migrator) Verify() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for version, row := range applied {
+		if version > m.LatestVersion() {
+			return fmt.Errorf("database is at migration %d, newer than this binary supports (%d)", version, m.LatestVersion())
+		}
+		if migration := m.migrations[version-1]; row.checksum != migration.Checksum() {
+			return fmt.Errorf("%w: %d (%s)", ErrChecksumMismatch, version, migration.Name)
+		}
+	}
+
+	return nil
+}
+
+// Status reports the state of every available migration
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if row, ok := applied[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = row.appliedAt
+			statuses[i].Modified = row.checksum != migration.Checksum()
+		}
+	}
+
+	return statuses, nil
+}
+
+// apply runs a single migration in its own transaction
+func (m *Migrator) apply(migration *Migration, up bool) error {
+	direction, script := "down", migration.Down
+	if up {
+		direction, script = "up", migration.Up
+	}
+
+	log.Info().
+		Int("version", migration.Version).
+		Str("name", migration.Name).
+		Str("direction", direction).
+		Msg("Applying migration")
+
+	tx, err := m.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("failed to run migration %d (%s) %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.Exec(m.dialect.Rebind(`
+			INSERT INTO schema_migrations (version, name, checksum, applied_at)
+			VALUES (?, ?, ?, ?)
+		`), migration.Version, migration.Name, migration.Checksum(), time.Now().UTC())
+	} else {
+		_, err = tx.Exec(m.dialect.Rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+
+	return nil
+}
+
+// applied returns the rows of schema_migrations keyed by version
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.conn.Query("SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema migration row: %w", err)
+		}
+		applied[version] = row
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema migration rows: %w", err)
+	}
+
+	return applied, nil
+}
+
+// ensureTable creates schema_migrations, stamping databases created before
+// migrations existed with the version matching their current schema
+func (m *Migrator) ensureTable() error {
+	exists, err := m.dialect.TableExists(m.conn, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	legacyVersion := 0
+	if m.dialect.LegacyVersion != nil {
+		legacyVersion, err = m.dialect.LegacyVersion(m.conn)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := m.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin schema_migrations setup: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	for _, migration := range m.migrations[:legacyVersion] {
+		_, err := tx.Exec(m.dialect.Rebind(`
+			INSERT INTO schema_migrations (version, name, checksum, applied_at)
+			VALUES (?, ?, ?, ?)
+		`), migration.Version, migration.Name, migration.Checksum(), time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("failed to record legacy migration %d: %w", migration.Version, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schema_migrations setup: %w", err)
+	}
+
+	if legacyVersion > 0 {
+		log.Info().Int("version", legacyVersion).Msg("Adopted existing database schema")
+	}
+
+	return nil
+}
+
+// detectLegacyVersion infers the schema version of a SQLite database created
+// by the schema bootstrap that predates versioned migrations
+func detectLegacyVersion(db *DB) (int, error) {
+	exists, err := db.tableExists("events")
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	version := 1
+	for _, column := range []string{"recurrence", "status"} {
+		has, err := db.columnExists("events", column)
+		if err != nil {
+			return 0, err
+		}
+		if !has {
+			break
+		}
+		version++
+	}
+
+	return version, nil
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_events_time;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,4 @@
+-- Events created before lifecycle states existed were immediately visible
+ALTER TABLE events ADD COLUMN status TEXT NOT NULL DEFAULT 'scheduled';
+
+CREATE INDEX idx_events_status ON events(status);
@@ -0,0 +1,18 @@
+-- Hashed keys cannot be recovered: they are kept as their hash and stop
+-- working. Keys that were never hashed keep their plaintext value.
+CREATE TABLE api_keys_old (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	key TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	last_used TIMESTAMP NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO api_keys_old (id, user_id, key, created_at, expires_at, last_used)
+SELECT id, user_id, COALESCE(legacy_key, key_hash), created_at, expires_at, last_used FROM api_keys;
+
+DROP TABLE api_keys;
+
+ALTER TABLE api_keys_old RENAME TO api_keys;
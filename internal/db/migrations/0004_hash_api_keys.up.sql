@@ -0,0 +1,23 @@
+-- API keys are stored as HMAC-SHA256 hashes. Existing plaintext keys are kept
+-- in legacy_key until the server hashes them with its pepper on startup.
+CREATE TABLE api_keys_new (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	prefix TEXT NOT NULL DEFAULT '',
+	key_hash TEXT UNIQUE,
+	legacy_key TEXT,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	last_used TIMESTAMP NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO api_keys_new (id, user_id, legacy_key, created_at, expires_at, last_used)
+SELECT id, user_id, key, created_at, expires_at, last_used FROM api_keys;
+
+DROP TABLE api_keys;
+
+ALTER TABLE api_keys_new RENAME TO api_keys;
+
+CREATE INDEX idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
@@ -0,0 +1,2 @@
+-- Comma-separated scopes; an empty list leaves the owner's role unrestricted
+ALTER TABLE api_keys ADD COLUMN scopes TEXT NOT NULL DEFAULT '';
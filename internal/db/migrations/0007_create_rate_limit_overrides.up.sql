@@ -0,0 +1,7 @@
+CREATE TABLE rate_limit_overrides (
+	subject_type TEXT NOT NULL CHECK (subject_type IN ('role', 'api_key')),
+	subject TEXT NOT NULL,
+	requests_per_min INTEGER NOT NULL CHECK (requests_per_min > 0),
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (subject_type, subject)
+);
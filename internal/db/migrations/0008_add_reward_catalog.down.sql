@@ -0,0 +1,3 @@
+ALTER TABLE events DROP COLUMN structured_rewards;
+
+DROP TABLE reward_catalog;
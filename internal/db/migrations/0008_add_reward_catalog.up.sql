@@ -0,0 +1,9 @@
+CREATE TABLE reward_catalog (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL CHECK (type IN ('item', 'currency')),
+	name TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL
+);
+
+-- JSON array of catalog-validated rewards; rewards keeps the raw JSON
+ALTER TABLE events ADD COLUMN structured_rewards TEXT NOT NULL DEFAULT '';
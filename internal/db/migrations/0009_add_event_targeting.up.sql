@@ -0,0 +1,2 @@
+-- Player targeting expression; empty targets every player
+ALTER TABLE events ADD COLUMN targeting TEXT NOT NULL DEFAULT '';
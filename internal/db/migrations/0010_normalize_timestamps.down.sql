@@ -0,0 +1,3 @@
+-- Normalized times are still read correctly by earlier versions, so they are
+-- kept as they are
+SELECT 1;
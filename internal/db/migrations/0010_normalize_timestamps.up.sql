@@ -0,0 +1,54 @@
+-- Rewrite the times of events, users and API keys in the fixed-width UTC
+-- format of the other tables (2006-01-02T15:04:05.000000000Z). They were
+-- stored as formatted by the driver, with the offset of the original time,
+-- or by datetime('now'), so they could not be compared as text.
+CREATE TEMP TABLE timestamp_values (
+	raw TEXT PRIMARY KEY,
+	fraction TEXT NOT NULL DEFAULT '',
+	normalized TEXT
+);
+
+INSERT INTO timestamp_values (raw)
+SELECT start_time FROM events
+UNION SELECT end_time FROM events
+UNION SELECT created_at FROM events
+UNION SELECT updated_at FROM events
+UNION SELECT created_at FROM users
+UNION SELECT created_at FROM api_keys
+UNION SELECT expires_at FROM api_keys
+UNION SELECT last_used FROM api_keys;
+
+-- SQLite keeps milliseconds only, so the fractional seconds are carried over
+-- as text: take the digits after the dot, up to the zone offset
+UPDATE timestamp_values SET fraction = substr(raw, instr(raw, '.') + 1) WHERE instr(raw, '.') > 0;
+UPDATE timestamp_values SET fraction = substr(fraction, 1, instr(fraction, 'Z') - 1) WHERE instr(fraction, 'Z') > 0;
+UPDATE timestamp_values SET fraction = substr(fraction, 1, instr(fraction, '+') - 1) WHERE instr(fraction, '+') > 0;
+UPDATE timestamp_values SET fraction = substr(fraction, 1, instr(fraction, '-') - 1) WHERE instr(fraction, '-') > 0;
+
+-- Convert the whole seconds to UTC without the fraction, which could
+-- otherwise round up to the next second
+UPDATE timestamp_values SET normalized =
+	strftime('%Y-%m-%dT%H:%M:%S',
+		CASE WHEN instr(raw, '.') > 0
+			THEN substr(raw, 1, instr(raw, '.') - 1) || substr(raw, instr(raw, '.') + 1 + length(fraction))
+			ELSE raw
+		END)
+	|| '.' || substr(fraction || '000000000', 1, 9) || 'Z';
+
+-- Unparseable times are left as NULL and abort the migration on the NOT NULL
+-- constraints below
+UPDATE events SET
+	start_time = (SELECT normalized FROM timestamp_values WHERE raw = events.start_time),
+	end_time = (SELECT normalized FROM timestamp_values WHERE raw = events.end_time),
+	created_at = (SELECT normalized FROM timestamp_values WHERE raw = events.created_at),
+	updated_at = (SELECT normalized FROM timestamp_values WHERE raw = events.updated_at);
+
+UPDATE users SET
+	created_at = (SELECT normalized FROM timestamp_values WHERE raw = users.created_at);
+
+UPDATE api_keys SET
+	created_at = (SELECT normalized FROM timestamp_values WHERE raw = api_keys.created_at),
+	expires_at = (SELECT normalized FROM timestamp_values WHERE raw = api_keys.expires_at),
+	last_used = (SELECT normalized FROM timestamp_values WHERE raw = api_keys.last_used);
+
+DROP TABLE timestamp_values;
@@ -0,0 +1,23 @@
+CREATE TABLE event_revisions (
+	event_id TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	snapshot TEXT NOT NULL,
+	author_user_id TEXT,
+	author_api_key_id TEXT,
+	reverted_from INTEGER NOT NULL DEFAULT 0,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (event_id, revision)
+);
+
+-- Revisions are immutable and outlive their event, so that deleted events
+-- keep their history
+CREATE TRIGGER event_revisions_no_update BEFORE UPDATE ON event_revisions
+BEGIN
+	SELECT RAISE(ABORT, 'event revisions are immutable');
+END;
+
+CREATE TRIGGER event_revisions_no_delete BEFORE DELETE ON event_revisions
+BEGIN
+	SELECT RAISE(ABORT, 'event revisions are immutable');
+END;
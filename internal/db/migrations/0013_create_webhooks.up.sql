@@ -0,0 +1,38 @@
+CREATE TABLE webhook_subscriptions (
+	id TEXT PRIMARY KEY,
+	url TEXT NOT NULL,
+	-- Comma-separated change types; empty sends every change
+	event_types TEXT NOT NULL DEFAULT '',
+	secret TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	id TEXT NOT NULL UNIQUE,
+	subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+	event_type TEXT NOT NULL,
+	event_id TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'dead')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TEXT NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id);
+CREATE INDEX idx_webhook_deliveries_event ON webhook_deliveries(event_id);
+
+CREATE TABLE webhook_attempts (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	delivery_id TEXT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_webhook_attempts_delivery ON webhook_attempts(delivery_id);
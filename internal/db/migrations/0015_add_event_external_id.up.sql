@@ -0,0 +1,3 @@
+-- Caller-assigned ID that imports upsert events by; empty when unset
+ALTER TABLE events ADD COLUMN external_id TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX idx_events_external_id ON events(external_id) WHERE external_id != '';
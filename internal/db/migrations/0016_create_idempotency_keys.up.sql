@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+	api_key_id TEXT NOT NULL,
+	key TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	completed INTEGER NOT NULL DEFAULT 0,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	-- JSON object of the replayed response headers
+	header TEXT NOT NULL DEFAULT '{}',
+	body BLOB NOT NULL DEFAULT x'',
+	created_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL,
+	PRIMARY KEY (api_key_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
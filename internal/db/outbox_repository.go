@@ -0,0 +1,250 @@
+package db
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// outboxColumns lists the columns read by scanOutboxEntry, in order
+const outboxColumns = "seq, type, event_id, event, status, attempts, next_attempt_at, last_error, created_at, updated_at"
+
+// OutboxRepository handles database operations for outbox entries
+type OutboxRepository struct {
+	db querier
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Create adds an entry to the database and sets its sequence number
+func (r *OutboxRepository) Create(entry *models.OutboxEntry) error {
+	event, err := json.Marshal(entry.Event)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO outbox (type, event_id, event, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, string(entry.Type), entry.EventID.String(), string(event), string(entry.Status), entry.Attempts,
+		formatTimestamp(entry.NextAttemptAt), entry.LastError, formatTimestamp(entry.CreatedAt), formatTimestamp(entry.UpdatedAt))
+
+	if err != nil {
+		return fmt.Errorf("failed to create outbox entry: %w", err)
+	}
+
+	entry.Seq, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get outbox sequence: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves an entry by its sequence number
+func (r *OutboxRepository) Get(seq int64) (*models.OutboxEntry, error) {
+	row := r.db.QueryRow(`
+		SELECT `+outboxColumns+`
+		FROM outbox
+		WHERE seq = ?
+	`, seq)
+
+	entry, err := scanOutboxEntry(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOutboxNotFound
+		}
+		return nil, fmt.Errorf("failed to get outbox entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Update saves the progress of an entry
+func (r *OutboxRepository) Update(entry *models.OutboxEntry) error {
+	result, err := r.db.Exec(`
+		UPDATE outbox
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, updated_at = ?
+		WHERE seq = ?
+	`, string(entry.Status), entry.Attempts, formatTimestamp(entry.NextAttemptAt), entry.LastError,
+		formatTimestamp(entry.UpdatedAt), entry.Seq)
+
+	if err != nil {
+		return fmt.Errorf("failed to update outbox entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrOutboxNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue retrieves up to limit pending entries due at now, in sequence
+// order, leaving out those behind an earlier undelivered entry of their event,
+// and leases them until leaseUntil
+func (r *OutboxRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]*models.OutboxEntry, error) {
+	entries, err := r.queryEntries(`
+		UPDATE outbox
+		SET next_attempt_at = ?
+		WHERE seq IN (
+			SELECT seq
+			FROM outbox AS o
+			WHERE status = ? AND next_attempt_at <= ?
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox AS earlier
+					WHERE earlier.event_id = o.event_id AND earlier.status <> ? AND earlier.seq < o.seq
+				)
+			ORDER BY seq
+			LIMIT ?
+		)
+		RETURNING `+outboxColumns,
+		formatTimestamp(leaseUntil), string(models.OutboxPending), formatTimestamp(now), string(models.OutboxDelivered), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not follow the order of the subquery
+	slices.SortFunc(entries, func(a, b *models.OutboxEntry) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	return entries, nil
+}
+
+// List retrieves entries matching the filter, newest first. Only entries
+// older than beforeSeq are returned when it is positive.
+func (r *OutboxRepository) List(filter models.OutboxFilter, beforeSeq int64, limit int) ([]*models.OutboxEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	if filter.EventID != nil {
+		conditions = append(conditions, "event_id = ?")
+		args = append(args, filter.EventID.String())
+	}
+	if beforeSeq > 0 {
+		conditions = append(conditions, "seq < ?")
+		args = append(args, beforeSeq)
+	}
+
+	query := `SELECT ` + outboxColumns + ` FROM outbox`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC LIMIT ?"
+	args = append(args, limit)
+
+	return r.queryEntries(query, args...)
+}
+
+// ListAfter retrieves up to limit entries newer than afterSeq, oldest first
+func (r *OutboxRepository) ListAfter(afterSeq int64, limit int) ([]*models.OutboxEntry, error) {
+	return r.queryEntries(`SELECT `+outboxColumns+` FROM outbox WHERE seq > ? ORDER BY seq LIMIT ?`, afterSeq, limit)
+}
+
+// PurgeDelivered removes the delivered entries last updated before the
+// given time
+func (r *OutboxRepository) PurgeDelivered(before time.Time) (int, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM outbox
+		WHERE status = ? AND updated_at < ?
+	`, string(models.OutboxDelivered), formatTimestamp(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox entries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// queryEntries runs a query selecting outboxColumns and scans every row
+func (r *OutboxRepository) queryEntries(query string, args ...interface{}) ([]*models.OutboxEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.OutboxEntry
+
+	for rows.Next() {
+		entry, err := scanOutboxEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanOutboxEntry reads a row selected with outboxColumns into an entry
+func scanOutboxEntry(row rowScanner) (*models.OutboxEntry, error) {
+	var entry models.OutboxEntry
+	var changeType, eventID, event, status, nextAttemptAt, createdAt, updatedAt string
+
+	if err := row.Scan(&entry.Seq, &changeType, &eventID, &event, &status, &entry.Attempts,
+		&nextAttemptAt, &entry.LastError, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	entry.EventID, err = uuid.Parse(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbox event ID in database: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(event), &entry.Event); err != nil {
+		return nil, fmt.Errorf("invalid outbox event in database: %w", err)
+	}
+
+	entry.Type = models.ChangeType(changeType)
+	entry.Status = models.OutboxStatus(status)
+
+	// Parse timestamps
+	entry.NextAttemptAt, err = parseTimestamp(nextAttemptAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next_attempt_at time in database: %w", err)
+	}
+
+	entry.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	}
+
+	entry.UpdatedAt, err = parseTimestamp(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid updated_at time in database: %w", err)
+	}
+
+	return &entry, nil
+}
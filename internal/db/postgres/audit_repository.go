@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// AuditRepository handles database operations for the audit log
+type AuditRepository struct {
+	db querier
+}
+
+// Create appends an entry to the audit log
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	err := r.db.QueryRow(`
+		INSERT INTO audit_log (id, actor_user_id, actor_api_key_id, action, target_type, target_id, before_json, after_json, request_id, transport, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING seq
+	`, entry.ID.String(), nullableUUID(entry.ActorUserID), nullableUUID(entry.ActorAPIKeyID),
+		entry.Action, entry.TargetType, entry.TargetID, nullableJSON(entry.Before), nullableJSON(entry.After),
+		entry.RequestID, entry.Transport, entry.CreatedAt).Scan(&entry.Seq)
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves audit entries matching the filter, newest first. Only
+// entries older than beforeSeq are returned when it is positive.
+func (r *AuditRepository) List(filter models.AuditFilter, beforeSeq int64, limit int) ([]*models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ActorUserID != nil {
+		conditions = append(conditions, "actor_user_id = "+bind(&args, filter.ActorUserID.String()))
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = "+bind(&args, filter.TargetType))
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, "target_id = "+bind(&args, filter.TargetID))
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+bind(&args, filter.Action))
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= "+bind(&args, filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at < "+bind(&args, filter.Until))
+	}
+	if beforeSeq > 0 {
+		conditions = append(conditions, "seq < "+bind(&args, beforeSeq))
+	}
+
+	query := `
+		SELECT seq, id, actor_user_id, actor_api_key_id, action, target_type, target_id, before_json, after_json, request_id, transport, created_at
+		FROM audit_log
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC LIMIT " + bind(&args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorUserID, actorAPIKeyID uuid.NullUUID
+		var before, after sql.NullString
+
+		if err := rows.Scan(&entry.Seq, &entry.ID, &actorUserID, &actorAPIKeyID, &entry.Action, &entry.TargetType, &entry.TargetID,
+			&before, &after, &entry.RequestID, &entry.Transport, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit row: %w", err)
+		}
+
+		if actorUserID.Valid {
+			entry.ActorUserID = &actorUserID.UUID
+		}
+		if actorAPIKeyID.Valid {
+			entry.ActorAPIKeyID = &actorAPIKeyID.UUID
+		}
+
+		// Copy snapshots
+		if before.Valid {
+			entry.Before = []byte(before.String)
+		}
+		if after.Valid {
+			entry.After = []byte(after.String)
+		}
+
+		entry.CreatedAt = entry.CreatedAt.UTC()
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullableUUID converts an optional UUID to a column value
+func nullableUUID(id *uuid.UUID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+// nullableJSON converts an optional JSON document to a column value
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
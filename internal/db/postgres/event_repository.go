@@ -0,0 +1,380 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// eventColumns lists the columns read by scanEvent, in order
+const eventColumns = "id, external_id, title, description, start_time, end_time, rewards, structured_rewards, recurrence, targeting, status, version"
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EventRepository handles database operations for events, stamping
+// modifications with the time of its clock
+type EventRepository struct {
+	db    querier
+	clock clock.Clock
+}
+
+// Create adds a new event to the database
+func (r *EventRepository) Create(event *models.LiveEvent) error {
+	structuredRewards, err := encodeRewards(event.StructuredRewards)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO events (id, external_id, title, description, start_time, end_time, rewards, structured_rewards, recurrence, targeting, status, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 1, $12, $12)
+	`, event.ID.String(), event.ExternalID, event.Title, event.Description, event.StartTime, event.EndTime, event.Rewards, structuredRewards, event.Recurrence, event.Targeting, string(event.Status), r.clock.Now())
+
+	if err != nil {
+		if isViolation(err, codeUniqueViolation, "idx_events_external_id") {
+			return models.ErrExternalIDExists
+		}
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	event.Version = 1
+	return nil
+}
+
+// GetByID retrieves an event by its ID
+func (r *EventRepository) GetByID(id uuid.UUID) (*models.LiveEvent, error) {
+	row := r.db.QueryRow(`
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE id = $1
+	`, id.String())
+
+	event, err := scanEvent(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	return event, nil
+}
+
+// GetByExternalID retrieves the event with a non-empty external ID
+func (r *EventRepository) GetByExternalID(externalID string) (*models.LiveEvent, error) {
+	if externalID == "" {
+		return nil, models.ErrEventNotFound
+	}
+
+	row := r.db.QueryRow(`
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE external_id = $1
+	`, externalID)
+
+	event, err := scanEvent(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	return event, nil
+}
+
+// Update updates an existing event if it is still at the version of event,
+// and increments its version. The status is left untouched; use UpdateStatus
+// to move an event through its lifecycle.
+func (r *EventRepository) Update(event *models.LiveEvent) error {
+	structuredRewards, err := encodeRewards(event.StructuredRewards)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE events
+		SET external_id = $1, title = $2, description = $3, start_time = $4, end_time = $5, rewards = $6, structured_rewards = $7, recurrence = $8, targeting = $9,
+			version = version + 1, updated_at = $10
+		WHERE id = $11 AND version = $12
+	`, event.ExternalID, event.Title, event.Description, event.StartTime, event.EndTime, event.Rewards, structuredRewards, event.Recurrence, event.Targeting, r.clock.Now(),
+		event.ID.String(), event.Version)
+
+	if err != nil {
+		if isViolation(err, codeUniqueViolation, "idx_events_external_id") {
+			return models.ErrExternalIDExists
+		}
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+
+	if err := r.checkVersioned(result, event.ID); err != nil {
+		return err
+	}
+
+	event.Version++
+	return nil
+}
+
+// checkVersioned tells why a write conditioned on the version of an event
+// affected no rows: either the event is gone or its version has changed
+func (r *EventRepository) checkVersioned(result sql.Result, id uuid.UUID) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM events WHERE id = $1)", id.String()).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check event: %w", err)
+	}
+	if !exists {
+		return models.ErrEventNotFound
+	}
+
+	return models.ErrVersionConflict
+}
+
+// UpdateStatus moves an event from one status to another and returns its new
+// version. The update only applies if the event is still in the expected
+// status, so concurrent transitions cannot overwrite each other.
+func (r *EventRepository) UpdateStatus(id uuid.UUID, from, to models.EventStatus) (int64, error) {
+	var version int64
+	err := r.db.QueryRow(`
+		UPDATE events
+		SET status = $1, version = version + 1, updated_at = $2
+		WHERE id = $3 AND status = $4
+		RETURNING version
+	`, string(to), r.clock.Now(), id.String(), string(from)).Scan(&version)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, models.ErrInvalidTransition
+		}
+		return 0, fmt.Errorf("failed to update event status: %w", err)
+	}
+
+	return version, nil
+}
+
+// Delete removes an event by its ID if it is still at the given version
+func (r *EventRepository) Delete(id uuid.UUID, version int64) error {
+	result, err := r.db.Exec("DELETE FROM events WHERE id = $1 AND version = $2", id.String(), version)
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+
+	return r.checkVersioned(result, id)
+}
+
+// List retrieves all events ordered by start time
+func (r *EventRepository) List() ([]*models.LiveEvent, error) {
+	return r.queryEvents(`
+		SELECT ` + eventColumns + `
+		FROM events
+		ORDER BY start_time, id
+	`)
+}
+
+// ListByStatus retrieves all events in any of the given statuses
+func (r *EventRepository) ListByStatus(statuses ...models.EventStatus) ([]*models.LiveEvent, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	var args []interface{}
+	placeholders := make([]string, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = bind(&args, string(status))
+	}
+
+	return r.queryEvents(`
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE status IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY start_time, id
+	`, args...)
+}
+
+// ListPage retrieves up to limit events matching the filter in the given
+// order, starting after the cursor when it is set. It also returns the
+// cursor of each event, to continue from on a later call.
+//
+// Time filters are applied in SQL to the stored start and end times only, so
+// recurring events are returned as candidates whenever they could match and
+// must still be checked with EventFilter.Matches, as must player targeting.
+func (r *EventRepository) ListPage(filter models.EventFilter, sort models.EventSort, after *models.EventCursor, limit int) ([]*models.LiveEvent, []models.EventCursor, error) {
+	if !sort.IsValid() {
+		return nil, nil, fmt.Errorf("invalid sort order %q", sort)
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = bind(&args, string(status))
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if !filter.StartsAfter.IsZero() {
+		conditions = append(conditions, "start_time >= "+bind(&args, filter.StartsAfter))
+	}
+	if !filter.EndsBefore.IsZero() {
+		conditions = append(conditions, "(recurrence != '' OR end_time <= "+bind(&args, filter.EndsBefore)+")")
+	}
+	if !filter.ActiveAt.IsZero() {
+		activeAt := bind(&args, filter.ActiveAt)
+		conditions = append(conditions, "start_time <= "+activeAt+" AND (recurrence != '' OR end_time >= "+activeAt+")")
+	}
+	if filter.Title != "" {
+		// Lowering in the C collation only folds ASCII letters, like SQLite
+		conditions = append(conditions, `lower(title COLLATE "C") LIKE `+bind(&args, "%"+likeEscaper.Replace(models.FoldTitle(filter.Title))+"%"))
+	}
+
+	// Keyset pagination on the sort column, with the ID breaking ties. Titles
+	// sort by byte value so that every backend agrees on the order.
+	column, valueType := sort.Field(), "timestamptz"
+	if column == "title" {
+		column, valueType = `title COLLATE "C"`, "text"
+	}
+	direction, comparison := "ASC", ">"
+	if sort.Descending() {
+		direction, comparison = "DESC", "<"
+	}
+	if after != nil {
+		value, err := cursorValue(sort, after.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		id, err := uuid.Parse(after.ID)
+		if err != nil {
+			return nil, nil, models.ErrInvalidPageToken
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+			column, comparison, bind(&args, value), valueType, bind(&args, id.String())))
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, bind(&args, limit))
+
+	events, err := r.queryEvents(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursors := make([]models.EventCursor, len(events))
+	for i, event := range events {
+		cursors[i] = models.EventCursor{Value: sortValue(event, sort), ID: event.ID.String()}
+	}
+
+	return events, cursors, nil
+}
+
+// sortValue returns the cursor value of an event in a listing
+func sortValue(event *models.LiveEvent, sort models.EventSort) string {
+	switch sort.Field() {
+	case "end_time":
+		return event.EndTime.UTC().Format(time.RFC3339Nano)
+	case "title":
+		return event.Title
+	default:
+		return event.StartTime.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// cursorValue parses the value of a cursor issued by sortValue
+func cursorValue(sort models.EventSort, value string) (interface{}, error) {
+	if sort.Field() == "title" {
+		return value, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, models.ErrInvalidPageToken
+	}
+
+	return t, nil
+}
+
+// queryEvents runs a query selecting eventColumns and scans every row
+func (r *EventRepository) queryEvents(query string, args ...interface{}) ([]*models.LiveEvent, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.LiveEvent
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent reads a row selected with eventColumns into an event
+func scanEvent(row rowScanner) (*models.LiveEvent, error) {
+	var event models.LiveEvent
+	var status, structuredRewards string
+
+	if err := row.Scan(&event.ID, &event.ExternalID, &event.Title, &event.Description, &event.StartTime, &event.EndTime, &event.Rewards, &structuredRewards, &event.Recurrence, &event.Targeting, &status, &event.Version); err != nil {
+		return nil, err
+	}
+
+	event.Status = models.EventStatus(status)
+	event.StartTime = event.StartTime.UTC()
+	event.EndTime = event.EndTime.UTC()
+
+	if structuredRewards != "" {
+		if err := json.Unmarshal([]byte(structuredRewards), &event.StructuredRewards); err != nil {
+			return nil, fmt.Errorf("invalid structured rewards in database: %w", err)
+		}
+	}
+
+	return &event, nil
+}
+
+// encodeRewards converts structured rewards to a column value
+func encodeRewards(rewards []models.Reward) (string, error) {
+	if len(rewards) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(rewards)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode rewards: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// eventRevisionColumns lists the columns read by scanEventRevision, in order
+const eventRevisionColumns = "event_id, revision, action, snapshot, author_user_id, author_api_key_id, reverted_from, created_at"
+
+// EventRevisionRepository handles database operations for event revisions
+type EventRevisionRepository struct {
+	db querier
+}
+
+// Create appends a revision, numbering it after the latest revision of its
+// event. Revisions are written in the transaction changing their event, whose
+// row lock keeps concurrent revisions of the event from taking the same
+// number.
+func (r *EventRevisionRepository) Create(revision *models.EventRevision) error {
+	snapshot, err := json.Marshal(revision.Event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event snapshot: %w", err)
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO event_revisions (event_id, revision, action, snapshot, author_user_id, author_api_key_id, reverted_from, created_at)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, $7
+		FROM event_revisions
+		WHERE event_id = $1
+		RETURNING revision
+	`, revision.EventID.String(), revision.Action, string(snapshot), nullableUUID(revision.AuthorUserID), nullableUUID(revision.AuthorAPIKeyID),
+		revision.RevertedFrom, revision.CreatedAt).Scan(&revision.Revision)
+
+	if err != nil {
+		return fmt.Errorf("failed to create event revision: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a revision of an event
+func (r *EventRevisionRepository) Get(eventID uuid.UUID, revision int) (*models.EventRevision, error) {
+	row := r.db.QueryRow(`
+		SELECT `+eventRevisionColumns+`
+		FROM event_revisions
+		WHERE event_id = $1 AND revision = $2
+	`, eventID.String(), revision)
+
+	result, err := scanEventRevision(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to get event revision: %w", err)
+	}
+
+	return result, nil
+}
+
+// List retrieves revisions of an event, newest first. Only revisions older
+// than before are returned when it is positive.
+func (r *EventRevisionRepository) List(eventID uuid.UUID, before, limit int) ([]*models.EventRevision, error) {
+	args := []interface{}{eventID.String()}
+	query := `
+		SELECT ` + eventRevisionColumns + `
+		FROM event_revisions
+		WHERE event_id = $1
+	`
+	if before > 0 {
+		query += " AND revision < " + bind(&args, before)
+	}
+	query += " ORDER BY revision DESC LIMIT " + bind(&args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.EventRevision
+
+	for rows.Next() {
+		revision, err := scanEventRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event revision row: %w", err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// scanEventRevision reads a row selected with eventRevisionColumns into a
+// revision
+func scanEventRevision(row rowScanner) (*models.EventRevision, error) {
+	var revision models.EventRevision
+	var snapshot []byte
+	var authorUserID, authorAPIKeyID uuid.NullUUID
+
+	if err := row.Scan(&revision.EventID, &revision.Revision, &revision.Action, &snapshot, &authorUserID, &authorAPIKeyID,
+		&revision.RevertedFrom, &revision.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if authorUserID.Valid {
+		revision.AuthorUserID = &authorUserID.UUID
+	}
+	if authorAPIKeyID.Valid {
+		revision.AuthorAPIKeyID = &authorAPIKeyID.UUID
+	}
+
+	// Decode snapshot
+	if err := json.Unmarshal(snapshot, &revision.Event); err != nil {
+		return nil, fmt.Errorf("invalid event snapshot in database: %w", err)
+	}
+
+	revision.CreatedAt = revision.CreatedAt.UTC()
+
+	return &revision, nil
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// idempotencyColumns are the columns scanIdempotencyRecord reads, in order
+const idempotencyColumns = "api_key_id, key, fingerprint, completed, status_code, header, body, created_at, expires_at"
+
+// IdempotencyRepository handles database operations for idempotency records
+type IdempotencyRepository struct {
+	db querier
+}
+
+// Create adds a record unless its key is taken
+func (r *IdempotencyRepository) Create(record *models.IdempotencyRecord) error {
+	header, err := encodeIdempotencyHeader(record.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO idempotency_keys (`+idempotencyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, record.APIKeyID.String(), record.Key, record.Fingerprint, record.Completed, record.StatusCode, header,
+		idempotencyBody(record.Body), record.CreatedAt, record.ExpiresAt)
+
+	if err != nil {
+		if isViolation(err, codeUniqueViolation, "idempotency_keys_pkey") {
+			return models.ErrIdempotencyExists
+		}
+		return fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the record of a key of an API key
+func (r *IdempotencyRepository) Get(apiKeyID uuid.UUID, key string) (*models.IdempotencyRecord, error) {
+	row := r.db.QueryRow(`
+		SELECT `+idempotencyColumns+`
+		FROM idempotency_keys
+		WHERE api_key_id = $1 AND key = $2
+	`, apiKeyID.String(), key)
+
+	record, err := scanIdempotencyRecord(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrIdempotencyMissing
+		}
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	return record, nil
+}
+
+// Complete saves the response of a record
+func (r *IdempotencyRepository) Complete(record *models.IdempotencyRecord) error {
+	header, err := encodeIdempotencyHeader(record.Header)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE idempotency_keys
+		SET completed = TRUE, status_code = $1, header = $2, body = $3, expires_at = $4
+		WHERE api_key_id = $5 AND key = $6
+	`, record.StatusCode, header, idempotencyBody(record.Body), record.ExpiresAt, record.APIKeyID.String(), record.Key)
+
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrIdempotencyMissing
+	}
+
+	return nil
+}
+
+// Delete removes the record of a key of an API key
+func (r *IdempotencyRepository) Delete(apiKeyID uuid.UUID, key string) error {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE api_key_id = $1 AND key = $2", apiKeyID.String(), key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrIdempotencyMissing
+	}
+
+	return nil
+}
+
+// PurgeExpired removes the records that expired before the given time
+func (r *IdempotencyRepository) PurgeExpired(before time.Time) (int, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE expires_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency records: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// encodeIdempotencyHeader encodes the response headers of a record as JSON
+func encodeIdempotencyHeader(header map[string]string) (string, error) {
+	if header == nil {
+		header = map[string]string{}
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode idempotency header: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// idempotencyBody returns the response body of a record, never nil so that
+// it is not stored as NULL
+func idempotencyBody(body []byte) []byte {
+	if body == nil {
+		return []byte{}
+	}
+	return body
+}
+
+// scanIdempotencyRecord reads a record row
+func scanIdempotencyRecord(row rowScanner) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var header []byte
+
+	if err := row.Scan(&record.APIKeyID, &record.Key, &record.Fingerprint, &record.Completed, &record.StatusCode,
+		&header, &record.Body, &record.CreatedAt, &record.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(header, &record.Header); err != nil {
+		return nil, fmt.Errorf("invalid idempotency header in database: %w", err)
+	}
+
+	record.CreatedAt = record.CreatedAt.UTC()
+	record.ExpiresAt = record.ExpiresAt.UTC()
+
+	return &record, nil
+}
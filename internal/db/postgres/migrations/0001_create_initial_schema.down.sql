@@ -0,0 +1,7 @@
+DROP TABLE reward_catalog;
+DROP TABLE rate_limit_overrides;
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only();
+DROP TABLE events;
+DROP TABLE api_keys;
+DROP TABLE users;
@@ -0,0 +1,93 @@
+-- PostgreSQL schema matching version 9 of the SQLite migrations. Times are
+-- stored as timestamptz, so they compare and sort chronologically whatever
+-- the offset they were written with.
+CREATE TABLE users (
+	id UUID PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	role TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+-- API keys are stored as HMAC-SHA256 hashes. The seeded admin key is kept in
+-- legacy_key until the server hashes it with its pepper on startup.
+CREATE TABLE api_keys (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	prefix TEXT NOT NULL DEFAULT '',
+	key_hash TEXT UNIQUE,
+	legacy_key TEXT,
+	-- Comma-separated scopes; an empty list leaves the owner's role unrestricted
+	scopes TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	last_used TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+
+CREATE TABLE events (
+	id UUID PRIMARY KEY,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	start_time TIMESTAMPTZ NOT NULL,
+	end_time TIMESTAMPTZ NOT NULL,
+	rewards TEXT NOT NULL DEFAULT '',
+	-- JSON array of catalog-validated rewards; rewards keeps the raw JSON
+	structured_rewards TEXT NOT NULL DEFAULT '',
+	recurrence TEXT NOT NULL DEFAULT '',
+	-- Player targeting expression; empty targets every player
+	targeting TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'draft',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_events_time ON events(start_time, end_time);
+CREATE INDEX idx_events_end_time ON events(end_time);
+CREATE INDEX idx_events_status ON events(status);
+
+CREATE TABLE audit_log (
+	seq BIGSERIAL PRIMARY KEY,
+	id UUID NOT NULL UNIQUE,
+	actor_user_id UUID,
+	actor_api_key_id UUID,
+	action TEXT NOT NULL,
+	target_type TEXT NOT NULL,
+	target_id TEXT NOT NULL,
+	before_json TEXT,
+	after_json TEXT,
+	request_id TEXT NOT NULL DEFAULT '',
+	transport TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_user_id);
+CREATE INDEX idx_audit_log_target ON audit_log(target_type, target_id);
+CREATE INDEX idx_audit_log_action ON audit_log(action);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- The audit log is append-only
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TABLE rate_limit_overrides (
+	subject_type TEXT NOT NULL CHECK (subject_type IN ('role', 'api_key')),
+	subject TEXT NOT NULL,
+	requests_per_min INTEGER NOT NULL CHECK (requests_per_min > 0),
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (subject_type, subject)
+);
+
+CREATE TABLE reward_catalog (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL CHECK (type IN ('item', 'currency')),
+	name TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE event_revisions;
+DROP FUNCTION event_revisions_immutable();
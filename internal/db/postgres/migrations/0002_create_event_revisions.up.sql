@@ -0,0 +1,22 @@
+CREATE TABLE event_revisions (
+	event_id UUID NOT NULL,
+	revision INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	snapshot JSONB NOT NULL,
+	author_user_id UUID,
+	author_api_key_id UUID,
+	reverted_from INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (event_id, revision)
+);
+
+-- Revisions are immutable and outlive their event, so that deleted events
+-- keep their history
+CREATE FUNCTION event_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'event revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER event_revisions_immutable BEFORE UPDATE OR DELETE ON event_revisions
+FOR EACH ROW EXECUTE FUNCTION event_revisions_immutable();
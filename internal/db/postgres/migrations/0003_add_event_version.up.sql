@@ -0,0 +1,2 @@
+-- Incremented by every change to an event, for optimistic concurrency
+ALTER TABLE events ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
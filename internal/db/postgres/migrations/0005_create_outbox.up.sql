@@ -0,0 +1,16 @@
+CREATE TABLE outbox (
+	seq BIGSERIAL PRIMARY KEY,
+	type TEXT NOT NULL,
+	event_id UUID NOT NULL,
+	-- JSON snapshot of the event after the change
+	event JSONB NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMPTZ NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_outbox_status ON outbox(status, seq);
+CREATE INDEX idx_outbox_event ON outbox(event_id, seq);
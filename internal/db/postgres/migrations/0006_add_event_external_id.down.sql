@@ -0,0 +1,2 @@
+DROP INDEX idx_events_external_id;
+ALTER TABLE events DROP COLUMN external_id;
@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+	api_key_id UUID NOT NULL,
+	key TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	completed BOOLEAN NOT NULL DEFAULT FALSE,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	-- JSON object of the replayed response headers
+	header JSONB NOT NULL DEFAULT '{}',
+	body BYTEA NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (api_key_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
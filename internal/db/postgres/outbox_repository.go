@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// outboxColumns lists the columns read by scanOutboxEntry, in order
+const outboxColumns = "seq, type, event_id, event, status, attempts, next_attempt_at, last_error, created_at, updated_at"
+
+// OutboxRepository handles database operations for outbox entries
+type OutboxRepository struct {
+	db querier
+}
+
+// Create adds an entry to the database and sets its sequence number
+func (r *OutboxRepository) Create(entry *models.OutboxEntry) error {
+	event, err := json.Marshal(entry.Event)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO outbox (type, event_id, event, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING seq
+	`, string(entry.Type), entry.EventID.String(), string(event), string(entry.Status), entry.Attempts,
+		entry.NextAttemptAt, entry.LastError, entry.CreatedAt, entry.UpdatedAt).Scan(&entry.Seq)
+
+	if err != nil {
+		return fmt.Errorf("failed to create outbox entry: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves an entry by its sequence number
+func (r *OutboxRepository) Get(seq int64) (*models.OutboxEntry, error) {
+	row := r.db.QueryRow(`
+		SELECT `+outboxColumns+`
+		FROM outbox
+		WHERE seq = $1
+	`, seq)
+
+	entry, err := scanOutboxEntry(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOutboxNotFound
+		}
+		return nil, fmt.Errorf("failed to get outbox entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Update saves the progress of an entry
+func (r *OutboxRepository) Update(entry *models.OutboxEntry) error {
+	result, err := r.db.Exec(`
+		UPDATE outbox
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = $5
+		WHERE seq = $6
+	`, string(entry.Status), entry.Attempts, entry.NextAttemptAt, entry.LastError, entry.UpdatedAt, entry.Seq)
+
+	if err != nil {
+		return fmt.Errorf("failed to update outbox entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrOutboxNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue retrieves up to limit pending entries due at now, in sequence
+// order, leaving out those behind an earlier undelivered entry of their event,
+// and leases them until leaseUntil. Entries being claimed by another replica
+// are skipped rather than waited for.
+func (r *OutboxRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]*models.OutboxEntry, error) {
+	entries, err := r.queryEntries(`
+		UPDATE outbox
+		SET next_attempt_at = $1
+		WHERE seq IN (
+			SELECT seq
+			FROM outbox AS o
+			WHERE status = $2 AND next_attempt_at <= $3
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox AS earlier
+					WHERE earlier.event_id = o.event_id AND earlier.status <> $4 AND earlier.seq < o.seq
+				)
+			ORDER BY seq
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+outboxColumns,
+		leaseUntil, string(models.OutboxPending), now, string(models.OutboxDelivered), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not follow the order of the subquery
+	slices.SortFunc(entries, func(a, b *models.OutboxEntry) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	return entries, nil
+}
+
+// List retrieves entries matching the filter, newest first. Only entries
+// older than beforeSeq are returned when it is positive.
+func (r *OutboxRepository) List(filter models.OutboxFilter, beforeSeq int64, limit int) ([]*models.OutboxEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+bind(&args, string(filter.Status)))
+	}
+	if filter.EventID != nil {
+		conditions = append(conditions, "event_id = "+bind(&args, filter.EventID.String()))
+	}
+	if beforeSeq > 0 {
+		conditions = append(conditions, "seq < "+bind(&args, beforeSeq))
+	}
+
+	query := `SELECT ` + outboxColumns + ` FROM outbox`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC LIMIT " + bind(&args, limit)
+
+	return r.queryEntries(query, args...)
+}
+
+// ListAfter retrieves up to limit entries newer than afterSeq, oldest first
+func (r *OutboxRepository) ListAfter(afterSeq int64, limit int) ([]*models.OutboxEntry, error) {
+	return r.queryEntries(`SELECT `+outboxColumns+` FROM outbox WHERE seq > $1 ORDER BY seq LIMIT $2`, afterSeq, limit)
+}
+
+// PurgeDelivered removes the delivered entries last updated before the
+// given time
+func (r *OutboxRepository) PurgeDelivered(before time.Time) (int, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM outbox
+		WHERE status = $1 AND updated_at < $2
+	`, string(models.OutboxDelivered), before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox entries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// queryEntries runs a query selecting outboxColumns and scans every row
+func (r *OutboxRepository) queryEntries(query string, args ...interface{}) ([]*models.OutboxEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.OutboxEntry
+
+	for rows.Next() {
+		entry, err := scanOutboxEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanOutboxEntry reads a row selected with outboxColumns into an entry
+func scanOutboxEntry(row rowScanner) (*models.OutboxEntry, error) {
+	var entry models.OutboxEntry
+	var changeType, status string
+	var event []byte
+
+	if err := row.Scan(&entry.Seq, &changeType, &entry.EventID, &event, &status, &entry.Attempts,
+		&entry.NextAttemptAt, &entry.LastError, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(event, &entry.Event); err != nil {
+		return nil, fmt.Errorf("invalid outbox event in database: %w", err)
+	}
+
+	entry.Type = models.ChangeType(changeType)
+	entry.Status = models.OutboxStatus(status)
+	entry.NextAttemptAt = entry.NextAttemptAt.UTC()
+	entry.CreatedAt = entry.CreatedAt.UTC()
+	entry.UpdatedAt = entry.UpdatedAt.UTC()
+
+	return &entry, nil
+}
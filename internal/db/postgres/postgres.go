@@ -0,0 +1,223 @@
+// Package postgres implements the store repositories on PostgreSQL, for
+// deployments running several replicas against a shared database. Times are
+// stored as timestamptz and read back in UTC.
+package postgres
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/db"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// PostgreSQL error codes mapped to model errors
+const (
+	codeUniqueViolation     = "23505"
+	codeForeignKeyViolation = "23503"
+)
+
+// dialect adapts the shared migrator to PostgreSQL
+var dialect = db.Dialect{
+	Rebind: rebind,
+	TableExists: func(conn *sql.DB, table string) (bool, error) {
+		var exists bool
+		err := conn.QueryRow("SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists)
+		if err != nil {
+			return false, fmt.Errorf("failed to check for %s table: %w", table, err)
+		}
+		return exists, nil
+	},
+}
+
+// DB represents the database connection
+type DB struct {
+	*sql.DB
+}
+
+// New connects to the database, applies pending migrations and seeds the
+// default admin user
+func New(dsn string) (*DB, error) {
+	db, err := Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bring the schema up to date
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrator.Up(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	// Seed initial data
+	if err := db.seedAdmin(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to seed database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open connects to the database without touching the schema
+func Open(dsn string) (*DB, error) {
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Test connection
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{sqlDB}, nil
+}
+
+// NewMigrator creates a migrator for the embedded PostgreSQL migration set
+func NewMigrator(database *DB) (*db.Migrator, error) {
+	return db.NewDialectMigrator(database.DB, dialect, migrationFiles, "migrations")
+}
+
+// querier runs statements on the database, either directly or within a
+// transaction
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NewStore returns the repositories backed by the database, stamping
+// modifications with the time of clk
+func NewStore(db *DB, clk clock.Clock) *store.Store {
+	s := newStore(db, clk)
+	s.Transactor = &transactor{db: db, clock: clk}
+	return s
+}
+
+// newStore returns the repositories running their statements on q
+func newStore(q querier, clk clock.Clock) *store.Store {
+	return &store.Store{
+		Events:         &EventRepository{db: q, clock: clk},
+		EventRevisions: &EventRevisionRepository{db: q},
+		Users:          &UserRepository{db: q},
+		APIKeys:        &APIKeyRepository{db: q},
+		Audit:          &AuditRepository{db: q},
+		RateLimits:     &RateLimitRepository{db: q},
+		RewardCatalog:  &RewardCatalogRepository{db: q},
+		Webhooks:       &WebhookRepository{db: q},
+		Outbox:         &OutboxRepository{db: q},
+		Idempotency:    &IdempotencyRepository{db: q},
+	}
+}
+
+// transactor runs transactions on the database
+type transactor struct {
+	db    *DB
+	clock clock.Clock
+}
+
+// InTx calls fn with repositories running their statements in a transaction,
+// committed if fn returns nil and rolled back otherwise
+func (t *transactor) InTx(fn func(tx *store.Store) error) error {
+	sqlTx, err := t.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	tx := newStore(sqlTx, t.clock)
+	tx.Transactor = store.Joined(tx)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// seedAdmin creates the default admin user and API key if no admin exists
+func (db *DB) seedAdmin() error {
+	// Check if admin user exists, create if not
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM users WHERE role = $1", string(models.RoleAdmin)).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check for admin user: %w", err)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	log.Info().Msg("Creating default admin user")
+	now := time.Now()
+	_, err = db.Exec(`
+		INSERT INTO users (id, username, role, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, store.AdminUserID, store.AdminUsername, string(models.RoleAdmin), now)
+	if err != nil {
+		return fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	// Create an API key for the admin user. It is stored as a legacy key and
+	// hashed by the auth service on startup.
+	_, err = db.Exec(`
+		INSERT INTO api_keys (id, user_id, legacy_key, created_at, expires_at, last_used)
+		VALUES ($1, $2, $3, $4, $5, $4)
+	`, store.AdminAPIKeyID, store.AdminUserID, store.AdminAPIKey, now, now.AddDate(0, 0, 365))
+	if err != nil {
+		return fmt.Errorf("failed to create admin API key: %w", err)
+	}
+
+	return nil
+}
+
+// rebind converts ? placeholders to PostgreSQL's numbered placeholders
+func rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// bind appends a query argument and returns its placeholder
+func bind(args *[]interface{}, value interface{}) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+// isViolation reports whether err is the violation of a constraint of the
+// given kind, and of the named constraint unless constraint is empty
+func isViolation(err error, code, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code && (constraint == "" || pgErr.ConstraintName == constraint)
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// RateLimitRepository handles database operations for rate limit overrides
+type RateLimitRepository struct {
+	db querier
+}
+
+// List retrieves all rate limit overrides
+func (r *RateLimitRepository) List() ([]*models.RateLimitOverride, error) {
+	rows, err := r.db.Query(`
+		SELECT subject_type, subject, requests_per_min, updated_at
+		FROM rate_limit_overrides
+		ORDER BY subject_type COLLATE "C", subject COLLATE "C"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rate limit overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*models.RateLimitOverride
+
+	for rows.Next() {
+		override, err := scanRateLimitOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rate limit override row: %w", err)
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rate limit override rows: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// Get retrieves the override for a subject
+func (r *RateLimitRepository) Get(subjectType models.RateLimitSubject, subject string) (*models.RateLimitOverride, error) {
+	row := r.db.QueryRow(`
+		SELECT subject_type, subject, requests_per_min, updated_at
+		FROM rate_limit_overrides
+		WHERE subject_type = $1 AND subject = $2
+	`, string(subjectType), subject)
+
+	override, err := scanRateLimitOverride(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrRateLimitNotFound
+		}
+		return nil, fmt.Errorf("failed to get rate limit override: %w", err)
+	}
+
+	return override, nil
+}
+
+// Set creates or replaces the override for a subject
+func (r *RateLimitRepository) Set(override *models.RateLimitOverride) error {
+	_, err := r.db.Exec(`
+		INSERT INTO rate_limit_overrides (subject_type, subject, requests_per_min, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subject_type, subject) DO UPDATE
+		SET requests_per_min = excluded.requests_per_min, updated_at = excluded.updated_at
+	`, string(override.SubjectType), override.Subject, override.RequestsPerMin, override.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to set rate limit override: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the override for a subject
+func (r *RateLimitRepository) Delete(subjectType models.RateLimitSubject, subject string) error {
+	result, err := r.db.Exec("DELETE FROM rate_limit_overrides WHERE subject_type = $1 AND subject = $2", string(subjectType), subject)
+	if err != nil {
+		return fmt.Errorf("failed to delete rate limit override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrRateLimitNotFound
+	}
+
+	return nil
+}
+
+// scanRateLimitOverride reads an override row
+func scanRateLimitOverride(row rowScanner) (*models.RateLimitOverride, error) {
+	var override models.RateLimitOverride
+	var subjectType string
+
+	if err := row.Scan(&subjectType, &override.Subject, &override.RequestsPerMin, &override.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	override.SubjectType = models.RateLimitSubject(subjectType)
+	override.UpdatedAt = override.UpdatedAt.UTC()
+
+	return &override, nil
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// RewardCatalogRepository handles database operations for the reward catalog
+type RewardCatalogRepository struct {
+	db querier
+}
+
+// Create adds an item or currency to the catalog
+func (r *RewardCatalogRepository) Create(item *models.RewardCatalogItem) error {
+	_, err := r.db.Exec(`
+		INSERT INTO reward_catalog (id, type, name, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, item.ID, string(item.Type), item.Name, item.CreatedAt)
+
+	if err != nil {
+		if isViolation(err, codeUniqueViolation, "reward_catalog_pkey") {
+			return models.ErrRewardExists
+		}
+		return fmt.Errorf("failed to create reward catalog item: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a catalog entry by its ID
+func (r *RewardCatalogRepository) GetByID(id string) (*models.RewardCatalogItem, error) {
+	row := r.db.QueryRow(`
+		SELECT id, type, name, created_at
+		FROM reward_catalog
+		WHERE id = $1
+	`, id)
+
+	item, err := scanRewardCatalogItem(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrUnknownReward
+		}
+		return nil, fmt.Errorf("failed to get reward catalog item: %w", err)
+	}
+
+	return item, nil
+}
+
+// List retrieves the whole catalog ordered by ID
+func (r *RewardCatalogRepository) List() ([]*models.RewardCatalogItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, type, name, created_at
+		FROM reward_catalog
+		ORDER BY id COLLATE "C"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reward catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.RewardCatalogItem
+
+	for rows.Next() {
+		item, err := scanRewardCatalogItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reward catalog row: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reward catalog rows: %w", err)
+	}
+
+	return items, nil
+}
+
+// Delete removes an entry from the catalog. Events that already grant it
+// are left unchanged.
+func (r *RewardCatalogRepository) Delete(id string) error {
+	result, err := r.db.Exec("DELETE FROM reward_catalog WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reward catalog item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUnknownReward
+	}
+
+	return nil
+}
+
+// scanRewardCatalogItem reads a catalog row into an item
+func scanRewardCatalogItem(row rowScanner) (*models.RewardCatalogItem, error) {
+	var item models.RewardCatalogItem
+	var rewardType string
+
+	if err := row.Scan(&item.ID, &rewardType, &item.Name, &item.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	item.Type = models.RewardType(rewardType)
+	item.CreatedAt = item.CreatedAt.UTC()
+
+	return &item, nil
+}
@@ -0,0 +1,56 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/db/postgres"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/store/storetest"
+)
+
+// testDSNVariable names the variable holding the DSN of the scratch database
+// the tests run against. Every check wipes that database, so the tests are
+// skipped unless it is set, and never run against the server's database.
+const testDSNVariable = "LIVEOPS_TEST_DB_DSN"
+
+func TestStore(t *testing.T) {
+	dsn := os.Getenv(testDSNVariable)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNVariable)
+	}
+	if dsn == os.Getenv("LIVEOPS_DB_DSN") {
+		t.Fatalf("%s must not be the server's LIVEOPS_DB_DSN", testDSNVariable)
+	}
+
+	storetest.Run(t, func(t *testing.T) *store.Store {
+		resetDatabase(t, dsn)
+
+		database, err := postgres.New(dsn)
+		if err != nil {
+			t.Fatalf("failed to create database: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+
+		return postgres.NewStore(database, clock.System)
+	})
+}
+
+// resetDatabase rolls back every migration of the database at dsn, leaving
+// it empty for the next check
+func resetDatabase(t *testing.T, dsn string) {
+	database, err := postgres.Open(dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	migrator, err := postgres.NewMigrator(database)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	if err := migrator.MigrateTo(0); err != nil {
+		t.Fatalf("failed to reset database: %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// UserRepository handles database operations for users
+type UserRepository struct {
+	db querier
+}
+
+// CreateUser adds a new user to the database
+func (r *UserRepository) CreateUser(user *models.User) error {
+	_, err := r.db.Exec(`
+		INSERT INTO users (id, username, role, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, user.ID.String(), user.Username, string(user.Role), user.CreatedAt)
+
+	if err != nil {
+		if isViolation(err, codeUniqueViolation, "users_username_key") {
+			return models.ErrUsernameExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserByID retrieves a user by ID
+func (r *UserRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
+	row := r.db.QueryRow(`
+		SELECT id, username, role, created_at
+		FROM users
+		WHERE id = $1
+	`, id.String())
+
+	user, err := scanUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername retrieves a user by username
+func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+	row := r.db.QueryRow(`
+		SELECT id, username, role, created_at
+		FROM users
+		WHERE username = $1
+	`, username)
+
+	user, err := scanUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// ListUsers retrieves all users ordered by username
+func (r *UserRepository) ListUsers() ([]*models.User, error) {
+	rows, err := r.db.Query(`
+		SELECT id, username, role, created_at
+		FROM users
+		ORDER BY username COLLATE "C"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// UpdateUserRole changes the role of a user
+func (r *UserRepository) UpdateUserRole(id uuid.UUID, role models.Role) error {
+	result, err := r.db.Exec("UPDATE users SET role = $1 WHERE id = $2", string(role), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// CountUsersByRole returns the number of users with the given role
+func (r *UserRepository) CountUsersByRole(role models.Role) (int, error) {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = $1", string(role)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteUser removes a user by ID. Their API keys are removed with them.
+func (r *UserRepository) DeleteUser(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM users WHERE id = $1", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// scanUser reads a user row
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var role string
+
+	if err := row.Scan(&user.ID, &user.Username, &role, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	user.Role = models.Role(role)
+	user.CreatedAt = user.CreatedAt.UTC()
+
+	return &user, nil
+}
+
+// apiKeyColumns lists the columns read by scanAPIKey, in order
+const apiKeyColumns = "id, user_id, prefix, COALESCE(key_hash, ''), scopes, created_at, expires_at, last_used"
+
+// APIKeyRepository handles database operations for API keys
+type APIKeyRepository struct {
+	db querier
+}
+
+// CreateAPIKey adds a new API key to the database. Only its prefix and hash
+// are stored.
+func (r *APIKeyRepository) CreateAPIKey(apiKey *models.APIKey) error {
+	_, err := r.db.Exec(`
+		INSERT INTO api_keys (id, user_id, prefix, key_hash, scopes, created_at, expires_at, last_used)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, apiKey.ID.String(), apiKey.UserID.String(), apiKey.Prefix, apiKey.KeyHash, joinScopes(apiKey.Scopes), apiKey.CreatedAt, apiKey.ExpiresAt, apiKey.LastUsed)
+
+	if err != nil {
+		switch {
+		case isViolation(err, codeUniqueViolation, ""):
+			return models.ErrAPIKeyExists
+		case isViolation(err, codeForeignKeyViolation, ""):
+			return models.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of its key string
+func (r *APIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	row := r.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1
+	`, keyHash)
+
+	apiKey, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// GetAPIKeyByID retrieves an API key by ID
+func (r *APIKeyRepository) GetAPIKeyByID(id uuid.UUID) (*models.APIKey, error) {
+	row := r.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id = $1
+	`, id.String())
+
+	apiKey, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// ListLegacyAPIKeys returns the plaintext of keys that are not hashed yet,
+// keyed by API key ID
+func (r *APIKeyRepository) ListLegacyAPIKeys() (map[uuid.UUID]string, error) {
+	rows, err := r.db.Query("SELECT id, legacy_key FROM api_keys WHERE legacy_key IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query legacy API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[uuid.UUID]string)
+
+	for rows.Next() {
+		var id uuid.UUID
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan legacy API key row: %w", err)
+		}
+
+		keys[id] = key
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating legacy API key rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// SetAPIKeyHash stores the prefix and hash of a legacy key and erases its plaintext
+func (r *APIKeyRepository) SetAPIKeyHash(id uuid.UUID, prefix, keyHash string) error {
+	_, err := r.db.Exec(`
+		UPDATE api_keys
+		SET prefix = $1, key_hash = $2, legacy_key = NULL
+		WHERE id = $3
+	`, prefix, keyHash, id.String())
+
+	if err != nil {
+		return fmt.Errorf("failed to update API key hash: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAPIKeyLastUsed updates the last_used timestamp for an API key
+func (r *APIKeyRepository) UpdateAPIKeyLastUsed(id uuid.UUID, lastUsed time.Time) error {
+	_, err := r.db.Exec("UPDATE api_keys SET last_used = $1 WHERE id = $2", lastUsed, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to update API key last_used: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAPIKey removes an API key by ID
+func (r *APIKeyRepository) DeleteAPIKey(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM api_keys WHERE id = $1", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// ListAPIKeysByUserID retrieves all API keys for a user, newest first
+func (r *APIKeyRepository) ListAPIKeysByUserID(userID uuid.UUID) ([]*models.APIKey, error) {
+	rows, err := r.db.Query(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var apiKeys []*models.APIKey
+
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key row: %w", err)
+		}
+
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key rows: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// scanAPIKey reads a row selected with apiKeyColumns into an API key
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	var scopes string
+
+	if err := row.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Prefix, &apiKey.KeyHash, &scopes, &apiKey.CreatedAt, &apiKey.ExpiresAt, &apiKey.LastUsed); err != nil {
+		return nil, err
+	}
+
+	apiKey.Scopes = splitScopes(scopes)
+	apiKey.CreatedAt = apiKey.CreatedAt.UTC()
+	apiKey.ExpiresAt = apiKey.ExpiresAt.UTC()
+	apiKey.LastUsed = apiKey.LastUsed.UTC()
+
+	return &apiKey, nil
+}
+
+// joinScopes encodes scopes for the api_keys.scopes column
+func joinScopes(scopes []models.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitScopes decodes the api_keys.scopes column
+func splitScopes(value string) []models.Scope {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	scopes := make([]models.Scope, len(parts))
+	for i, part := range parts {
+		scopes[i] = models.Scope(part)
+	}
+	return scopes
+}
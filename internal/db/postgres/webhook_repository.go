@@ -0,0 +1,360 @@
+package postgres
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// deliveryColumns lists the columns read by scanDelivery, in order
+const deliveryColumns = "seq, id, subscription_id, event_type, event_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at"
+
+// WebhookRepository handles database operations for webhook subscriptions,
+// deliveries and delivery attempts
+type WebhookRepository struct {
+	db querier
+}
+
+// CreateSubscription adds a subscription to the database
+func (r *WebhookRepository) CreateSubscription(subscription *models.WebhookSubscription) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhook_subscriptions (id, url, event_types, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, subscription.ID.String(), subscription.URL, joinChangeTypes(subscription.EventTypes), subscription.Secret, subscription.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves a subscription by its ID
+func (r *WebhookRepository) GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error) {
+	row := r.db.QueryRow(`
+		SELECT id, url, event_types, secret, created_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`, id.String())
+
+	subscription, err := scanSubscription(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions retrieves every subscription, oldest first
+func (r *WebhookRepository) ListSubscriptions() ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, url, event_types, secret, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription row: %w", err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscription rows: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription by its ID. Its deliveries and
+// their attempts are removed by cascade.
+func (r *WebhookRepository) DeleteSubscription(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM webhook_subscriptions WHERE id = $1", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery queues a delivery and sets its sequence number
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	err := r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (id, subscription_id, event_type, event_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING seq
+	`, delivery.ID.String(), delivery.SubscriptionID.String(), string(delivery.EventType), delivery.EventID, string(delivery.Payload),
+		string(delivery.Status), delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.CreatedAt, delivery.UpdatedAt).Scan(&delivery.Seq)
+
+	if err != nil {
+		if isViolation(err, codeForeignKeyViolation, "") {
+			return models.ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery by its ID
+func (r *WebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	row := r.db.QueryRow(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE id = $1
+	`, id.String())
+
+	delivery, err := scanDelivery(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// UpdateDelivery saves the progress of a delivery
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	result, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = $5
+		WHERE id = $6
+	`, string(delivery.Status), delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.UpdatedAt, delivery.ID.String())
+
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries retrieves up to limit pending deliveries due at now,
+// those due the longest first, and leases them until leaseUntil. They are
+// returned in queueing order. Deliveries being claimed by another replica
+// are skipped rather than waited for.
+func (r *WebhookRepository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	deliveries, err := r.queryDeliveries(`
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at, seq
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns,
+		leaseUntil, string(models.DeliveryPending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not follow the order of the subquery
+	slices.SortFunc(deliveries, func(a, b *models.WebhookDelivery) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	return deliveries, nil
+}
+
+// ListDeliveries retrieves deliveries matching the filter, newest first. Only
+// deliveries older than beforeSeq are returned when it is positive.
+func (r *WebhookRepository) ListDeliveries(filter models.WebhookDeliveryFilter, beforeSeq int64, limit int) ([]*models.WebhookDelivery, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != nil {
+		conditions = append(conditions, "subscription_id = "+bind(&args, filter.SubscriptionID.String()))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+bind(&args, string(filter.Status)))
+	}
+	if filter.EventID != "" {
+		conditions = append(conditions, "event_id = "+bind(&args, filter.EventID))
+	}
+	if beforeSeq > 0 {
+		conditions = append(conditions, "seq < "+bind(&args, beforeSeq))
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC LIMIT " + bind(&args, limit)
+
+	return r.queryDeliveries(query, args...)
+}
+
+// queryDeliveries runs a query selecting deliveryColumns and scans every row
+func (r *WebhookRepository) queryDeliveries(query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// CreateAttempt logs an attempt at posting a delivery
+func (r *WebhookRepository) CreateAttempt(attempt *models.WebhookAttempt) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhook_attempts (delivery_id, status_code, error, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, attempt.DeliveryID.String(), attempt.StatusCode, attempt.Error, attempt.DurationMS, attempt.CreatedAt)
+
+	if err != nil {
+		if isViolation(err, codeForeignKeyViolation, "") {
+			return models.ErrDeliveryNotFound
+		}
+		return fmt.Errorf("failed to create webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListAttempts retrieves the attempts of a delivery, oldest first
+func (r *WebhookRepository) ListAttempts(deliveryID uuid.UUID) ([]*models.WebhookAttempt, error) {
+	rows, err := r.db.Query(`
+		SELECT status_code, error, duration_ms, created_at
+		FROM webhook_attempts
+		WHERE delivery_id = $1
+		ORDER BY seq
+	`, deliveryID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*models.WebhookAttempt
+
+	for rows.Next() {
+		attempt := models.WebhookAttempt{DeliveryID: deliveryID}
+
+		if err := rows.Scan(&attempt.StatusCode, &attempt.Error, &attempt.DurationMS, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt row: %w", err)
+		}
+		attempt.CreatedAt = attempt.CreatedAt.UTC()
+
+		attempts = append(attempts, &attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook attempt rows: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// scanSubscription reads a subscription row
+func scanSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	var eventTypes string
+
+	if err := row.Scan(&subscription.ID, &subscription.URL, &eventTypes, &subscription.Secret, &subscription.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	subscription.EventTypes = splitChangeTypes(eventTypes)
+	subscription.CreatedAt = subscription.CreatedAt.UTC()
+
+	return &subscription, nil
+}
+
+// scanDelivery reads a row selected with deliveryColumns into a delivery
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var eventType, payload, status string
+
+	if err := row.Scan(&delivery.Seq, &delivery.ID, &delivery.SubscriptionID, &eventType, &delivery.EventID, &payload, &status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	delivery.EventType = models.ChangeType(eventType)
+	delivery.Payload = []byte(payload)
+	delivery.Status = models.WebhookDeliveryStatus(status)
+	delivery.NextAttemptAt = delivery.NextAttemptAt.UTC()
+	delivery.CreatedAt = delivery.CreatedAt.UTC()
+	delivery.UpdatedAt = delivery.UpdatedAt.UTC()
+
+	return &delivery, nil
+}
+
+// joinChangeTypes encodes change types for the webhook_subscriptions.event_types
+// column
+func joinChangeTypes(changeTypes []models.ChangeType) string {
+	parts := make([]string, len(changeTypes))
+	for i, changeType := range changeTypes {
+		parts[i] = string(changeType)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitChangeTypes decodes the webhook_subscriptions.event_types column
+func splitChangeTypes(value string) []models.ChangeType {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	changeTypes := make([]models.ChangeType, len(parts))
+	for i, part := range parts {
+		changeTypes[i] = models.ChangeType(part)
+	}
+	return changeTypes
+}
@@ -5,18 +5,72 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
 )
 
+// timestampFormat is a fixed-width UTC layout whose lexical order matches
+// chronological order, so stored timestamps can be compared in SQL. Every
+// time is stored in this format, whatever its location, and times are never
+// compared with SQLite's own clock.
+const timestampFormat = "2006-01-02T15:04:05.000000000Z"
+
+// formatTimestamp formats a time for storage
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+// parseTimestamp parses a time read from the database. Columns declared as
+// TIMESTAMP are already parsed by the driver and read back in RFC 3339.
+func parseTimestamp(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
 // DB represents the database connection
 type DB struct {
 	*sql.DB
 }
 
-// New creates a new database connection
+// New creates a new database connection, applies pending migrations and
+// seeds the default admin user
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bring the schema up to date
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrator.Up(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	// Seed initial data
+	if err := db.seedAdmin(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to seed database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open creates a new database connection without touching the schema
+func Open(dbPath string) (*DB, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if dir != "." && dir != "/" {
@@ -25,8 +79,15 @@ func New(dbPath string) (*DB, error) {
 		}
 	}
 
-	// Open database connection
-	sqlDB, err := sql.Open("sqlite3", dbPath)
+	// Open database connection. Every pooled connection enforces foreign
+	// keys, and transactions take the write lock when they begin, so that
+	// concurrent transactions wait for each other rather than fail when one
+	// reads before it writes.
+	separator := "?"
+	if strings.Contains(dbPath, "?") {
+		separator = "&"
+	}
+	sqlDB, err := sql.Open("sqlite3", dbPath+separator+"_foreign_keys=on&_txlock=immediate")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -37,108 +98,64 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := sqlDB.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		sqlDB.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
-	db := &DB{sqlDB}
-
-	// Initialize database schema
-	if err := db.initSchema(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return db, nil
+	return &DB{sqlDB}, nil
 }
 
-// initSchema creates the database schema if it doesn't exist
-func (db *DB) initSchema() error {
-	log.Info().Msg("Initializing database schema")
-
-	// Create users table
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			id TEXT PRIMARY KEY,
-			username TEXT NOT NULL UNIQUE,
-			role TEXT NOT NULL,
-			created_at TIMESTAMP NOT NULL
-		)
-	`)
+// seedAdmin creates the default admin user and API key if no admin exists
+func (db *DB) seedAdmin() error {
+	// Check if admin user exists, create if not
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&count)
 	if err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
+		return fmt.Errorf("failed to check for admin user: %w", err)
 	}
 
-	// Create api_keys table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS api_keys (
-			id TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL,
-			key TEXT NOT NULL UNIQUE,
-			created_at TIMESTAMP NOT NULL,
-			expires_at TIMESTAMP NOT NULL,
-			last_used TIMESTAMP NOT NULL,
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create api_keys table: %w", err)
+	if count > 0 {
+		return nil
 	}
 
-	// Create events table
+	log.Info().Msg("Creating default admin user")
+	now := time.Now()
 	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS events (
-			id TEXT PRIMARY KEY,
-			title TEXT NOT NULL,
-			description TEXT,
-			start_time TIMESTAMP NOT NULL,
-			end_time TIMESTAMP NOT NULL,
-			rewards TEXT,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		)
-	`)
+		INSERT INTO users (id, username, role, created_at)
+		VALUES (?, ?, ?, ?)
+	`, store.AdminUserID, store.AdminUsername, string(models.RoleAdmin), formatTimestamp(now))
 	if err != nil {
-		return fmt.Errorf("failed to create events table: %w", err)
+		return fmt.Errorf("failed to create admin user: %w", err)
 	}
 
-	// Create index on event start and end times
+	// Create an API key for the admin user. It is stored as a legacy key and
+	// hashed by the auth service on startup.
 	_, err = db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_events_time 
-		ON events(start_time, end_time)
-	`)
+		INSERT INTO api_keys (id, user_id, legacy_key, created_at, expires_at, last_used)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, store.AdminAPIKeyID, store.AdminUserID, store.AdminAPIKey,
+		formatTimestamp(now), formatTimestamp(now.AddDate(0, 0, 365)), formatTimestamp(now))
 	if err != nil {
-		return fmt.Errorf("failed to create events index: %w", err)
+		return fmt.Errorf("failed to create admin API key: %w", err)
 	}
 
-	// Check if admin user exists, create if not
+	return nil
+}
+
+// tableExists checks if a table is present in the database
+func (db *DB) tableExists(table string) (bool, error) {
 	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&count)
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
 	if err != nil {
-		return fmt.Errorf("failed to check for admin user: %w", err)
+		return false, fmt.Errorf("failed to check for %s table: %w", table, err)
 	}
 
-	if count == 0 {
-		log.Info().Msg("Creating default admin user")
-		_, err = db.Exec(`
-			INSERT INTO users (id, username, role, created_at)
-			VALUES (?, ?, ?, datetime('now'))
-		`, "00000000-0000-0000-0000-000000000000", "admin", "admin")
-		if err != nil {
-			return fmt.Errorf("failed to create admin user: %w", err)
-		}
+	return count > 0, nil
+}
 
-		// Create an API key for the admin user
-		_, err = db.Exec(`
-			INSERT INTO api_keys (id, user_id, key, created_at, expires_at, last_used)
-			VALUES (?, ?, ?, datetime('now'), datetime('now', '+365 days'), datetime('now'))
-		`, "00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000000", "admin-api-key-00000000-0000-0000-0000-000000000000")
-		if err != nil {
-			return fmt.Errorf("failed to create admin API key: %w", err)
-		}
+// columnExists checks if a table has the given column
+func (db *DB) columnExists(table, column string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s table: %w", table, err)
 	}
 
-	return nil
+	return count > 0, nil
 }
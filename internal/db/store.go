@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// querier runs statements on the database, either directly or within a
+// transaction
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NewStore returns the repositories backed by the database, stamping
+// modifications with the time of clk
+func NewStore(db *DB, clk clock.Clock) *store.Store {
+	s := newStore(db, clk)
+	s.Transactor = &transactor{db: db, clock: clk}
+	return s
+}
+
+// newStore returns the repositories running their statements on q
+func newStore(q querier, clk clock.Clock) *store.Store {
+	return &store.Store{
+		Events:         &EventRepository{db: q, clock: clk},
+		EventRevisions: &EventRevisionRepository{db: q},
+		Users:          &UserRepository{db: q},
+		APIKeys:        &APIKeyRepository{db: q},
+		Audit:          &AuditRepository{db: q},
+		RateLimits:     &RateLimitRepository{db: q},
+		RewardCatalog:  &RewardCatalogRepository{db: q},
+		Webhooks:       &WebhookRepository{db: q},
+		Outbox:         &OutboxRepository{db: q},
+		Idempotency:    &IdempotencyRepository{db: q},
+	}
+}
+
+// transactor runs transactions on the database
+type transactor struct {
+	db    *DB
+	clock clock.Clock
+}
+
+// InTx calls fn with repositories running their statements in a transaction,
+// committed if fn returns nil and rolled back otherwise
+func (t *transactor) InTx(fn func(tx *store.Store) error) error {
+	sqlTx, err := t.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	tx := newStore(sqlTx, t.clock)
+	tx.Transactor = store.Joined(tx)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isConstraintError reports whether err is the violation of a constraint of
+// the given kind
+func isConstraintError(err error, code sqlite3.ErrNoExtended) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == code
+}
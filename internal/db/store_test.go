@@ -0,0 +1,39 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/db"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, newTestStore)
+}
+
+// TestStoreLocalTimeZone runs the suite again with the server in a time zone
+// ahead of UTC, so that times written or read back in local time rather than
+// UTC make the time zone checks fail
+func TestStoreLocalTimeZone(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("JST", 9*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	storetest.Run(t, newTestStore)
+}
+
+// newTestStore returns a store on a fresh, migrated database in a temporary
+// directory removed after the test
+func newTestStore(t *testing.T) *store.Store {
+	database, err := db.New(filepath.Join(t.TempDir(), "liveops.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return db.NewStore(database, clock.System)
+}
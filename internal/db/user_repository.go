@@ -3,15 +3,17 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
 	"github.com/tombombadilom/liveops/internal/models"
 )
 
 // UserRepository handles database operations for users
 type UserRepository struct {
-	db *DB
+	db querier
 }
 
 // NewUserRepository creates a new user repository
@@ -24,9 +26,12 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 	_, err := r.db.Exec(`
 		INSERT INTO users (id, username, role, created_at)
 		VALUES (?, ?, ?, ?)
-	`, user.ID.String(), user.Username, string(user.Role), user.CreatedAt)
+	`, user.ID.String(), user.Username, string(user.Role), formatTimestamp(user.CreatedAt))
 
 	if err != nil {
+		if isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return models.ErrUsernameExists
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -47,7 +52,7 @@ func (r *UserRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -62,7 +67,7 @@ func (r *UserRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
 	user.Role = models.Role(roleStr)
 
 	// Parse timestamp
-	user.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
+	user.CreatedAt, err = parseTimestamp(createdAt)
 	if err != nil {
 		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
 	}
@@ -84,7 +89,7 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -99,7 +104,7 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 	user.Role = models.Role(roleStr)
 
 	// Parse timestamp
-	user.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
+	user.CreatedAt, err = parseTimestamp(createdAt)
 	if err != nil {
 		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
 	}
@@ -140,7 +145,7 @@ func (r *UserRepository) ListUsers() ([]*models.User, error) {
 		user.Role = models.Role(roleStr)
 
 		// Parse timestamp
-		user.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
+		user.CreatedAt, err = parseTimestamp(createdAt)
 		if err != nil {
 			return nil, fmt.Errorf("invalid created_at time in database: %w", err)
 		}
@@ -155,7 +160,36 @@ func (r *UserRepository) ListUsers() ([]*models.User, error) {
 	return users, nil
 }
 
-// DeleteUser removes a user by ID
+// UpdateUserRole changes the role of a user
+func (r *UserRepository) UpdateUserRole(id uuid.UUID, role models.Role) error {
+	result, err := r.db.Exec("UPDATE users SET role = ? WHERE id = ?", string(role), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// CountUsersByRole returns the number of users with a role
+func (r *UserRepository) CountUsersByRole(role models.Role) (int, error) {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?", string(role)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteUser removes a user by ID. Their API keys are removed with them.
 func (r *UserRepository) DeleteUser(id uuid.UUID) error {
 	result, err := r.db.Exec("DELETE FROM users WHERE id = ?", id.String())
 	if err != nil {
@@ -168,15 +202,18 @@ func (r *UserRepository) DeleteUser(id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return models.ErrUserNotFound
 	}
 
 	return nil
 }
 
+// apiKeyColumns lists the columns read by scanAPIKey, in order
+const apiKeyColumns = "id, user_id, prefix, COALESCE(key_hash, ''), scopes, created_at, expires_at, last_used"
+
 // APIKeyRepository handles database operations for API keys
 type APIKeyRepository struct {
-	db *DB
+	db querier
 }
 
 // NewAPIKeyRepository creates a new API key repository
@@ -184,32 +221,37 @@ func NewAPIKeyRepository(db *DB) *APIKeyRepository {
 	return &APIKeyRepository{db: db}
 }
 
-// CreateAPIKey adds a new API key to the database
+// CreateAPIKey adds a new API key to the database. Only its prefix and hash
+// are stored.
 func (r *APIKeyRepository) CreateAPIKey(apiKey *models.APIKey) error {
 	_, err := r.db.Exec(`
-		INSERT INTO api_keys (id, user_id, key, created_at, expires_at, last_used)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, apiKey.ID.String(), apiKey.UserID.String(), apiKey.Key, apiKey.CreatedAt, apiKey.ExpiresAt, apiKey.LastUsed)
+		INSERT INTO api_keys (id, user_id, prefix, key_hash, scopes, created_at, expires_at, last_used)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, apiKey.ID.String(), apiKey.UserID.String(), apiKey.Prefix, apiKey.KeyHash, joinScopes(apiKey.Scopes),
+		formatTimestamp(apiKey.CreatedAt), formatTimestamp(apiKey.ExpiresAt), formatTimestamp(apiKey.LastUsed))
 
 	if err != nil {
+		switch {
+		case isConstraintError(err, sqlite3.ErrConstraintUnique), isConstraintError(err, sqlite3.ErrConstraintPrimaryKey):
+			return models.ErrAPIKeyExists
+		case isConstraintError(err, sqlite3.ErrConstraintForeignKey):
+			return models.ErrUserNotFound
+		}
 		return fmt.Errorf("failed to create API key: %w", err)
 	}
 
 	return nil
 }
 
-// GetAPIKeyByKey retrieves an API key by its key string
-func (r *APIKeyRepository) GetAPIKeyByKey(key string) (*models.APIKey, error) {
-	var apiKey models.APIKey
-	var idStr, userIDStr string
-	var createdAt, expiresAt, lastUsed string
-
-	err := r.db.QueryRow(`
-		SELECT id, user_id, key, created_at, expires_at, last_used
+// GetAPIKeyByHash retrieves an API key by the hash of its key string
+func (r *APIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	row := r.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
 		FROM api_keys
-		WHERE key = ?
-	`, key).Scan(&idStr, &userIDStr, &apiKey.Key, &createdAt, &expiresAt, &lastUsed)
+		WHERE key_hash = ?
+	`, keyHash)
 
+	apiKey, err := scanAPIKey(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrInvalidAPIKey
@@ -217,34 +259,73 @@ func (r *APIKeyRepository) GetAPIKeyByKey(key string) (*models.APIKey, error) {
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	// Parse UUIDs
-	apiKey.ID, err = uuid.Parse(idStr)
+	return apiKey, nil
+}
+
+// GetAPIKeyByID retrieves an API key by ID
+func (r *APIKeyRepository) GetAPIKeyByID(id uuid.UUID) (*models.APIKey, error) {
+	row := r.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id = ?
+	`, id.String())
+
+	apiKey, err := scanAPIKey(row)
 	if err != nil {
-		return nil, fmt.Errorf("invalid API key ID in database: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, models.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	apiKey.UserID, err = uuid.Parse(userIDStr)
+	return apiKey, nil
+}
+
+// ListLegacyAPIKeys returns the plaintext of keys stored before hashing was
+// introduced, keyed by API key ID
+func (r *APIKeyRepository) ListLegacyAPIKeys() (map[uuid.UUID]string, error) {
+	rows, err := r.db.Query("SELECT id, legacy_key FROM api_keys WHERE legacy_key IS NOT NULL")
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID in database: %w", err)
+		return nil, fmt.Errorf("failed to query legacy API keys: %w", err)
 	}
+	defer rows.Close()
 
-	// Parse timestamps
-	apiKey.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-	if err != nil {
-		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	keys := make(map[uuid.UUID]string)
+
+	for rows.Next() {
+		var idStr, key string
+		if err := rows.Scan(&idStr, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan legacy API key row: %w", err)
+		}
+
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API key ID in database: %w", err)
+		}
+
+		keys[id] = key
 	}
 
-	apiKey.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
-	if err != nil {
-		return nil, fmt.Errorf("invalid expires_at time in database: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating legacy API key rows: %w", err)
 	}
 
-	apiKey.LastUsed, err = time.Parse(time.RFC3339, lastUsed)
+	return keys, nil
+}
+
+// SetAPIKeyHash stores the prefix and hash of a legacy key and erases its plaintext
+func (r *APIKeyRepository) SetAPIKeyHash(id uuid.UUID, prefix, keyHash string) error {
+	_, err := r.db.Exec(`
+		UPDATE api_keys
+		SET prefix = ?, key_hash = ?, legacy_key = NULL
+		WHERE id = ?
+	`, prefix, keyHash, id.String())
+
 	if err != nil {
-		return nil, fmt.Errorf("invalid last_used time in database: %w", err)
+		return fmt.Errorf("failed to update API key hash: %w", err)
 	}
 
-	return &apiKey, nil
+	return nil
 }
 
 // UpdateAPIKeyLastUsed updates the last_used timestamp for an API key
@@ -253,7 +334,7 @@ func (r *APIKeyRepository) UpdateAPIKeyLastUsed(id uuid.UUID, lastUsed time.Time
 		UPDATE api_keys
 		SET last_used = ?
 		WHERE id = ?
-	`, lastUsed, id.String())
+	`, formatTimestamp(lastUsed), id.String())
 
 	if err != nil {
 		return fmt.Errorf("failed to update API key last_used: %w", err)
@@ -275,7 +356,7 @@ func (r *APIKeyRepository) DeleteAPIKey(id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("API key not found")
+		return models.ErrAPIKeyNotFound
 	}
 
 	return nil
@@ -284,7 +365,7 @@ func (r *APIKeyRepository) DeleteAPIKey(id uuid.UUID) error {
 // ListAPIKeysByUserID retrieves all API keys for a user
 func (r *APIKeyRepository) ListAPIKeysByUserID(userID uuid.UUID) ([]*models.APIKey, error) {
 	rows, err := r.db.Query(`
-		SELECT id, user_id, key, created_at, expires_at, last_used
+		SELECT `+apiKeyColumns+`
 		FROM api_keys
 		WHERE user_id = ?
 		ORDER BY created_at DESC
@@ -297,47 +378,84 @@ func (r *APIKeyRepository) ListAPIKeysByUserID(userID uuid.UUID) ([]*models.APIK
 	var apiKeys []*models.APIKey
 
 	for rows.Next() {
-		var apiKey models.APIKey
-		var idStr, userIDStr string
-		var createdAt, expiresAt, lastUsed string
-
-		if err := rows.Scan(&idStr, &userIDStr, &apiKey.Key, &createdAt, &expiresAt, &lastUsed); err != nil {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan API key row: %w", err)
 		}
 
-		// Parse UUIDs
-		apiKey.ID, err = uuid.Parse(idStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid API key ID in database: %w", err)
-		}
+		apiKeys = append(apiKeys, apiKey)
+	}
 
-		apiKey.UserID, err = uuid.Parse(userIDStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid user ID in database: %w", err)
-		}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key rows: %w", err)
+	}
 
-		// Parse timestamps
-		apiKey.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("invalid created_at time in database: %w", err)
-		}
+	return apiKeys, nil
+}
 
-		apiKey.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
-		if err != nil {
-			return nil, fmt.Errorf("invalid expires_at time in database: %w", err)
-		}
+// scanAPIKey reads a row selected with apiKeyColumns into an API key
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	var idStr, userIDStr, scopes string
+	var createdAt, expiresAt, lastUsed string
 
-		apiKey.LastUsed, err = time.Parse(time.RFC3339, lastUsed)
-		if err != nil {
-			return nil, fmt.Errorf("invalid last_used time in database: %w", err)
-		}
+	if err := row.Scan(&idStr, &userIDStr, &apiKey.Prefix, &apiKey.KeyHash, &scopes, &createdAt, &expiresAt, &lastUsed); err != nil {
+		return nil, err
+	}
+
+	apiKey.Scopes = splitScopes(scopes)
 
-		apiKeys = append(apiKeys, &apiKey)
+	var err error
+
+	// Parse UUIDs
+	apiKey.ID, err = uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key ID in database: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating API key rows: %w", err)
+	apiKey.UserID, err = uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID in database: %w", err)
 	}
 
-	return apiKeys, nil
+	// Parse timestamps
+	apiKey.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	}
+
+	apiKey.ExpiresAt, err = parseTimestamp(expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires_at time in database: %w", err)
+	}
+
+	apiKey.LastUsed, err = parseTimestamp(lastUsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last_used time in database: %w", err)
+	}
+
+	return &apiKey, nil
+}
+
+// joinScopes encodes scopes for the api_keys.scopes column
+func joinScopes(scopes []models.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitScopes decodes the api_keys.scopes column
+func splitScopes(value string) []models.Scope {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	scopes := make([]models.Scope, len(parts))
+	for i, part := range parts {
+		scopes[i] = models.Scope(part)
+	}
+	return scopes
 }
@@ -0,0 +1,417 @@
+package db
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// deliveryColumns lists the columns read by scanDelivery, in order
+const deliveryColumns = "seq, id, subscription_id, event_type, event_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at"
+
+// WebhookRepository handles database operations for webhook subscriptions,
+// deliveries and delivery attempts
+type WebhookRepository struct {
+	db querier
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription adds a subscription to the database
+func (r *WebhookRepository) CreateSubscription(subscription *models.WebhookSubscription) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhook_subscriptions (id, url, event_types, secret, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, subscription.ID.String(), subscription.URL, joinChangeTypes(subscription.EventTypes), subscription.Secret,
+		formatTimestamp(subscription.CreatedAt))
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves a subscription by its ID
+func (r *WebhookRepository) GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error) {
+	row := r.db.QueryRow(`
+		SELECT id, url, event_types, secret, created_at
+		FROM webhook_subscriptions
+		WHERE id = ?
+	`, id.String())
+
+	subscription, err := scanSubscription(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions retrieves every subscription, oldest first
+func (r *WebhookRepository) ListSubscriptions() ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, url, event_types, secret, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription row: %w", err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscription rows: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription by its ID. Its deliveries and
+// their attempts are removed by cascade.
+func (r *WebhookRepository) DeleteSubscription(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM webhook_subscriptions WHERE id = ?", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery queues a delivery and sets its sequence number
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (id, subscription_id, event_type, event_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, delivery.ID.String(), delivery.SubscriptionID.String(), string(delivery.EventType), delivery.EventID, string(delivery.Payload),
+		string(delivery.Status), delivery.Attempts, formatTimestamp(delivery.NextAttemptAt), delivery.LastError,
+		formatTimestamp(delivery.CreatedAt), formatTimestamp(delivery.UpdatedAt))
+
+	if err != nil {
+		if isConstraintError(err, sqlite3.ErrConstraintForeignKey) {
+			return models.ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	delivery.Seq, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get webhook delivery sequence: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery by its ID
+func (r *WebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	row := r.db.QueryRow(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE id = ?
+	`, id.String())
+
+	delivery, err := scanDelivery(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// UpdateDelivery saves the progress of a delivery
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	result, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, updated_at = ?
+		WHERE id = ?
+	`, string(delivery.Status), delivery.Attempts, formatTimestamp(delivery.NextAttemptAt), delivery.LastError,
+		formatTimestamp(delivery.UpdatedAt), delivery.ID.String())
+
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries retrieves up to limit pending deliveries due at now,
+// those due the longest first, and leases them until leaseUntil. They are
+// returned in queueing order.
+func (r *WebhookRepository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	deliveries, err := r.queryDeliveries(`
+		UPDATE webhook_deliveries
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, seq
+			LIMIT ?
+		)
+		RETURNING `+deliveryColumns,
+		formatTimestamp(leaseUntil), string(models.DeliveryPending), formatTimestamp(now), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not follow the order of the subquery
+	slices.SortFunc(deliveries, func(a, b *models.WebhookDelivery) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	return deliveries, nil
+}
+
+// ListDeliveries retrieves deliveries matching the filter, newest first. Only
+// deliveries older than beforeSeq are returned when it is positive.
+func (r *WebhookRepository) ListDeliveries(filter models.WebhookDeliveryFilter, beforeSeq int64, limit int) ([]*models.WebhookDelivery, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != nil {
+		conditions = append(conditions, "subscription_id = ?")
+		args = append(args, filter.SubscriptionID.String())
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	if filter.EventID != "" {
+		conditions = append(conditions, "event_id = ?")
+		args = append(args, filter.EventID)
+	}
+	if beforeSeq > 0 {
+		conditions = append(conditions, "seq < ?")
+		args = append(args, beforeSeq)
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq DESC LIMIT ?"
+	args = append(args, limit)
+
+	return r.queryDeliveries(query, args...)
+}
+
+// queryDeliveries runs a query selecting deliveryColumns and scans every row
+func (r *WebhookRepository) queryDeliveries(query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// CreateAttempt logs an attempt at posting a delivery
+func (r *WebhookRepository) CreateAttempt(attempt *models.WebhookAttempt) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhook_attempts (delivery_id, status_code, error, duration_ms, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, attempt.DeliveryID.String(), attempt.StatusCode, attempt.Error, attempt.DurationMS, formatTimestamp(attempt.CreatedAt))
+
+	if err != nil {
+		if isConstraintError(err, sqlite3.ErrConstraintForeignKey) {
+			return models.ErrDeliveryNotFound
+		}
+		return fmt.Errorf("failed to create webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListAttempts retrieves the attempts of a delivery, oldest first
+func (r *WebhookRepository) ListAttempts(deliveryID uuid.UUID) ([]*models.WebhookAttempt, error) {
+	rows, err := r.db.Query(`
+		SELECT status_code, error, duration_ms, created_at
+		FROM webhook_attempts
+		WHERE delivery_id = ?
+		ORDER BY seq
+	`, deliveryID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*models.WebhookAttempt
+
+	for rows.Next() {
+		attempt := models.WebhookAttempt{DeliveryID: deliveryID}
+		var createdAt string
+
+		if err := rows.Scan(&attempt.StatusCode, &attempt.Error, &attempt.DurationMS, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt row: %w", err)
+		}
+
+		attempt.CreatedAt, err = parseTimestamp(createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+		}
+
+		attempts = append(attempts, &attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook attempt rows: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// scanSubscription reads a subscription row
+func scanSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	var idStr, eventTypes, createdAt string
+
+	if err := row.Scan(&idStr, &subscription.URL, &eventTypes, &subscription.Secret, &createdAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	subscription.ID, err = uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook subscription ID in database: %w", err)
+	}
+
+	subscription.EventTypes = splitChangeTypes(eventTypes)
+
+	subscription.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+// scanDelivery reads a row selected with deliveryColumns into a delivery
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var idStr, subscriptionID, eventType, payload, status, nextAttemptAt, createdAt, updatedAt string
+
+	if err := row.Scan(&delivery.Seq, &idStr, &subscriptionID, &eventType, &delivery.EventID, &payload, &status, &delivery.Attempts,
+		&nextAttemptAt, &delivery.LastError, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	// Parse UUIDs
+	delivery.ID, err = uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook delivery ID in database: %w", err)
+	}
+
+	delivery.SubscriptionID, err = uuid.Parse(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook subscription ID in database: %w", err)
+	}
+
+	delivery.EventType = models.ChangeType(eventType)
+	delivery.Payload = []byte(payload)
+	delivery.Status = models.WebhookDeliveryStatus(status)
+
+	// Parse timestamps
+	delivery.NextAttemptAt, err = parseTimestamp(nextAttemptAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next_attempt_at time in database: %w", err)
+	}
+
+	delivery.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at time in database: %w", err)
+	}
+
+	delivery.UpdatedAt, err = parseTimestamp(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid updated_at time in database: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// joinChangeTypes encodes change types for the webhook_subscriptions.event_types
+// column
+func joinChangeTypes(changeTypes []models.ChangeType) string {
+	parts := make([]string, len(changeTypes))
+	for i, changeType := range changeTypes {
+		parts[i] = string(changeType)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitChangeTypes decodes the webhook_subscriptions.event_types column
+func splitChangeTypes(value string) []models.ChangeType {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	changeTypes := make([]models.ChangeType, len(parts))
+	for i, part := range parts {
+		changeTypes[i] = models.ChangeType(part)
+	}
+	return changeTypes
+}
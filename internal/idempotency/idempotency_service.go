@@ -0,0 +1,166 @@
+// Package idempotency lets clients retry mutating requests safely. The first
+// response to a request made with an idempotency key is stored for the API
+// key the request was made with, and replayed to retries of the same request
+// until it expires; reusing a key for a different request is refused.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// beginAttempts bounds the attempts at claiming a key that other requests
+// keep releasing or replacing
+const beginAttempts = 3
+
+// claimTTL is how long a request holds the key it claimed before completing
+// it. Keys of requests that never complete, because the server died or the
+// response could not be stored, are taken over by retries once it has passed.
+const claimTTL = 2 * time.Minute
+
+// Service stores and replays the responses of requests made with
+// idempotency keys
+type Service struct {
+	repo  store.IdempotencyRepository
+	clock clock.Clock
+	// ttl is how long responses are replayed
+	ttl time.Duration
+}
+
+// NewService creates an idempotency service keeping responses for ttl and
+// telling the time by clk
+func NewService(repo store.IdempotencyRepository, clk clock.Clock, ttl time.Duration) *Service {
+	return &Service{
+		repo:  repo,
+		clock: clk,
+		ttl:   ttl,
+	}
+}
+
+// Fingerprint hashes the parts of a request that its retries must repeat
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		// Prefix parts with their length so that they cannot run into each
+		// other
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		h.Write(length[:])
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims a key of an API key for a request. It returns nil when the
+// request is new, in which case the caller handles it and then calls
+// Complete or Release; otherwise it returns the completed record of an
+// earlier request with the same fingerprint, whose response is to be
+// replayed. Keys still claimed by a request in progress fail with
+// models.ErrIdempotencyPending, and keys used for a request with another
+// fingerprint with models.ErrIdempotencyReused. A claim lapses after
+// claimTTL, so that a key whose request was never completed or released can
+// be claimed again.
+func (s *Service) Begin(apiKeyID uuid.UUID, key, fingerprint string) (*models.IdempotencyRecord, error) {
+	if err := models.ValidateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; attempt <= beginAttempts; attempt++ {
+		now := s.clock.Now()
+		err := s.repo.Create(&models.IdempotencyRecord{
+			APIKeyID:    apiKeyID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(claimTTL),
+		})
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, models.ErrIdempotencyExists) {
+			return nil, err
+		}
+
+		record, err := s.repo.Get(apiKeyID, key)
+		if errors.Is(err, models.ErrIdempotencyMissing) {
+			// Released since, so claim it again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record.ExpiresAt.Before(now) {
+			// Expired, or a claim that lapsed, but not purged yet. Purging
+			// rather than deleting the record leaves alone one that replaced
+			// it meanwhile.
+			if _, err := s.repo.PurgeExpired(now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case record.Fingerprint != fingerprint:
+			return nil, models.ErrIdempotencyReused
+		case !record.Completed:
+			return nil, models.ErrIdempotencyPending
+		default:
+			return record, nil
+		}
+	}
+
+	return nil, models.ErrIdempotencyPending
+}
+
+// Complete stores the response to the request that claimed a key, to be
+// replayed for the TTL of the service
+func (s *Service) Complete(apiKeyID uuid.UUID, key string, statusCode int, header map[string]string, body []byte) error {
+	return s.repo.Complete(&models.IdempotencyRecord{
+		APIKeyID:   apiKeyID,
+		Key:        key,
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       body,
+		ExpiresAt:  s.clock.Now().Add(s.ttl),
+	})
+}
+
+// Release gives up a key without storing a response, so that the request
+// can be retried, for instance after a server error
+func (s *Service) Release(apiKeyID uuid.UUID, key string) error {
+	err := s.repo.Delete(apiKeyID, key)
+	if errors.Is(err, models.ErrIdempotencyMissing) {
+		return nil
+	}
+	return err
+}
+
+// RunPurger removes expired records every interval until ctx is done
+func (s *Service) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if purged, err := s.repo.PurgeExpired(s.clock.Now()); err != nil {
+				log.Error().Err(err).Msg("Failed to purge expired idempotency keys")
+			} else if purged > 0 {
+				log.Debug().Int("count", purged).Msg("Purged expired idempotency keys")
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package idempotency_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/idempotency"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store/memory"
+)
+
+// ttl is how long the services under test replay responses
+const ttl = 24 * time.Hour
+
+// newTestService returns a service on a fresh memory store, telling the time
+// by a clock the test moves by setting it
+func newTestService() (*idempotency.Service, *clock.Fixed) {
+	clk := clock.Fixed(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	return idempotency.NewService(memory.New().Idempotency, &clk, ttl), &clk
+}
+
+// advance moves a clock forward by d
+func advance(clk *clock.Fixed, d time.Duration) {
+	*clk = clock.Fixed(clk.Now().Add(d))
+}
+
+// begin claims a key, failing the test unless Begin returns the wanted error
+func begin(t *testing.T, service *idempotency.Service, apiKeyID uuid.UUID, key, fingerprint string, want error) *models.IdempotencyRecord {
+	t.Helper()
+
+	record, err := service.Begin(apiKeyID, key, fingerprint)
+	if !errors.Is(err, want) {
+		t.Fatalf("Begin(%q, %q) returned error %v, want %v", key, fingerprint, err, want)
+	}
+	return record
+}
+
+func TestBeginReplaysCompletedRequests(t *testing.T) {
+	service, clk := newTestService()
+	apiKeyID := uuid.New()
+
+	if record := begin(t, service, apiKeyID, "key-1", "a", nil); record != nil {
+		t.Fatalf("Begin of a new key returned %+v, want nil", record)
+	}
+	header := map[string]string{"Content-Type": "application/json"}
+	if err := service.Complete(apiKeyID, "key-1", 201, header, []byte(`{"id":"1"}`)); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	// Retries get the stored response until it expires
+	advance(clk, ttl-time.Minute)
+	record := begin(t, service, apiKeyID, "key-1", "a", nil)
+	if record == nil || !record.Completed || record.StatusCode != 201 || string(record.Body) != `{"id":"1"}` ||
+		record.Header["Content-Type"] != "application/json" {
+		t.Fatalf("Begin of a completed key returned %+v, want the stored response", record)
+	}
+
+	// Other requests with the key are refused
+	begin(t, service, apiKeyID, "key-1", "b", models.ErrIdempotencyReused)
+
+	// Keys are scoped to their API key
+	if record := begin(t, service, uuid.New(), "key-1", "b", nil); record != nil {
+		t.Fatalf("Begin for another API key returned %+v, want nil", record)
+	}
+
+	// Expired responses are forgotten
+	advance(clk, 2*time.Minute)
+	if record := begin(t, service, apiKeyID, "key-1", "b", nil); record != nil {
+		t.Fatalf("Begin of an expired key returned %+v, want nil", record)
+	}
+}
+
+func TestBeginRefusesPendingClaims(t *testing.T) {
+	service, clk := newTestService()
+	apiKeyID := uuid.New()
+
+	begin(t, service, apiKeyID, "key-1", "a", nil)
+	begin(t, service, apiKeyID, "key-1", "a", models.ErrIdempotencyPending)
+	begin(t, service, apiKeyID, "key-1", "b", models.ErrIdempotencyReused)
+
+	// Released keys can be claimed again
+	if err := service.Release(apiKeyID, "key-1"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := service.Release(apiKeyID, "key-1"); err != nil {
+		t.Fatalf("Release of a released key failed: %v", err)
+	}
+	begin(t, service, apiKeyID, "key-1", "a", nil)
+
+	// Claims that are never completed lapse long before responses expire
+	advance(clk, time.Hour)
+	if record := begin(t, service, apiKeyID, "key-1", "a", nil); record != nil {
+		t.Fatalf("Begin of a lapsed claim returned %+v, want nil", record)
+	}
+	begin(t, service, apiKeyID, "key-1", "a", models.ErrIdempotencyPending)
+
+	// Completing the claim extends it to the TTL of responses
+	if err := service.Complete(apiKeyID, "key-1", 200, nil, nil); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	advance(clk, time.Hour)
+	if record := begin(t, service, apiKeyID, "key-1", "a", nil); record == nil || record.StatusCode != 200 {
+		t.Fatalf("Begin of a completed key returned %+v, want the stored response", record)
+	}
+}
+
+func TestBeginValidatesKeys(t *testing.T) {
+	service, _ := newTestService()
+
+	for _, key := range []string{"", "with space", "tab\tkey", "café", string(make([]byte, models.MaxIdempotencyKeyLength+1))} {
+		begin(t, service, uuid.New(), key, "a", models.ErrInvalidIdempotency)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if idempotency.Fingerprint([]byte("ab"), []byte("c")) == idempotency.Fingerprint([]byte("a"), []byte("bc")) {
+		t.Error("Fingerprint ran parts into each other")
+	}
+	if idempotency.Fingerprint([]byte("a")) != idempotency.Fingerprint([]byte("a")) {
+		t.Error("Fingerprint is not deterministic")
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Audit target types
+const (
+	AuditTargetEvent  = "event"
+	AuditTargetUser   = "user"
+	AuditTargetAPIKey = "api_key"
+)
+
+// Audit transports
+const (
+	TransportHTTP   = "http"
+	TransportGRPC   = "grpc"
+	TransportSystem = "system"
+)
+
+// AuditEntry records a single mutating operation. Before and After hold the
+// fields that changed, or the full object for creations and deletions.
+type AuditEntry struct {
+	ID            uuid.UUID       `json:"id"`
+	ActorUserID   *uuid.UUID      `json:"actor_user_id,omitempty"`
+	ActorAPIKeyID *uuid.UUID      `json:"actor_api_key_id,omitempty"`
+	Action        string          `json:"action"`
+	TargetType    string          `json:"target_type"`
+	TargetID      string          `json:"target_id"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	RequestID     string          `json:"request_id,omitempty"`
+	Transport     string          `json:"transport"`
+	CreatedAt     time.Time       `json:"created_at"`
+
+	// Seq orders entries and backs pagination cursors
+	Seq int64 `json:"-"`
+}
+
+// AuditFilter narrows an audit log query. Zero values match everything.
+type AuditFilter struct {
+	ActorUserID *uuid.UUID
+	TargetType  string
+	TargetID    string
+	Action      string
+	Since       time.Time
+	Until       time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ChangeType describes what happened to an event in a change notification
+type ChangeType string
+
+const (
+	// ChangeSnapshot carries the current state of an event when a watch starts
+	ChangeSnapshot ChangeType = "snapshot"
+	// ChangeSnapshotComplete marks the end of the initial snapshot
+	ChangeSnapshotComplete ChangeType = "snapshot_complete"
+	// ChangeCreated is sent when an event is created
+	ChangeCreated ChangeType = "created"
+	// ChangeUpdated is sent when an event is edited or changes status manually
+	ChangeUpdated ChangeType = "updated"
+	// ChangeDeleted is sent when an event is deleted
+	ChangeDeleted ChangeType = "deleted"
+	// ChangeStarted is sent when a scheduled event goes live
+	ChangeStarted ChangeType = "started"
+	// ChangeEnded is sent when a live event passes its final end time
+	ChangeEnded ChangeType = "ended"
+)
+
+// EventChange is a notification about an event. Event holds the state after
+// the change, or the last known state for deletions.
+type EventChange struct {
+	Seq     int64      `json:"sequence"`
+	Type    ChangeType `json:"type"`
+	EventID string     `json:"event_id,omitempty"`
+	Event   *LiveEvent `json:"event,omitempty"`
+	Time    time.Time  `json:"time"`
+}
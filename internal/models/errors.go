@@ -1,15 +1,53 @@
 package models
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/tombombadilom/liveops/internal/targeting"
+)
 
 // Common errors for models
 var (
 	ErrEmptyTitle         = errors.New("title cannot be empty")
 	ErrInvalidTimeRange   = errors.New("start time must be before end time")
 	ErrInvalidRewardsJSON = errors.New("rewards must be valid JSON")
+	ErrInvalidRewards     = errors.New("invalid rewards")
+	ErrUnknownReward      = errors.New("reward not in catalog")
+	ErrRewardExists       = errors.New("reward already in catalog")
+	ErrInvalidRecurrence  = errors.New("invalid recurrence rule")
+	ErrInvalidTargeting   = targeting.ErrInvalidExpression
+	ErrInvalidExternalID  = errors.New("invalid external ID")
+	ErrExternalIDExists   = errors.New("external ID already in use")
 	ErrEventNotFound      = errors.New("event not found")
+	ErrRevisionNotFound   = errors.New("event revision not found")
+	ErrVersionConflict    = errors.New("event version does not match")
+	ErrInvalidTransition  = errors.New("invalid event status transition")
+	ErrInvalidSort        = errors.New("invalid sort order")
+	ErrInvalidPageToken   = errors.New("invalid page token")
 	ErrInvalidID          = errors.New("invalid ID format")
 	ErrInvalidAPIKey      = errors.New("invalid API key")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrAPIKeyExists       = errors.New("API key already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUsernameExists     = errors.New("username already exists")
+	ErrEmptyUsername      = errors.New("username cannot be empty")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrLastAdmin          = errors.New("cannot remove the last admin")
+	ErrInvalidPlayerToken = errors.New("invalid player token")
+	ErrPlayerTokenExpired = errors.New("player token expired")
+	ErrInvalidScope       = errors.New("invalid API key scope")
+	ErrInvalidRateLimit   = errors.New("invalid rate limit override")
+	ErrRateLimitNotFound  = errors.New("rate limit override not found")
+	ErrInvalidWebhook     = errors.New("invalid webhook subscription")
+	ErrWebhookNotFound    = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrOutboxNotFound     = errors.New("outbox entry not found")
+	ErrOutboxNotFailed    = errors.New("outbox entry has not failed")
+	ErrInvalidIdempotency = errors.New("invalid idempotency key")
+	ErrIdempotencyMissing = errors.New("idempotency key not found")
+	ErrIdempotencyExists  = errors.New("idempotency key already exists")
+	ErrIdempotencyReused  = errors.New("idempotency key already used for a different request")
+	ErrIdempotencyPending = errors.New("request with this idempotency key still in progress")
 	ErrUnauthorized       = errors.New("unauthorized access")
 	ErrForbidden          = errors.New("forbidden action")
 )
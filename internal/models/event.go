@@ -2,45 +2,168 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/targeting"
 )
 
 // LiveEvent represents a live event in the system
 type LiveEvent struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	Rewards     string    `json:"rewards"` // JSON string
+	ID                uuid.UUID   `json:"id"`
+	ExternalID        string      `json:"external_id,omitempty"` // caller-assigned unique ID, for upserts
+	Title             string      `json:"title"`
+	Description       string      `json:"description"`
+	StartTime         time.Time   `json:"start_time"`
+	EndTime           time.Time   `json:"end_time"`
+	Rewards           string      `json:"rewards"`                      // JSON string
+	StructuredRewards []Reward    `json:"structured_rewards,omitempty"` // catalog-validated rewards
+	Recurrence        string      `json:"recurrence,omitempty"`         // iCalendar RRULE/EXDATE lines
+	Targeting         string      `json:"targeting,omitempty"`          // player targeting expression
+	Status            EventStatus `json:"status"`
+	// Version is incremented by every change to the event, so that clients
+	// can detect concurrent modifications
+	Version int64 `json:"version"`
 }
 
-// NewLiveEvent creates a new LiveEvent with a generated UUID
-func NewLiveEvent(title, description string, startTime, endTime time.Time, rewards string) (*LiveEvent, error) {
+// EventFields holds the fields of an event set on creation and update.
+// Rewards may be given as raw JSON, as structured rewards, or both, in which
+// case the structured rewards take precedence.
+type EventFields struct {
+	ExternalID        string
+	Title             string
+	Description       string
+	StartTime         time.Time
+	EndTime           time.Time
+	Rewards           string
+	StructuredRewards []Reward
+	Recurrence        string
+	Targeting         string
+}
+
+// MaxExternalIDLength bounds the length of external IDs, in bytes
+const MaxExternalIDLength = 255
+
+// NewLiveEvent creates a new draft LiveEvent with a generated UUID
+func NewLiveEvent(fields EventFields) (*LiveEvent, error) {
 	// Validate rewards is valid JSON
-	if rewards != "" {
+	if fields.Rewards != "" {
 		var js json.RawMessage
-		if err := json.Unmarshal([]byte(rewards), &js); err != nil {
-			return nil, err
+		if err := json.Unmarshal([]byte(fields.Rewards), &js); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRewardsJSON, err)
+		}
+	}
+
+	event := &LiveEvent{
+		ID:      uuid.New(),
+		Status:  StatusDraft,
+		Version: 1,
+	}
+	event.SetFields(fields)
+
+	return event, nil
+}
+
+// SetFields replaces the editable fields of the event
+func (e *LiveEvent) SetFields(fields EventFields) {
+	e.ExternalID = fields.ExternalID
+	e.Title = fields.Title
+	e.Description = fields.Description
+	e.StartTime = fields.StartTime
+	e.EndTime = fields.EndTime
+	e.Rewards = fields.Rewards
+	e.StructuredRewards = fields.StructuredRewards
+	e.Recurrence = fields.Recurrence
+	e.Targeting = fields.Targeting
+}
+
+// Fields returns the editable fields of the event
+func (e *LiveEvent) Fields() EventFields {
+	return EventFields{
+		ExternalID:        e.ExternalID,
+		Title:             e.Title,
+		Description:       e.Description,
+		StartTime:         e.StartTime,
+		EndTime:           e.EndTime,
+		Rewards:           e.Rewards,
+		StructuredRewards: e.StructuredRewards,
+		Recurrence:        e.Recurrence,
+		Targeting:         e.Targeting,
+	}
+}
+
+// TargetsPlayer returns true if the player is in the audience of the event.
+// Events without targeting target every player.
+func (e *LiveEvent) TargetsPlayer(player *targeting.Player) bool {
+	return targeting.Match(e.Targeting, player)
+}
+
+// IsActive returns true if the event is active at the current time of the
+// clock
+func (e *LiveEvent) IsActive(c clock.Clock) bool {
+	return e.IsActiveAt(c.Now())
+}
+
+// IsActiveAt returns true if an occurrence of the event spans the given instant
+func (e *LiveEvent) IsActiveAt(t time.Time) bool {
+	if e.Recurrence == "" {
+		return t.After(e.StartTime) && t.Before(e.EndTime)
+	}
+
+	occurrences, err := e.Occurrences(t, t.Add(time.Nanosecond), 1)
+	if err != nil || len(occurrences) == 0 {
+		return false
+	}
+
+	return t.After(occurrences[0].StartTime) && t.Before(occurrences[0].EndTime)
+}
+
+// Occurrences returns the occurrences of the event overlapping [from, to),
+// limited to limit entries when limit is positive. A non-recurring event has
+// a single occurrence spanning its start and end times.
+func (e *LiveEvent) Occurrences(from, to time.Time, limit int) ([]Occurrence, error) {
+	if e.Recurrence == "" {
+		if e.StartTime.Before(to) && e.EndTime.After(from) {
+			return []Occurrence{{StartTime: e.StartTime, EndTime: e.EndTime}}, nil
 		}
+		return nil, nil
 	}
 
-	return &LiveEvent{
-		ID:          uuid.New(),
-		Title:       title,
-		Description: description,
-		StartTime:   startTime,
-		EndTime:     endTime,
-		Rewards:     rewards,
-	}, nil
+	rec, err := ParseRecurrence(e.Recurrence)
+	if err != nil {
+		return nil, ErrInvalidRecurrence
+	}
+
+	return rec.Occurrences(e.StartTime, e.EndTime.Sub(e.StartTime), from, to, limit), nil
 }
 
-// IsActive returns true if the event is currently active
-func (e *LiveEvent) IsActive() bool {
-	now := time.Now()
-	return now.After(e.StartTime) && now.Before(e.EndTime)
+// FinalEndTime returns the end of the last occurrence of the event. The
+// boolean is false for recurring events without COUNT or UNTIL, which never end.
+func (e *LiveEvent) FinalEndTime() (time.Time, bool) {
+	if e.Recurrence == "" {
+		return e.EndTime, true
+	}
+
+	rec, err := ParseRecurrence(e.Recurrence)
+	if err != nil || (rec.Count == 0 && rec.Until.IsZero()) {
+		return time.Time{}, false
+	}
+
+	duration := e.EndTime.Sub(e.StartTime)
+	to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if !rec.Until.IsZero() {
+		to = rec.Until.Add(time.Nanosecond)
+	}
+
+	occurrences := rec.Occurrences(e.StartTime, duration, e.StartTime, to, 0)
+	if len(occurrences) == 0 {
+		return e.EndTime, true
+	}
+
+	return occurrences[len(occurrences)-1].EndTime, true
 }
 
 // Validate checks if the event data is valid
@@ -48,6 +171,12 @@ func (e *LiveEvent) Validate() error {
 	if e.Title == "" {
 		return ErrEmptyTitle
 	}
+	if len(e.ExternalID) > MaxExternalIDLength {
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidExternalID, MaxExternalIDLength)
+	}
+	if strings.TrimSpace(e.ExternalID) != e.ExternalID {
+		return fmt.Errorf("%w: surrounded by spaces", ErrInvalidExternalID)
+	}
 	if e.StartTime.After(e.EndTime) {
 		return ErrInvalidTimeRange
 	}
@@ -57,5 +186,13 @@ func (e *LiveEvent) Validate() error {
 			return ErrInvalidRewardsJSON
 		}
 	}
+	if e.Recurrence != "" {
+		if _, err := ParseRecurrence(e.Recurrence); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+		}
+	}
+	if err := targeting.Validate(e.Targeting); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/targeting"
+)
+
+// EventSort orders event listings. A leading "-" sorts in descending order.
+type EventSort string
+
+const (
+	SortStartTimeAsc  EventSort = "start_time"
+	SortStartTimeDesc EventSort = "-start_time"
+	SortEndTimeAsc    EventSort = "end_time"
+	SortEndTimeDesc   EventSort = "-end_time"
+	SortTitleAsc      EventSort = "title"
+	SortTitleDesc     EventSort = "-title"
+)
+
+// DefaultEventSort is used when no sort order is requested
+const DefaultEventSort = SortStartTimeAsc
+
+// IsValid checks if the sort order is known
+func (s EventSort) IsValid() bool {
+	switch s {
+	case SortStartTimeAsc, SortStartTimeDesc, SortEndTimeAsc, SortEndTimeDesc, SortTitleAsc, SortTitleDesc:
+		return true
+	default:
+		return false
+	}
+}
+
+// Field returns the event field the listing is sorted by
+func (s EventSort) Field() string {
+	return strings.TrimPrefix(string(s), "-")
+}
+
+// Descending returns true for descending sort orders
+func (s EventSort) Descending() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// EventFilter narrows an event listing. Zero values match everything.
+type EventFilter struct {
+	// Statuses restricts events to any of the given statuses
+	Statuses []EventStatus
+	// StartsAfter keeps events starting at or after this time
+	StartsAfter time.Time
+	// EndsBefore keeps events whose last occurrence ends at or before this time
+	EndsBefore time.Time
+	// ActiveAt keeps events with an occurrence spanning this instant
+	ActiveAt time.Time
+	// Title keeps events whose title contains this text, ignoring the case
+	// of ASCII letters (see FoldTitle)
+	Title string
+	// Player keeps events whose targeting matches this player
+	Player *targeting.Player
+}
+
+// Matches checks the time and targeting filters against an event, including
+// the occurrences of recurring events, which cannot be expanded in SQL
+func (f *EventFilter) Matches(event *LiveEvent) bool {
+	if f.Player != nil && !event.TargetsPlayer(f.Player) {
+		return false
+	}
+
+	if !f.EndsBefore.IsZero() {
+		end, ok := event.FinalEndTime()
+		if !ok || end.After(f.EndsBefore) {
+			return false
+		}
+	}
+
+	if !f.ActiveAt.IsZero() && !event.IsActiveAt(f.ActiveAt) {
+		return false
+	}
+
+	return true
+}
+
+// FoldTitle lowers the case of the ASCII letters of a title or title filter.
+// Other letters keep their case: SQLite only folds ASCII, and title filters
+// must match the same events on every backend.
+func FoldTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, title)
+}
+
+// EventCursor is the position of an event in a sorted listing: the value of
+// the sort field as stored, and the event ID to break ties
+type EventCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
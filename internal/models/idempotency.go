@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxIdempotencyKeyLength bounds the length of idempotency keys
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord remembers a request made with an idempotency key and,
+// once it has completed, its response, so that retries of the request get
+// the same response instead of applying it again. Keys are scoped to the API
+// key the request was made with.
+type IdempotencyRecord struct {
+	APIKeyID uuid.UUID
+	Key      string
+	// Fingerprint hashes the method, target and payload of the request,
+	// which retries must repeat
+	Fingerprint string
+	// Completed is false while the first request is still being handled
+	Completed bool
+	// StatusCode is the HTTP status or gRPC code of the response
+	StatusCode int
+	// Header holds the response headers replayed along with the body
+	Header    map[string]string
+	Body      []byte
+	CreatedAt time.Time
+	// ExpiresAt ends the claim of a pending record, and the replay of a
+	// completed one
+	ExpiresAt time.Time
+}
+
+// ValidateIdempotencyKey checks that a key is short and printable ASCII
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("%w: must be 1 to %d characters", ErrInvalidIdempotency, MaxIdempotencyKeyLength)
+	}
+	if strings.IndexFunc(key, func(r rune) bool { return r < 0x21 || r > 0x7e }) >= 0 {
+		return fmt.Errorf("%w: must be printable ASCII without spaces", ErrInvalidIdempotency)
+	}
+	return nil
+}
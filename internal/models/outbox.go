@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditTargetOutbox is the audit target type for outbox entries
+const AuditTargetOutbox = "outbox"
+
+// OutboxStatus is the state of an outbox entry
+type OutboxStatus string
+
+const (
+	// OutboxPending entries are waiting to be sent to the sinks
+	OutboxPending OutboxStatus = "pending"
+	// OutboxDelivered entries were accepted by every sink
+	OutboxDelivered OutboxStatus = "delivered"
+	// OutboxFailed entries failed every attempt and are no longer retried
+	OutboxFailed OutboxStatus = "failed"
+)
+
+// IsValid checks if the status is known
+func (s OutboxStatus) IsValid() bool {
+	switch s {
+	case OutboxPending, OutboxDelivered, OutboxFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// OutboxEntry is an event change recorded in the same transaction as the
+// change itself, waiting to be sent to the outbox sinks. Entries of an event
+// are sent in the order of their sequence numbers.
+type OutboxEntry struct {
+	Seq     int64      `json:"seq"`
+	Type    ChangeType `json:"type"`
+	EventID uuid.UUID  `json:"event_id"`
+	// Event holds the state after the change, or the last known state for
+	// deletions
+	Event  *LiveEvent   `json:"event"`
+	Status OutboxStatus `json:"status"`
+	// Attempts counts the failed attempts at sending the entry
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewOutboxEntry creates a pending entry for a change to an event, due
+// immediately
+func NewOutboxEntry(changeType ChangeType, event *LiveEvent, now time.Time) *OutboxEntry {
+	eventCopy := *event
+	return &OutboxEntry{
+		Type:          changeType,
+		EventID:       event.ID,
+		Event:         &eventCopy,
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// OutboxFilter narrows an outbox query. Zero values match everything.
+type OutboxFilter struct {
+	Status  OutboxStatus
+	EventID *uuid.UUID
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PublicEvent is the view of an event served to players. It leaves out the
+// internal description and targeting rules.
+type PublicEvent struct {
+	ID                string      `json:"id"`
+	Title             string      `json:"title"`
+	StartTime         time.Time   `json:"start_time"`
+	EndTime           time.Time   `json:"end_time"`
+	Rewards           string      `json:"rewards,omitempty"`
+	StructuredRewards []Reward    `json:"structured_rewards,omitempty"`
+	Recurrence        string      `json:"recurrence,omitempty"`
+	Status            EventStatus `json:"status"`
+}
+
+// Public returns the player view of the event
+func (e *LiveEvent) Public() *PublicEvent {
+	return &PublicEvent{
+		ID:                e.ID.String(),
+		Title:             e.Title,
+		StartTime:         e.StartTime,
+		EndTime:           e.EndTime,
+		Rewards:           e.Rewards,
+		StructuredRewards: e.StructuredRewards,
+		Recurrence:        e.Recurrence,
+		Status:            e.Status,
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateLimitSubject identifies what a rate limit override applies to
+type RateLimitSubject string
+
+const (
+	// RateLimitSubjectRole applies an override to every key of users with a role
+	RateLimitSubjectRole RateLimitSubject = "role"
+	// RateLimitSubjectAPIKey applies an override to a single API key
+	RateLimitSubjectAPIKey RateLimitSubject = "api_key"
+)
+
+// AuditTargetRateLimit is the audit target type for rate limit overrides
+const AuditTargetRateLimit = "rate_limit"
+
+// RateLimitOverride replaces the default requests-per-minute budget for a
+// role or an API key. Key overrides take precedence over role overrides.
+type RateLimitOverride struct {
+	SubjectType    RateLimitSubject `json:"subject_type"`
+	Subject        string           `json:"subject"`
+	RequestsPerMin int              `json:"requests_per_min"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// Validate checks that the override targets a known role or a key ID and
+// sets a positive budget
+func (o *RateLimitOverride) Validate() error {
+	switch o.SubjectType {
+	case RateLimitSubjectRole:
+		switch Role(o.Subject) {
+		case RoleAdmin, RoleEditor, RoleViewer:
+		default:
+			return fmt.Errorf("%w: unknown role %q", ErrInvalidRateLimit, o.Subject)
+		}
+	case RateLimitSubjectAPIKey:
+		if _, err := uuid.Parse(o.Subject); err != nil {
+			return fmt.Errorf("%w: invalid API key ID %q", ErrInvalidRateLimit, o.Subject)
+		}
+	default:
+		return fmt.Errorf("%w: unknown subject type %q", ErrInvalidRateLimit, o.SubjectType)
+	}
+
+	if o.RequestsPerMin <= 0 {
+		return fmt.Errorf("%w: requests per minute must be positive", ErrInvalidRateLimit)
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base interval of a recurrence rule
+type Frequency string
+
+const (
+	// FrequencyDaily repeats the event every day
+	FrequencyDaily Frequency = "DAILY"
+	// FrequencyWeekly repeats the event every week
+	FrequencyWeekly Frequency = "WEEKLY"
+	// FrequencyMonthly repeats the event every month
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// maxRecurrenceIterations bounds the number of candidate dates examined while
+// expanding a rule, so that pathological rules cannot loop forever
+const maxRecurrenceIterations = 100000
+
+// icalTimeFormat is the UTC date-time format used by iCalendar (RFC 5545)
+const icalTimeFormat = "20060102T150405Z"
+
+// WeekdayNum is a BYDAY entry, optionally prefixed with an ordinal
+// (e.g. "SA", "1MO", "-1SU")
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Recurrence is a parsed subset of an iCalendar recurrence rule
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+	ExDates  []time.Time
+}
+
+// Occurrence is a single concrete instance of a (possibly recurring) event
+type Occurrence struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRecurrence parses a recurrence definition made of RFC 5545 content
+// lines. The RRULE line is required and supports FREQ (DAILY, WEEKLY, MONTHLY),
+// INTERVAL, BYDAY, COUNT and UNTIL. An optional EXDATE line lists excluded
+// occurrence start times. A bare "FREQ=..." value is accepted as an RRULE.
+func ParseRecurrence(value string) (*Recurrence, error) {
+	var rec *Recurrence
+	var exDates []time.Time
+
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, body, found := strings.Cut(line, ":")
+		if !found {
+			name, body = "RRULE", line
+		}
+		// Drop parameters such as EXDATE;VALUE=DATE-TIME
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch name {
+		case "RRULE":
+			if rec != nil {
+				return nil, errors.New("only one RRULE is supported")
+			}
+			r, err := parseRRule(body)
+			if err != nil {
+				return nil, err
+			}
+			rec = r
+		case "EXDATE":
+			for _, v := range strings.Split(body, ",") {
+				t, err := parseICalTime(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid EXDATE %q: %w", v, err)
+				}
+				exDates = append(exDates, t)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence property %q", name)
+		}
+	}
+
+	if rec == nil {
+		return nil, errors.New("RRULE is required")
+	}
+	rec.ExDates = exDates
+
+	return rec, nil
+}
+
+// parseRRule parses the value of an RRULE property
+func parseRRule(value string) (*Recurrence, error) {
+	rec := &Recurrence{Interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, val, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch f := Frequency(strings.ToUpper(val)); f {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+				rec.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rec.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rec.Count = n
+		case "UNTIL":
+			t, err := parseICalTime(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q: %w", val, err)
+			}
+			rec.Until = t
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				wd, err := parseWeekdayNum(d)
+				if err != nil {
+					return nil, err
+				}
+				rec.ByDay = append(rec.ByDay, wd)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rec.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rec.Count > 0 && !rec.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL cannot both be set")
+	}
+	for _, wd := range rec.ByDay {
+		if wd.Ordinal != 0 && rec.Freq != FrequencyMonthly {
+			return nil, errors.New("BYDAY ordinals are only supported with FREQ=MONTHLY")
+		}
+	}
+
+	return rec, nil
+}
+
+// parseWeekdayNum parses a single BYDAY entry
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	wd, ok := weekdayCodes[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	var ordinal int
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		ordinal = n
+	}
+
+	return WeekdayNum{Ordinal: ordinal, Weekday: wd}, nil
+}
+
+// parseICalTime parses an iCalendar UTC date-time, also accepting RFC 3339
+func parseICalTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(icalTimeFormat, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Occurrences returns the occurrences of a rule anchored at start with the
+// given duration whose time span overlaps [from, to). At most limit
+// occurrences are returned when limit is positive.
+func (r *Recurrence) Occurrences(start time.Time, duration time.Duration, from, to time.Time, limit int) []Occurrence {
+	var occurrences []Occurrence
+	var emitted int
+
+	r.iterate(start, func(t time.Time) bool {
+		emitted++
+		if r.Count > 0 && emitted > r.Count {
+			return false
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		if !t.Before(to) {
+			return false
+		}
+		if r.isExcluded(t) {
+			return true
+		}
+		if end := t.Add(duration); end.After(from) {
+			occurrences = append(occurrences, Occurrence{StartTime: t, EndTime: end})
+			if limit > 0 && len(occurrences) >= limit {
+				return false
+			}
+		}
+		return true
+	})
+
+	return occurrences
+}
+
+// isExcluded reports whether t is listed in EXDATE
+func (r *Recurrence) isExcluded(t time.Time) bool {
+	for _, ex := range r.ExDates {
+		if ex.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// iterate calls fn with each candidate occurrence start in chronological
+// order, beginning with start itself, until fn returns false
+func (r *Recurrence) iterate(start time.Time, fn func(time.Time) bool) {
+	hour, min, sec := start.Clock()
+	nsec := start.Nanosecond()
+	loc := start.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, nsec, loc)
+	}
+
+	for i := 0; i < maxRecurrenceIterations; i++ {
+		var candidates []time.Time
+
+		switch r.Freq {
+		case FrequencyDaily:
+			day := start.AddDate(0, 0, i*r.Interval)
+			if len(r.ByDay) == 0 || r.matchesWeekday(day.Weekday()) {
+				candidates = append(candidates, day)
+			}
+		case FrequencyWeekly:
+			// Weeks start on Monday, as in the RFC 5545 default WKST
+			offset := (int(start.Weekday()) + 6) % 7
+			monday := start.AddDate(0, 0, -offset+i*7*r.Interval)
+			if len(r.ByDay) == 0 {
+				candidates = append(candidates, monday.AddDate(0, 0, offset))
+			} else {
+				for d := 0; d < 7; d++ {
+					day := monday.AddDate(0, 0, d)
+					if r.matchesWeekday(day.Weekday()) {
+						candidates = append(candidates, at(day.Year(), day.Month(), day.Day()))
+					}
+				}
+			}
+		case FrequencyMonthly:
+			first := time.Date(start.Year(), start.Month()+time.Month(i*r.Interval), 1, 0, 0, 0, 0, loc)
+			if len(r.ByDay) == 0 {
+				// Months without the anchor day are skipped, as per RFC 5545
+				if day := at(first.Year(), first.Month(), start.Day()); day.Month() == first.Month() {
+					candidates = append(candidates, day)
+				}
+			} else {
+				candidates = r.monthlyByDay(first, at)
+			}
+		default:
+			return
+		}
+
+		for _, c := range candidates {
+			if c.Before(start) {
+				continue
+			}
+			if !fn(c) {
+				return
+			}
+		}
+	}
+}
+
+// matchesWeekday reports whether wd appears in BYDAY
+func (r *Recurrence) matchesWeekday(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// monthlyByDay expands BYDAY entries within the month starting at first
+func (r *Recurrence) monthlyByDay(first time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	seen := make(map[int]bool)
+	var days []int
+
+	for _, wd := range r.ByDay {
+		var matches []int
+		for d := 1; d <= daysInMonth; d++ {
+			if first.AddDate(0, 0, d-1).Weekday() == wd.Weekday {
+				matches = append(matches, d)
+			}
+		}
+
+		switch {
+		case wd.Ordinal == 0:
+			// keep every matching weekday
+		case wd.Ordinal > 0 && wd.Ordinal <= len(matches):
+			matches = matches[wd.Ordinal-1 : wd.Ordinal]
+		case wd.Ordinal < 0 && -wd.Ordinal <= len(matches):
+			matches = matches[len(matches)+wd.Ordinal : len(matches)+wd.Ordinal+1]
+		default:
+			matches = nil
+		}
+
+		for _, d := range matches {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+		}
+	}
+
+	sort.Ints(days)
+
+	candidates := make([]time.Time, len(days))
+	for i, d := range days {
+		candidates[i] = at(first.Year(), first.Month(), d)
+	}
+
+	return candidates
+}
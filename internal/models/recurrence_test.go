@@ -0,0 +1,147 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// monday is the start of the events under test, a Monday
+var monday = time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+
+// day returns 10:00 UTC on a day of 2025
+func day(month time.Month, d int) time.Time {
+	return time.Date(2025, month, d, 10, 0, 0, 0, time.UTC)
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []time.Time
+	}{
+		{"bare rule", "FREQ=DAILY;COUNT=2", monday, []time.Time{day(1, 6), day(1, 7)}},
+		{"daily interval", "RRULE:FREQ=DAILY;INTERVAL=2;COUNT=3", monday, []time.Time{day(1, 6), day(1, 8), day(1, 10)}},
+		{"until is inclusive", "RRULE:FREQ=DAILY;UNTIL=20250108T100000Z", monday, []time.Time{day(1, 6), day(1, 7), day(1, 8)}},
+		{"until as a date", "RRULE:FREQ=DAILY;UNTIL=20250108", monday, []time.Time{day(1, 6), day(1, 7)}},
+		{"until in RFC 3339", "RRULE:FREQ=WEEKLY;UNTIL=2025-01-20T10:00:00Z", monday, []time.Time{day(1, 6), day(1, 13), day(1, 20)}},
+		{"daily weekdays", "RRULE:FREQ=DAILY;BYDAY=SA,SU;COUNT=3", monday, []time.Time{day(1, 11), day(1, 12), day(1, 18)}},
+		{"weekly days", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4", monday, []time.Time{day(1, 6), day(1, 8), day(1, 10), day(1, 13)}},
+		{"weekly days skip the past", "RRULE:FREQ=WEEKLY;BYDAY=MO,SA;COUNT=3", day(1, 8), []time.Time{day(1, 11), day(1, 13), day(1, 18)}},
+		{"weekly interval", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=2", monday, []time.Time{day(1, 7), day(1, 21)}},
+		{"monthly day", "RRULE:FREQ=MONTHLY;COUNT=3", day(1, 31), []time.Time{day(1, 31), day(3, 31), day(5, 31)}},
+		{"first weekday", "RRULE:FREQ=MONTHLY;BYDAY=1MO;COUNT=3", monday, []time.Time{day(1, 6), day(2, 3), day(3, 3)}},
+		{"last weekday", "RRULE:FREQ=MONTHLY;BYDAY=-1SU;COUNT=2", monday, []time.Time{day(1, 26), day(2, 23)}},
+		{"fifth weekday", "RRULE:FREQ=MONTHLY;BYDAY=5MO;COUNT=2", monday, []time.Time{day(3, 31), day(6, 30)}},
+		{"ordinals combined", "RRULE:FREQ=MONTHLY;BYDAY=2TU,-1FR;COUNT=4", monday, []time.Time{day(1, 14), day(1, 31), day(2, 11), day(2, 28)}},
+		{"lowercase", "rrule:freq=monthly;byday=-2we;count=1", monday, []time.Time{day(1, 22)}},
+		{"excluded dates count", "RRULE:FREQ=DAILY;COUNT=3\nEXDATE:20250107T100000Z", monday, []time.Time{day(1, 6), day(1, 8)}},
+		{"excluded date lists", "RRULE:FREQ=DAILY;COUNT=4\r\nEXDATE;VALUE=DATE-TIME:20250106T100000Z,20250108T100000Z", monday, []time.Time{day(1, 7), day(1, 9)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec, err := models.ParseRecurrence(test.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) failed: %v", test.rule, err)
+			}
+
+			occurrences := rec.Occurrences(test.start, time.Hour, test.start, test.start.AddDate(1, 0, 0), 0)
+			var got []time.Time
+			for _, o := range occurrences {
+				got = append(got, o.StartTime)
+				if !o.EndTime.Equal(o.StartTime.Add(time.Hour)) {
+					t.Errorf("occurrence at %s ends at %s", o.StartTime, o.EndTime)
+				}
+			}
+			if !equalTimes(got, test.want) {
+				t.Errorf("got occurrences %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceOccurrencesWindow(t *testing.T) {
+	rec, err := models.ParseRecurrence("RRULE:FREQ=DAILY")
+	if err != nil {
+		t.Fatalf("ParseRecurrence failed: %v", err)
+	}
+
+	// Occurrences overlapping the window are included, even if they started
+	// before it
+	got := rec.Occurrences(monday, 2*time.Hour, day(1, 8).Add(time.Hour), day(1, 10), 0)
+	if want := []time.Time{day(1, 8), day(1, 9)}; !equalTimes(startTimes(got), want) {
+		t.Errorf("got occurrences %v, want %v", startTimes(got), want)
+	}
+
+	// The limit stops the expansion
+	got = rec.Occurrences(monday, time.Hour, monday, day(12, 31), 2)
+	if want := []time.Time{day(1, 6), day(1, 7)}; !equalTimes(startTimes(got), want) {
+		t.Errorf("got occurrences %v, want %v", startTimes(got), want)
+	}
+
+	// Endless rules stop after 100000 iterations
+	got = rec.Occurrences(monday, time.Hour, monday, monday.AddDate(1000, 0, 0), 0)
+	if len(got) != 100000 {
+		t.Errorf("got %d occurrences of an endless rule, want 100000", len(got))
+	}
+}
+
+func TestParseRecurrenceRejectsMalformedRules(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"EXDATE:20250107T100000Z",
+		"RRULE:",
+		"RRULE:FREQ=DAILY\nRRULE:FREQ=WEEKLY",
+		"RDATE:20250107T100000Z\nRRULE:FREQ=DAILY",
+		"RRULE:FREQ=DAILY\nEXDATE:tomorrow",
+		"FREQ=YEARLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;COUNT",
+		"FREQ=DAILY;BYMONTH=1",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=two",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250110T000000Z",
+		"FREQ=WEEKLY;BYDAY=",
+		"FREQ=WEEKLY;BYDAY=M",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=MO,,FR",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYDAY=-1SU",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=-6MO",
+		"FREQ=MONTHLY;BYDAY=+MO",
+		"FREQ=MONTHLY;BYDAY=1.5MO",
+	} {
+		if rec, err := models.ParseRecurrence(rule); err == nil {
+			t.Errorf("ParseRecurrence(%q) = %+v, want an error", rule, rec)
+		}
+	}
+}
+
+// startTimes returns the start times of occurrences
+func startTimes(occurrences []models.Occurrence) []time.Time {
+	var times []time.Time
+	for _, o := range occurrences {
+		times = append(times, o.StartTime)
+	}
+	return times
+}
+
+// equalTimes reports whether two lists hold the same instants
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision actions, naming the mutation that produced a revision
+const (
+	RevisionActionBaseline = "baseline"
+	RevisionActionCreate   = "create"
+	RevisionActionUpdate   = "update"
+	RevisionActionStatus   = "status"
+	RevisionActionRevert   = "revert"
+	RevisionActionDelete   = "delete"
+)
+
+// EventRevision is an immutable snapshot of an event taken after one of its
+// mutations. Revisions are numbered from 1 for every event and outlive it.
+// A baseline revision records the state of an event that predates revision
+// tracking, just before its first tracked mutation.
+type EventRevision struct {
+	EventID        uuid.UUID  `json:"event_id"`
+	Revision       int        `json:"revision"`
+	Action         string     `json:"action"`
+	Event          LiveEvent  `json:"event"`
+	AuthorUserID   *uuid.UUID `json:"author_user_id,omitempty"`
+	AuthorAPIKeyID *uuid.UUID `json:"author_api_key_id,omitempty"`
+	// RevertedFrom is the revision a revert restored
+	RevertedFrom int       `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FieldChange is a field whose value differs between two revisions, with
+// its JSON values in each. A field missing from a revision is null.
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// DiffEvents returns the fields that differ between two snapshots of an
+// event, ordered by name. Fields are compared on their JSON encoding.
+func DiffEvents(from, to *LiveEvent) ([]FieldChange, error) {
+	fromFields, err := eventFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := eventFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(fromFields)+len(toFields))
+	for name := range fromFields {
+		names = append(names, name)
+	}
+	for name := range toFields {
+		if _, ok := fromFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		fromValue, toValue := fromFields[name], toFields[name]
+		if bytes.Equal(fromValue, toValue) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, From: nullIfMissing(fromValue), To: nullIfMissing(toValue)})
+	}
+
+	return changes, nil
+}
+
+// eventFields returns the top-level JSON fields of an event
+func eventFields(event *LiveEvent) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// nullIfMissing returns a JSON null for a missing field
+func nullIfMissing(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
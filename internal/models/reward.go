@@ -0,0 +1,107 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RewardType distinguishes items from currencies in rewards and the catalog
+type RewardType string
+
+const (
+	// RewardTypeItem grants inventory items
+	RewardTypeItem RewardType = "item"
+	// RewardTypeCurrency grants an amount of a currency
+	RewardTypeCurrency RewardType = "currency"
+)
+
+// AuditTargetReward is the audit target type for reward catalog entries
+const AuditTargetReward = "reward"
+
+// IsValid checks if the reward type is known
+func (t RewardType) IsValid() bool {
+	return t == RewardTypeItem || t == RewardTypeCurrency
+}
+
+// Reward is a single reward granted by an event. Tiered events grant each
+// reward to players whose progress reaches its threshold.
+type Reward struct {
+	Type      RewardType `json:"type"`
+	ID        string     `json:"id"`
+	Quantity  int64      `json:"quantity"`
+	Tier      int32      `json:"tier,omitempty"`
+	Threshold int64      `json:"threshold,omitempty"`
+}
+
+// Validate checks the reward fields that do not depend on the catalog
+func (r *Reward) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("%w: reward ID is required", ErrInvalidRewards)
+	}
+	if r.Type != "" && !r.Type.IsValid() {
+		return fmt.Errorf("%w: unknown type %q for reward %q", ErrInvalidRewards, r.Type, r.ID)
+	}
+	if r.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity of reward %q must be positive", ErrInvalidRewards, r.ID)
+	}
+	if r.Tier < 0 || r.Threshold < 0 {
+		return fmt.Errorf("%w: tier and threshold of reward %q cannot be negative", ErrInvalidRewards, r.ID)
+	}
+	return nil
+}
+
+// ParseRewards decodes the raw rewards JSON of an event into structured
+// rewards. Two shapes are recognized: an array of Reward objects, and an
+// object mapping catalog IDs to quantities, such as {"gems": 100}, whose
+// reward types are left empty to be resolved from the catalog. Any other
+// valid JSON is a legacy free-form value, for which ok is false.
+func ParseRewards(raw string) (rewards []Reward, ok bool, err error) {
+	data := bytes.TrimSpace([]byte(raw))
+	if len(data) == 0 {
+		return nil, false, nil
+	}
+
+	switch data[0] {
+	case '[':
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&rewards); err != nil {
+			return nil, false, fmt.Errorf("%w: %v", ErrInvalidRewards, err)
+		}
+		return rewards, true, nil
+
+	case '{':
+		var quantities map[string]json.Number
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&quantities); err != nil {
+			// Not a quantity map; keep it as a legacy value
+			return nil, false, nil
+		}
+
+		for id, number := range quantities {
+			quantity, err := number.Int64()
+			if err != nil {
+				return nil, false, fmt.Errorf("%w: quantity of reward %q must be an integer", ErrInvalidRewards, id)
+			}
+			rewards = append(rewards, Reward{ID: id, Quantity: quantity})
+		}
+		sort.Slice(rewards, func(i, j int) bool {
+			return rewards[i].ID < rewards[j].ID
+		})
+		return rewards, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// RewardCatalogItem is an item or currency that events may grant
+type RewardCatalogItem struct {
+	ID        string     `json:"id"`
+	Type      RewardType `json:"type"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+}
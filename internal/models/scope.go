@@ -0,0 +1,96 @@
+package models
+
+import "fmt"
+
+// Scope restricts what an API key may do on behalf of its owner
+type Scope string
+
+const (
+	// ScopeEventsRead allows reading events
+	ScopeEventsRead Scope = "events:read"
+	// ScopeEventsWrite allows creating and updating events
+	ScopeEventsWrite Scope = "events:write"
+	// ScopeEventsDelete allows deleting events
+	ScopeEventsDelete Scope = "events:delete"
+	// ScopeAdminUsers allows managing users
+	ScopeAdminUsers Scope = "admin:users"
+	// ScopeAdminKeys allows managing API keys
+	ScopeAdminKeys Scope = "admin:keys"
+	// ScopeAdminAudit allows reading the audit log
+	ScopeAdminAudit Scope = "admin:audit"
+	// ScopeAdminRewards allows managing the reward catalog
+	ScopeAdminRewards Scope = "admin:rewards"
+	// ScopeAdminWebhooks allows managing webhook subscriptions and deliveries
+	ScopeAdminWebhooks Scope = "admin:webhooks"
+	// ScopeAdminOutbox allows inspecting and retrying outbox entries
+	ScopeAdminOutbox Scope = "admin:outbox"
+)
+
+// actionScopes maps permission actions to the scope a key needs for them
+var actionScopes = map[string]Scope{
+	"read":           ScopeEventsRead,
+	"preview":        ScopeEventsRead,
+	"create":         ScopeEventsWrite,
+	"update":         ScopeEventsWrite,
+	"delete":         ScopeEventsDelete,
+	"admin:users":    ScopeAdminUsers,
+	"admin:keys":     ScopeAdminKeys,
+	"admin:audit":    ScopeAdminAudit,
+	"admin:rewards":  ScopeAdminRewards,
+	"admin:webhooks": ScopeAdminWebhooks,
+	"admin:outbox":   ScopeAdminOutbox,
+}
+
+// scopeActions maps each scope to a representative action, used to check
+// that a role can make use of the scope
+var scopeActions = map[Scope]string{
+	ScopeEventsRead:    "read",
+	ScopeEventsWrite:   "create",
+	ScopeEventsDelete:  "delete",
+	ScopeAdminUsers:    "admin:users",
+	ScopeAdminKeys:     "admin:keys",
+	ScopeAdminAudit:    "admin:audit",
+	ScopeAdminRewards:  "admin:rewards",
+	ScopeAdminWebhooks: "admin:webhooks",
+	ScopeAdminOutbox:   "admin:outbox",
+}
+
+// IsValid checks if the scope is known
+func (s Scope) IsValid() bool {
+	_, ok := scopeActions[s]
+	return ok
+}
+
+// ValidateScopes checks that every scope is known and usable by the role
+func ValidateScopes(role Role, scopes []Scope) error {
+	for _, scope := range scopes {
+		action, ok := scopeActions[scope]
+		if !ok {
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidScope, scope)
+		}
+		if !HasPermission(role, action) {
+			return fmt.Errorf("%w: role %s cannot use scope %q", ErrInvalidScope, role, scope)
+		}
+	}
+	return nil
+}
+
+// Allows checks if the key's scopes permit the action. A key without scopes
+// carries the full permissions of its owner's role.
+func (k *APIKey) Allows(action string) bool {
+	if k == nil || len(k.Scopes) == 0 {
+		return true
+	}
+
+	required, ok := actionScopes[action]
+	if !ok {
+		return false
+	}
+
+	for _, scope := range k.Scopes {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package models
+
+// EventStatus represents the lifecycle state of an event
+type EventStatus string
+
+const (
+	// StatusDraft is being edited and is not visible to game clients
+	StatusDraft EventStatus = "draft"
+	// StatusScheduled is published and waiting for its start time
+	StatusScheduled EventStatus = "scheduled"
+	// StatusLive is published and has started
+	StatusLive EventStatus = "live"
+	// StatusEnded has passed its final end time
+	StatusEnded EventStatus = "ended"
+	// StatusArchived is kept for history only
+	StatusArchived EventStatus = "archived"
+	// StatusCancelled was withdrawn before it ended
+	StatusCancelled EventStatus = "cancelled"
+)
+
+// statusTransitions lists the states reachable from each state
+var statusTransitions = map[EventStatus][]EventStatus{
+	StatusDraft:     {StatusScheduled, StatusCancelled},
+	StatusScheduled: {StatusDraft, StatusLive, StatusCancelled},
+	StatusLive:      {StatusEnded, StatusCancelled},
+	StatusEnded:     {StatusArchived},
+	StatusCancelled: {StatusArchived},
+}
+
+// IsValid checks if the status is a known lifecycle state
+func (s EventStatus) IsValid() bool {
+	switch s {
+	case StatusDraft, StatusScheduled, StatusLive, StatusEnded, StatusArchived, StatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPublished returns true if events in this state are visible to game clients
+func (s EventStatus) IsPublished() bool {
+	return s == StatusScheduled || s == StatusLive
+}
+
+// CanTransition checks if an event may move from one state to another
+func CanTransition(from, to EventStatus) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +21,16 @@ const (
 	RoleViewer Role = "viewer"
 )
 
+// IsValid checks if the role is known
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleEditor, RoleViewer:
+		return true
+	default:
+		return false
+	}
+}
+
 // User represents a user in the system
 type User struct {
 	ID        uuid.UUID `json:"id"`
@@ -28,11 +39,19 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// APIKey represents an API key for authentication
+// APIKeyPrefix starts every generated API key
+const APIKeyPrefix = "lo_"
+
+// APIKey represents an API key for authentication. Only the hash of the key
+// is stored; the full key is set on Key right after generation and is never
+// available again.
 type APIKey struct {
 	ID        uuid.UUID `json:"id"`
 	UserID    uuid.UUID `json:"user_id"`
-	Key       string    `json:"key"`
+	Prefix    string    `json:"prefix"`
+	Key       string    `json:"key,omitempty"`
+	KeyHash   string    `json:"-"`
+	Scopes    []Scope   `json:"scopes,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 	LastUsed  time.Time `json:"last_used"`
@@ -48,16 +67,25 @@ func NewUser(username string, role Role) *User {
 	}
 }
 
-// GenerateAPIKey creates a new API key for a user
-func GenerateAPIKey(userID uuid.UUID, validDays int) (*APIKey, error) {
-	// Generate a random key
+// GenerateAPIKey creates a new API key for a user, optionally restricted to
+// the given scopes. The key has the form lo_<8 hex chars>_<secret>, where the
+// first part is its public prefix.
+func GenerateAPIKey(userID uuid.UUID, validDays int, scopes []Scope) (*APIKey, error) {
+	// Generate a random public identifier
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	// Generate a random secret
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
 	if err != nil {
 		return nil, err
 	}
 
-	key := base64.URLEncoding.EncodeToString(b)
+	prefix := APIKeyPrefix + hex.EncodeToString(id)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(b)
 
 	now := time.Now()
 	expiresAt := now.AddDate(0, 0, validDays)
@@ -65,13 +93,29 @@ func GenerateAPIKey(userID uuid.UUID, validDays int) (*APIKey, error) {
 	return &APIKey{
 		ID:        uuid.New(),
 		UserID:    userID,
+		Prefix:    prefix,
 		Key:       key,
+		Scopes:    scopes,
 		CreatedAt: now,
 		ExpiresAt: expiresAt,
 		LastUsed:  now,
 	}, nil
 }
 
+// KeyPrefix returns the public prefix of a full API key. Keys issued before
+// prefixes existed are identified by their first eight characters.
+func KeyPrefix(key string) string {
+	const legacyPrefixLength = 8
+
+	if len(key) > len(APIKeyPrefix)+8 && key[:len(APIKeyPrefix)] == APIKeyPrefix {
+		return key[:len(APIKeyPrefix)+8]
+	}
+	if len(key) > legacyPrefixLength {
+		return key[:legacyPrefixLength]
+	}
+	return ""
+}
+
 // IsExpired checks if the API key has expired
 func (k *APIKey) IsExpired() bool {
 	return time.Now().After(k.ExpiresAt)
@@ -94,6 +138,13 @@ func HasPermission(role Role, action string) bool {
 	case "delete":
 		// Only admin can delete
 		return role == RoleAdmin
+	case "preview":
+		// Only admin and editor can evaluate the schedule at another time
+		return role == RoleAdmin || role == RoleEditor
+	case "admin", "admin:users", "admin:keys", "admin:audit", "admin:rewards", "admin:webhooks", "admin:outbox":
+		// Only admin can manage users, API keys, the reward catalog, webhooks
+		// and the outbox or read the audit log
+		return role == RoleAdmin
 	default:
 		return false
 	}
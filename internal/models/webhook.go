@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit target types for webhooks
+const (
+	AuditTargetWebhook         = "webhook"
+	AuditTargetWebhookDelivery = "webhook_delivery"
+)
+
+// WebhookChangeTypes lists the event changes webhooks can subscribe to
+var WebhookChangeTypes = []ChangeType{ChangeCreated, ChangeUpdated, ChangeDeleted, ChangeStarted, ChangeEnded}
+
+// WebhookSubscription asks for event changes to be posted to a URL. The
+// secret signs every payload, so that receivers can check where it came from.
+type WebhookSubscription struct {
+	ID  uuid.UUID `json:"id"`
+	URL string    `json:"url"`
+	// EventTypes restricts the changes sent; empty sends every change
+	EventTypes []ChangeType `json:"event_types,omitempty"`
+	Secret     string       `json:"-"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// Validate checks the URL and event types of the subscription
+func (s *WebhookSubscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: URL must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+
+	for _, eventType := range s.EventTypes {
+		if !slices.Contains(WebhookChangeTypes, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	if s.Secret == "" {
+		return fmt.Errorf("%w: secret cannot be empty", ErrInvalidWebhook)
+	}
+
+	return nil
+}
+
+// Wants reports whether the subscription asks for changes of the given type
+func (s *WebhookSubscription) Wants(changeType ChangeType) bool {
+	return len(s.EventTypes) == 0 || slices.Contains(s.EventTypes, changeType)
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt
+	DeliveryPending WebhookDeliveryStatus = "pending"
+	// DeliveryDelivered deliveries were accepted by the receiver
+	DeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// DeliveryDead deliveries failed every attempt and are no longer retried
+	DeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// IsValid checks if the status is known
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return true
+	default:
+		return false
+	}
+}
+
+// WebhookDelivery is a change queued for posting to a subscription. The
+// payload is fixed when the change happens, so retries send the same body.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	EventType      ChangeType            `json:"event_type"`
+	EventID        string                `json:"event_id"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	// Attempts counts the failed attempts since the delivery was queued
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Seq orders deliveries and backs pagination cursors
+	Seq int64 `json:"-"`
+}
+
+// WebhookDeliveryFilter narrows a delivery query. Zero values match
+// everything.
+type WebhookDeliveryFilter struct {
+	SubscriptionID *uuid.UUID
+	Status         WebhookDeliveryStatus
+	EventID        string
+}
+
+// WebhookAttempt logs a single attempt at posting a delivery. StatusCode is
+// zero when no response was received.
+type WebhookAttempt struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookPayload is the JSON body posted to subscriptions
+type WebhookPayload struct {
+	ID         uuid.UUID  `json:"id"`
+	Type       ChangeType `json:"type"`
+	EventID    string     `json:"event_id"`
+	Event      *LiveEvent `json:"event"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
@@ -0,0 +1,288 @@
+// Package outbox sends the event changes recorded in the outbox to sinks.
+// Changes are written to the outbox in the same transaction as the changes
+// themselves, and a background dispatcher sends them at least once, in order
+// for every event, retrying failures with exponential backoff until they
+// succeed or run out of attempts.
+package outbox
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// Page size limits for ListEntries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+const (
+	// MaxAttempts is the number of failed attempts after which an entry has
+	// failed
+	MaxAttempts = 10
+	// initialBackoff is the wait after the first failed attempt; it doubles
+	// after every further failure
+	initialBackoff = 5 * time.Second
+	// maxBackoff caps the wait between attempts
+	maxBackoff = 10 * time.Minute
+	// claimLease is how long claimed entries are left alone by other
+	// dispatchers; a dispatcher that stops while sending them leaves them to
+	// be claimed again once it passes
+	claimLease = 2 * time.Minute
+	// dispatchBatchSize is the number of entries claimed at once
+	dispatchBatchSize = 100
+	// deliveredRetention is how long delivered entries are kept
+	deliveredRetention = 7 * 24 * time.Hour
+	// purgeInterval is how often delivered entries are purged
+	purgeInterval = time.Hour
+)
+
+// ErrInvalidPageToken is returned for malformed pagination cursors
+var ErrInvalidPageToken = models.ErrInvalidPageToken
+
+// Sink receives the changes recorded in the outbox. Entries may be sent more
+// than once, for instance when another sink fails, so sinks should tolerate
+// duplicates; Seq identifies an entry.
+type Sink interface {
+	// Name identifies the sink in errors and logs
+	Name() string
+	// Send hands over an entry, returning an error to have it sent again
+	// later
+	Send(ctx context.Context, entry *models.OutboxEntry) error
+}
+
+// Service dispatches outbox entries to sinks and lets admins inspect them
+type Service struct {
+	outboxRepo   store.OutboxRepository
+	sinks        []Sink
+	auditService *audit.AuditService
+	clock        clock.Clock
+
+	// wake prompts the dispatcher to look for due entries
+	wake chan struct{}
+}
+
+// NewService creates an outbox service sending entries to every sink in
+// turn and telling the time by clk
+func NewService(outboxRepo store.OutboxRepository, sinks []Sink, auditService *audit.AuditService, clk clock.Clock) *Service {
+	return &Service{
+		outboxRepo:   outboxRepo,
+		sinks:        sinks,
+		auditService: auditService,
+		clock:        clk,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Wake prompts the dispatcher to look for due entries, after changes have
+// been committed to the outbox
+func (s *Service) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ListEntries returns a page of entries matching the filter, newest first,
+// and the token for the next page (empty on the last page)
+func (s *Service) ListEntries(filter models.OutboxFilter, pageSize int, pageToken string) ([]*models.OutboxEntry, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var beforeSeq int64
+	if pageToken != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", ErrInvalidPageToken
+		}
+		beforeSeq, err = strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || beforeSeq <= 0 {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	// Fetch one extra entry to know whether another page exists
+	entries, err := s.outboxRepo.List(filter, beforeSeq, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list outbox entries: %w", err)
+	}
+
+	var nextPageToken string
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		last := entries[len(entries)-1].Seq
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(last, 10)))
+	}
+
+	return entries, nextPageToken, nil
+}
+
+// GetEntry retrieves an entry by its sequence number
+func (s *Service) GetEntry(seq int64) (*models.OutboxEntry, error) {
+	return s.outboxRepo.Get(seq)
+}
+
+// RetryEntry queues a failed entry for an immediate attempt with a fresh
+// retry budget. Later entries of its event may already have been sent.
+func (s *Service) RetryEntry(ctx context.Context, seq int64) (*models.OutboxEntry, error) {
+	entry, err := s.outboxRepo.Get(seq)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != models.OutboxFailed {
+		return nil, fmt.Errorf("%w: entry is %s", models.ErrOutboxNotFailed, entry.Status)
+	}
+
+	before := *entry
+	now := s.clock.Now()
+	entry.Status = models.OutboxPending
+	entry.Attempts = 0
+	entry.NextAttemptAt = now
+	entry.UpdatedAt = now
+
+	if err := s.outboxRepo.Update(entry); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetOutbox, strconv.FormatInt(seq, 10), &before, entry)
+	s.Wake()
+
+	return entry, nil
+}
+
+// RunDispatcher sends due entries until ctx is done. It wakes up when
+// changes are committed or retried, and at least every interval.
+func (s *Service) RunDispatcher(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	var lastPurge time.Time
+	for {
+		sent, err := s.DispatchDue(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to dispatch outbox entries")
+		} else if sent > 0 {
+			log.Debug().Int("count", sent).Msg("Dispatched outbox entries")
+		}
+
+		if now := s.clock.Now(); now.Sub(lastPurge) >= purgeInterval {
+			if purged, err := s.outboxRepo.PurgeDelivered(now.Add(-deliveredRetention)); err != nil {
+				log.Error().Err(err).Msg("Failed to purge delivered outbox entries")
+			} else if purged > 0 {
+				log.Info().Int("count", purged).Msg("Purged delivered outbox entries")
+			}
+			lastPurge = now
+		}
+
+		// Keep going while full batches are waiting
+		if err == nil && sent == dispatchBatchSize {
+			continue
+		}
+
+		timer.Reset(interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// DispatchDue claims a batch of due entries and sends each to every sink,
+// in sequence order. It returns the number of entries claimed.
+func (s *Service) DispatchDue(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	entries, err := s.outboxRepo.ClaimDue(now, now.Add(claimLease), dispatchBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox entries: %w", err)
+	}
+
+	// Once an entry fails, the later entries of its event wait for it
+	blocked := make(map[uuid.UUID]bool)
+	for _, entry := range entries {
+		if blocked[entry.EventID] || ctx.Err() != nil {
+			s.release(entry)
+			continue
+		}
+		if !s.send(ctx, entry) {
+			blocked[entry.EventID] = true
+		}
+	}
+
+	return len(entries), nil
+}
+
+// send hands an entry to every sink and records the outcome. It reports
+// whether every sink accepted the entry.
+func (s *Service) send(ctx context.Context, entry *models.OutboxEntry) bool {
+	logger := log.With().Int64("seq", entry.Seq).Str("event_id", entry.EventID.String()).Logger()
+
+	var sendErr error
+	for _, sink := range s.sinks {
+		if err := sink.Send(ctx, entry); err != nil {
+			sendErr = fmt.Errorf("%s: %w", sink.Name(), err)
+			break
+		}
+	}
+
+	now := s.clock.Now()
+	entry.UpdatedAt = now
+	if sendErr == nil {
+		entry.Status = models.OutboxDelivered
+		entry.LastError = ""
+	} else {
+		entry.Attempts++
+		entry.LastError = sendErr.Error()
+		if entry.Attempts >= MaxAttempts {
+			entry.Status = models.OutboxFailed
+			logger.Warn().Err(sendErr).Int("attempts", entry.Attempts).Msg("Outbox entry failed")
+		} else {
+			entry.NextAttemptAt = now.Add(Backoff(entry.Attempts))
+			logger.Debug().Err(sendErr).Int("attempts", entry.Attempts).Time("next_attempt_at", entry.NextAttemptAt).Msg("Outbox entry will be retried")
+		}
+	}
+
+	if err := s.outboxRepo.Update(entry); err != nil {
+		logger.Error().Err(err).Msg("Failed to update outbox entry")
+	}
+
+	return sendErr == nil
+}
+
+// release gives up the claim on an entry that was not sent, making it due
+// again
+func (s *Service) release(entry *models.OutboxEntry) {
+	entry.NextAttemptAt = s.clock.Now()
+	if err := s.outboxRepo.Update(entry); err != nil {
+		log.Error().Err(err).Int64("seq", entry.Seq).Msg("Failed to release outbox entry")
+	}
+}
+
+// Backoff returns the wait before the next attempt after the given number
+// of failed attempts
+func Backoff(attempts int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
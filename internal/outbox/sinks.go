@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/webhook"
+)
+
+// Message is the record of a change written by the file sink
+type Message struct {
+	Seq        int64             `json:"seq"`
+	Type       models.ChangeType `json:"type"`
+	EventID    uuid.UUID         `json:"event_id"`
+	Event      *models.LiveEvent `json:"event"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+// NewMessage returns the message recording an entry
+func NewMessage(entry *models.OutboxEntry) Message {
+	return Message{
+		Seq:        entry.Seq,
+		Type:       entry.Type,
+		EventID:    entry.EventID,
+		Event:      entry.Event,
+		OccurredAt: entry.CreatedAt,
+	}
+}
+
+// LogSink writes every change to the server log
+type LogSink struct{}
+
+// Name identifies the sink
+func (LogSink) Name() string {
+	return "log"
+}
+
+// Send logs the change
+func (LogSink) Send(ctx context.Context, entry *models.OutboxEntry) error {
+	log.Info().
+		Int64("seq", entry.Seq).
+		Str("type", string(entry.Type)).
+		Str("event_id", entry.EventID.String()).
+		Msg("Event changed")
+	return nil
+}
+
+// FileSink appends every change as a line of JSON to a file
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens the file at path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox file: %w", err)
+	}
+
+	return &FileSink{file: file}, nil
+}
+
+// Name identifies the sink
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Send appends the change to the file and flushes it to disk
+func (s *FileSink) Send(ctx context.Context, entry *models.OutboxEntry) error {
+	line, err := json.Marshal(NewMessage(entry))
+	if err != nil {
+		return fmt.Errorf("failed to encode change: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// WebhookSink queues every change for delivery to the webhook
+// subscriptions that want it
+type WebhookSink struct {
+	webhooks *webhook.Service
+}
+
+// NewWebhookSink creates a sink queueing changes with the webhook service
+func NewWebhookSink(webhooks *webhook.Service) *WebhookSink {
+	return &WebhookSink{webhooks: webhooks}
+}
+
+// Name identifies the sink
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send queues the deliveries of the change
+func (s *WebhookSink) Send(ctx context.Context, entry *models.OutboxEntry) error {
+	return s.webhooks.Enqueue(entry)
+}
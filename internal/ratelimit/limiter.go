@@ -0,0 +1,305 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// sweepInterval is how often idle buckets are dropped. A bucket idle for a
+// full minute has refilled completely, so forgetting it changes nothing.
+const sweepInterval = time.Minute
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed bool
+	// Limit is the budget, in requests per minute, that applied
+	Limit int
+	// Remaining is the number of requests left in the bucket
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, when denied
+	RetryAfter time.Duration
+}
+
+// bucket is a token bucket holding up to limit tokens and refilling at
+// limit tokens per minute
+type bucket struct {
+	tokens float64
+	limit  int
+	last   time.Time
+}
+
+// overrideKey identifies a rate limit override
+type overrideKey struct {
+	subjectType models.RateLimitSubject
+	subject     string
+}
+
+// Limiter enforces per-key and per-IP request budgets with token buckets.
+// Overrides are cached in memory, updated whenever they change through the
+// limiter and reloaded periodically to pick up changes made by other
+// replicas.
+type Limiter struct {
+	rateLimitRepo store.RateLimitRepository
+	auditService  *audit.AuditService
+	defaultPerMin int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	overrides map[overrideKey]int
+	lastSweep time.Time
+}
+
+// NewLimiter creates a limiter with a default budget of defaultPerMin
+// requests per minute. A non-positive default disables rate limiting.
+func NewLimiter(rateLimitRepo store.RateLimitRepository, auditService *audit.AuditService, defaultPerMin int) *Limiter {
+	return &Limiter{
+		rateLimitRepo: rateLimitRepo,
+		auditService:  auditService,
+		defaultPerMin: defaultPerMin,
+		buckets:       make(map[string]*bucket),
+		overrides:     make(map[overrideKey]int),
+	}
+}
+
+// Enabled reports whether requests are rate limited at all
+func (l *Limiter) Enabled() bool {
+	return l.defaultPerMin > 0
+}
+
+// Load reads the overrides from the database into the cache
+func (l *Limiter) Load() error {
+	overrides, err := l.rateLimitRepo.List()
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[overrideKey]int, len(overrides))
+	for _, override := range overrides {
+		cache[overrideKey{override.SubjectType, override.Subject}] = override.RequestsPerMin
+	}
+
+	l.mu.Lock()
+	l.overrides = cache
+	l.mu.Unlock()
+
+	return nil
+}
+
+// RunRefresher reloads the overrides every interval until ctx is done, so
+// that changes made by other replicas apply within interval
+func (l *Limiter) RunRefresher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Load(); err != nil {
+				log.Error().Err(err).Msg("Failed to reload rate limit overrides")
+			}
+		}
+	}
+}
+
+// AllowKey takes a token from the bucket of an API key, whose budget is the
+// key override, else the owner's role override, else the default
+func (l *Limiter) AllowKey(user *models.User, apiKey *models.APIKey) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.defaultPerMin
+	if perMin, ok := l.overrides[overrideKey{models.RateLimitSubjectAPIKey, apiKey.ID.String()}]; ok {
+		limit = perMin
+	} else if perMin, ok := l.overrides[overrideKey{models.RateLimitSubjectRole, string(user.Role)}]; ok {
+		limit = perMin
+	}
+
+	return l.take("key:"+apiKey.ID.String(), limit, time.Now())
+}
+
+// AllowIP takes a token from the bucket of a client IP, used for requests
+// that are not authenticated with an API key
+func (l *Limiter) AllowIP(ip string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.take("ip:"+ip, l.defaultPerMin, time.Now())
+}
+
+// CheckIP reports whether the bucket of a client IP has a token left,
+// without taking it
+func (l *Limiter) CheckIP(ip string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.Enabled() {
+		return Result{Allowed: true}
+	}
+
+	now := time.Now()
+	l.sweep(now)
+	return l.refill("ip:"+ip, l.defaultPerMin, now).result(false)
+}
+
+// AllowPlayer takes a token from the bucket of a player authenticated with a
+// player token
+func (l *Limiter) AllowPlayer(subject string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.take("player:"+subject, l.defaultPerMin, time.Now())
+}
+
+// take refills the named bucket and tries to remove a token from it. The
+// caller must hold l.mu.
+func (l *Limiter) take(name string, limit int, now time.Time) Result {
+	if !l.Enabled() {
+		return Result{Allowed: true}
+	}
+
+	l.sweep(now)
+
+	return l.refill(name, limit, now).result(true)
+}
+
+// refill returns the named bucket, created full or refilled up to now. The
+// caller must hold l.mu.
+func (l *Limiter) refill(name string, limit int, now time.Time) *bucket {
+	b, ok := l.buckets[name]
+	if !ok {
+		b = &bucket{tokens: float64(limit), limit: limit, last: now}
+		l.buckets[name] = b
+	}
+
+	// Refill, clamping to the current limit in case an override changed it
+	b.limit = limit
+	b.tokens += now.Sub(b.last).Seconds() * b.rate()
+	b.tokens = math.Min(b.tokens, float64(limit))
+	b.last = now
+
+	return b
+}
+
+// rate returns the refill rate of a bucket, in tokens per second
+func (b *bucket) rate() float64 {
+	return float64(b.limit) / time.Minute.Seconds()
+}
+
+// result reports whether the bucket has a token left, removing it when take
+// is set
+func (b *bucket) result(take bool) Result {
+	rate := b.rate()
+
+	result := Result{Limit: b.limit}
+	if b.tokens >= 1 {
+		if take {
+			b.tokens--
+		}
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((float64(b.limit) - b.tokens) / rate)
+
+	return result
+}
+
+// sweep drops buckets that have been idle long enough to be full. The
+// caller must hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for name, b := range l.buckets {
+		if now.Sub(b.last) >= time.Minute {
+			delete(l.buckets, name)
+		}
+	}
+}
+
+// ListOverrides returns all rate limit overrides
+func (l *Limiter) ListOverrides() ([]*models.RateLimitOverride, error) {
+	return l.rateLimitRepo.List()
+}
+
+// SetOverride creates or replaces a rate limit override
+func (l *Limiter) SetOverride(ctx context.Context, subjectType models.RateLimitSubject, subject string, requestsPerMin int) (*models.RateLimitOverride, error) {
+	override := &models.RateLimitOverride{
+		SubjectType:    subjectType,
+		Subject:        subject,
+		RequestsPerMin: requestsPerMin,
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := override.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Look up the previous value for the audit log
+	before, err := l.rateLimitRepo.Get(subjectType, subject)
+	if err != nil && err != models.ErrRateLimitNotFound {
+		return nil, err
+	}
+
+	if err := l.rateLimitRepo.Set(override); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.overrides[overrideKey{subjectType, subject}] = requestsPerMin
+	l.mu.Unlock()
+
+	action := models.AuditActionCreate
+	if before != nil {
+		action = models.AuditActionUpdate
+	}
+	l.auditService.Record(ctx, action, models.AuditTargetRateLimit, overrideTargetID(subjectType, subject), before, override)
+
+	return override, nil
+}
+
+// DeleteOverride removes a rate limit override, restoring the default budget
+func (l *Limiter) DeleteOverride(ctx context.Context, subjectType models.RateLimitSubject, subject string) error {
+	before, err := l.rateLimitRepo.Get(subjectType, subject)
+	if err != nil {
+		return err
+	}
+
+	if err := l.rateLimitRepo.Delete(subjectType, subject); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	delete(l.overrides, overrideKey{subjectType, subject})
+	l.mu.Unlock()
+
+	l.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetRateLimit, overrideTargetID(subjectType, subject), before, nil)
+
+	return nil
+}
+
+// overrideTargetID identifies an override in the audit log
+func overrideTargetID(subjectType models.RateLimitSubject, subject string) string {
+	return fmt.Sprintf("%s:%s", subjectType, subject)
+}
+
+// secondsToDuration converts fractional seconds to a duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,58 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/ratelimit"
+	"github.com/tombombadilom/liveops/internal/store/memory"
+)
+
+func TestRefresherPicksUpOverridesOfOtherReplicas(t *testing.T) {
+	repos := memory.New()
+	auditService := audit.NewAuditService(repos.Audit)
+	first := ratelimit.NewLimiter(repos.RateLimits, auditService, 60)
+	second := ratelimit.NewLimiter(repos.RateLimits, auditService, 60)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go second.RunRefresher(ctx, 10*time.Millisecond)
+
+	user := &models.User{ID: uuid.New(), Role: models.RoleEditor}
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: user.ID}
+
+	if _, err := first.SetOverride(ctx, models.RateLimitSubjectRole, string(models.RoleEditor), 600); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if limit := first.AllowKey(user, apiKey).Limit; limit != 600 {
+		t.Errorf("limiter setting the override applied %d, want 600", limit)
+	}
+
+	waitForLimit(t, second, user, apiKey, 600)
+
+	if err := first.DeleteOverride(ctx, models.RateLimitSubjectRole, string(models.RoleEditor)); err != nil {
+		t.Fatalf("DeleteOverride failed: %v", err)
+	}
+	waitForLimit(t, second, user, apiKey, 60)
+}
+
+// waitForLimit waits for a limiter to apply a budget to an API key
+func waitForLimit(t *testing.T, limiter *ratelimit.Limiter, user *models.User, apiKey *models.APIKey, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		limit := limiter.AllowKey(user, apiKey).Limit
+		if limit == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("limiter applied %d, want %d", limit, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
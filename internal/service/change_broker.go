@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+const (
+	// changeHistorySize is the number of recent changes kept for resuming
+	changeHistorySize = 1024
+	// subscriberBufferSize is the number of changes queued per subscriber
+	// before it is considered too slow and dropped
+	subscriberBufferSize = 256
+	// changeFeedBatchSize is the number of outbox entries read at a time
+	changeFeedBatchSize = 256
+	// changeGapTimeout is how long the feed waits for a missing sequence
+	// number before moving past it. PostgreSQL assigns sequence numbers
+	// before commit, so a change may become visible after later ones, and
+	// the numbers of rolled back transactions never appear.
+	changeGapTimeout = 5 * time.Second
+)
+
+// ChangeBroker fans out event changes to subscribers and keeps a short
+// history so that reconnecting subscribers can resume where they left off.
+//
+// Changes are read from the outbox, which records them in the transaction
+// making them, and keep its sequence numbers. Every replica sharing a
+// database therefore sees the changes made through all of them under the
+// same numbers, and a subscriber can resume on any replica.
+type ChangeBroker struct {
+	outboxRepo store.OutboxRepository
+	wake       chan struct{}
+
+	// pollMu serializes polls of the outbox
+	pollMu sync.Mutex
+	// gapSince is when the entry following seq was first found missing
+	gapSince time.Time
+
+	mu          sync.Mutex
+	loaded      bool
+	seq         int64
+	history     []models.EventChange
+	subscribers map[*ChangeSubscription]struct{}
+}
+
+// ChangeSubscription receives changes published after it was created.
+// Changes is closed when the subscription is closed or falls behind.
+type ChangeSubscription struct {
+	Changes <-chan models.EventChange
+
+	broker  *ChangeBroker
+	changes chan models.EventChange
+}
+
+// NewChangeBroker creates a change broker following the outbox
+func NewChangeBroker(outboxRepo store.OutboxRepository) *ChangeBroker {
+	return &ChangeBroker{
+		outboxRepo:  outboxRepo,
+		wake:        make(chan struct{}, 1),
+		subscribers: make(map[*ChangeSubscription]struct{}),
+	}
+}
+
+// Wake prompts the broker to poll the outbox without waiting for the next
+// interval
+func (b *ChangeBroker) Wake() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run polls the outbox every interval, and whenever woken, until ctx is done
+func (b *ChangeBroker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Poll(); err != nil {
+			log.Error().Err(err).Msg("Failed to read event changes from the outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll delivers the changes added to the outbox since the last poll, in
+// sequence order. It stops at a missing sequence number until the number
+// turns up or changeGapTimeout has passed.
+func (b *ChangeBroker) Poll() error {
+	b.pollMu.Lock()
+	defer b.pollMu.Unlock()
+
+	b.mu.Lock()
+	err := b.load()
+	seq := b.seq
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for {
+		entries, err := b.outboxRepo.ListAfter(seq, changeFeedBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list outbox entries: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.Seq != seq+1 {
+				now := time.Now()
+				if b.gapSince.IsZero() {
+					b.gapSince = now
+				}
+				if now.Sub(b.gapSince) < changeGapTimeout {
+					return nil
+				}
+			}
+			b.gapSince = time.Time{}
+
+			b.publish(models.EventChange{
+				Seq:     entry.Seq,
+				Type:    entry.Type,
+				EventID: entry.EventID.String(),
+				Event:   entry.Event,
+				Time:    entry.CreatedAt,
+			})
+			seq = entry.Seq
+		}
+
+		if len(entries) < changeFeedBatchSize {
+			return nil
+		}
+	}
+}
+
+// load starts the broker after the latest change in the outbox, the first
+// time it is called. The caller must hold b.mu.
+func (b *ChangeBroker) load() error {
+	if b.loaded {
+		return nil
+	}
+
+	latest, err := b.outboxRepo.List(models.OutboxFilter{}, 0, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read the latest outbox entry: %w", err)
+	}
+	if len(latest) > 0 {
+		b.seq = latest[0].Seq
+	}
+	b.loaded = true
+
+	return nil
+}
+
+// publish records a change in the history and delivers it to subscribers
+func (b *ChangeBroker) publish(change models.EventChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq = change.Seq
+	b.history = append(b.history, change)
+	if len(b.history) > changeHistorySize {
+		b.history = b.history[len(b.history)-changeHistorySize:]
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.changes <- change:
+		default:
+			// Drop subscribers that fall behind rather than block the feed
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. If since is a sequence number that can
+// still be resumed, the changes after it are returned in replay and resumed
+// is true. Otherwise the caller must send a snapshot consistent with seq,
+// the sequence number of the latest change.
+func (b *ChangeBroker) Subscribe(since int64) (sub *ChangeSubscription, replay []models.EventChange, seq int64, resumed bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return nil, nil, 0, false, err
+	}
+
+	changes := make(chan models.EventChange, subscriberBufferSize)
+	sub = &ChangeSubscription{Changes: changes, broker: b, changes: changes}
+	b.subscribers[sub] = struct{}{}
+
+	if since > 0 && since <= b.seq && since >= b.oldestResumable() {
+		for _, change := range b.history {
+			if change.Seq > since {
+				replay = append(replay, change)
+			}
+		}
+		resumed = true
+	}
+
+	return sub, replay, b.seq, resumed, nil
+}
+
+// oldestResumable returns the lowest sequence number a subscriber can resume
+// from without missing changes. The caller must hold b.mu.
+func (b *ChangeBroker) oldestResumable() int64 {
+	if len(b.history) == 0 {
+		return b.seq
+	}
+	return b.history[0].Seq - 1
+}
+
+// remove unregisters a subscriber and closes its channel. The caller must
+// hold b.mu.
+func (b *ChangeBroker) remove(sub *ChangeSubscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.changes)
+	}
+}
+
+// Close stops the subscription
+func (s *ChangeSubscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/store/memory"
+)
+
+// newReplica returns an event service on a shared store, as run by one of
+// several replicas, with its change feed running until the test ends
+func newReplica(t *testing.T, repos *store.Store, clk clock.Clock) *service.EventService {
+	t.Helper()
+
+	auditService := audit.NewAuditService(repos.Audit)
+	rewardService := service.NewRewardService(repos.RewardCatalog, auditService)
+	eventService := service.NewEventService(repos.Events, repos.EventRevisions, repos.Outbox, repos.Transactor, rewardService, auditService, clk, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go eventService.RunChangeFeed(ctx, 10*time.Millisecond)
+
+	return eventService
+}
+
+// nextChange waits for the next change of a watch
+func nextChange(t *testing.T, watch *service.EventWatch) models.EventChange {
+	t.Helper()
+
+	select {
+	case change, ok := <-watch.Changes:
+		if !ok {
+			t.Fatal("watch closed")
+		}
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+	}
+	return models.EventChange{}
+}
+
+func TestWatchEventsAcrossReplicas(t *testing.T) {
+	repos := memory.New()
+	clk := clock.Fixed(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	first := newReplica(t, repos, clk)
+	second := newReplica(t, repos, clk)
+
+	// Watch both replicas from the start
+	var watch *service.EventWatch
+	for _, replica := range []*service.EventService{first, second} {
+		var err error
+		watch, err = replica.WatchEvents(0)
+		if err != nil {
+			t.Fatalf("WatchEvents failed: %v", err)
+		}
+		defer watch.Close()
+		if len(watch.Initial) != 1 || watch.Initial[0].Type != models.ChangeSnapshotComplete {
+			t.Fatalf("got initial changes %+v, want an empty snapshot", watch.Initial)
+		}
+	}
+
+	// Changes made through one replica reach the watchers of the other
+	event, err := first.CreateEvent(context.Background(), models.EventFields{
+		Title:     "Summer festival",
+		StartTime: clk.Now().Add(time.Hour),
+		EndTime:   clk.Now().Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	created := nextChange(t, watch)
+	if created.Type != models.ChangeCreated || created.EventID != event.ID.String() {
+		t.Fatalf("got change %+v, want the creation", created)
+	}
+
+	if _, err := first.PublishEvent(context.Background(), event.ID.String()); err != nil {
+		t.Fatalf("PublishEvent failed: %v", err)
+	}
+	published := nextChange(t, watch)
+	if published.Type != models.ChangeUpdated || published.Seq <= created.Seq || published.Event.Status != models.StatusScheduled {
+		t.Fatalf("got change %+v, want the publication after %d", published, created.Seq)
+	}
+
+	// A watcher of the second replica resumes on the first under the same
+	// sequence numbers, once the first has read the changes
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resumed, err := first.WatchEvents(created.Seq)
+		if err != nil {
+			t.Fatalf("WatchEvents failed: %v", err)
+		}
+		resumed.Close()
+
+		if len(resumed.Initial) == 1 && resumed.Initial[0].Seq == published.Seq && resumed.Initial[0].Type == models.ChangeUpdated {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("resuming after %d returned %+v, want the publication", created.Seq, resumed.Initial)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A replica started later has no history to resume from
+	third := newReplica(t, repos, clk)
+	snapshot, err := third.WatchEvents(created.Seq)
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+	defer snapshot.Close()
+	if len(snapshot.Initial) != 2 || snapshot.Initial[0].Type != models.ChangeSnapshot || snapshot.Initial[1].Seq != published.Seq {
+		t.Fatalf("got initial changes %+v, want a snapshot at %d", snapshot.Initial, published.Seq)
+	}
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// MaxBatchSize bounds the number of events of a single batch
+const MaxBatchSize = 500
+
+// ErrTooManyBatchItems is returned for batches of more than MaxBatchSize events
+var ErrTooManyBatchItems = fmt.Errorf("batches are limited to %d events", MaxBatchSize)
+
+// BatchError fails a whole batch because of one of its events, numbered from
+// 0 in the order they were given
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+// Error describes the failing event
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("event %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the failing event
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchResult is the outcome of an event of a batch: the event as created or
+// updated, or as it was when deleted, or else the error it failed with
+type BatchResult struct {
+	Event *models.LiveEvent
+	Err   error
+}
+
+// BatchUpdate is an event update of a batch
+type BatchUpdate struct {
+	ID     string
+	Fields models.EventFields
+	// ExpectedVersion makes the update conditional when positive, like for
+	// UpdateEvent
+	ExpectedVersion int64
+}
+
+// BatchDelete is an event deletion of a batch
+type BatchDelete struct {
+	ID string
+	// ExpectedVersion makes the deletion conditional when positive, like for
+	// DeleteEvent
+	ExpectedVersion int64
+}
+
+// batchChange is an event change of a batch, published once committed
+type batchChange struct {
+	changeType models.ChangeType
+	before     *models.LiveEvent
+	event      *models.LiveEvent
+}
+
+// batchCheck checks an event of a batch against the store in tx and returns
+// the change to apply. Events that cannot be applied are reported by itemErr;
+// err fails the whole batch.
+type batchCheck func(tx *store.Store, i int) (change batchChange, itemErr error, err error)
+
+// BatchCreateEvents creates events in a single transaction. In atomic
+// batches the first failing event fails the batch with a *BatchError and
+// nothing is created; otherwise failing events are reported in their results
+// and the others are created.
+func (s *EventService) BatchCreateEvents(ctx context.Context, items []models.EventFields, atomic bool) ([]BatchResult, error) {
+	if len(items) > MaxBatchSize {
+		return nil, ErrTooManyBatchItems
+	}
+
+	// Validate every event before touching the store
+	results := make([]BatchResult, len(items))
+	events := make([]*models.LiveEvent, len(items))
+	for i := range items {
+		event, err := s.prepareBatchCreate(items[i])
+		if err != nil {
+			if atomic {
+				return nil, &BatchError{Index: i, Err: err}
+			}
+			results[i].Err = err
+			continue
+		}
+		events[i] = event
+	}
+
+	return s.runBatch(ctx, results, atomic, func(tx *store.Store, i int) (batchChange, error, error) {
+		event := events[i]
+		if event.ExternalID != "" {
+			_, err := tx.Events.GetByExternalID(event.ExternalID)
+			if err == nil {
+				return batchChange{}, models.ErrExternalIDExists, nil
+			}
+			if !errors.Is(err, models.ErrEventNotFound) {
+				return batchChange{}, nil, err
+			}
+		}
+		return batchChange{changeType: models.ChangeCreated, event: event}, nil, nil
+	})
+}
+
+// prepareBatchCreate builds and validates the event of a batch creation
+func (s *EventService) prepareBatchCreate(fields models.EventFields) (*models.LiveEvent, error) {
+	if err := s.resolveRewards(&fields); err != nil {
+		return nil, err
+	}
+
+	event, err := models.NewLiveEvent(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// BatchUpdateEvents updates events in a single transaction, failing or
+// reporting failing events like BatchCreateEvents. An event may be updated
+// more than once, each update seeing the previous ones.
+func (s *EventService) BatchUpdateEvents(ctx context.Context, items []BatchUpdate, atomic bool) ([]BatchResult, error) {
+	if len(items) > MaxBatchSize {
+		return nil, ErrTooManyBatchItems
+	}
+
+	// Check the IDs and rewards before touching the store
+	results := make([]BatchResult, len(items))
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		var err error
+		if ids[i], err = uuid.Parse(items[i].ID); err != nil {
+			err = models.ErrInvalidID
+		} else {
+			err = s.resolveRewards(&items[i].Fields)
+		}
+		if err != nil {
+			if atomic {
+				return nil, &BatchError{Index: i, Err: err}
+			}
+			results[i].Err = err
+		}
+	}
+
+	return s.runBatch(ctx, results, atomic, func(tx *store.Store, i int) (batchChange, error, error) {
+		event, itemErr, err := getBatchEvent(tx, ids[i], items[i].ExpectedVersion)
+		if itemErr != nil || err != nil {
+			return batchChange{}, itemErr, err
+		}
+
+		before := *event
+		event.SetFields(items[i].Fields)
+		if err := event.Validate(); err != nil {
+			return batchChange{}, err, nil
+		}
+
+		if event.ExternalID != "" {
+			other, err := tx.Events.GetByExternalID(event.ExternalID)
+			if err == nil && other.ID != event.ID {
+				return batchChange{}, models.ErrExternalIDExists, nil
+			}
+			if err != nil && !errors.Is(err, models.ErrEventNotFound) {
+				return batchChange{}, nil, err
+			}
+		}
+
+		return batchChange{changeType: models.ChangeUpdated, before: &before, event: event}, nil, nil
+	})
+}
+
+// BatchDeleteEvents removes events in a single transaction, failing or
+// reporting failing events like BatchCreateEvents
+func (s *EventService) BatchDeleteEvents(ctx context.Context, items []BatchDelete, atomic bool) ([]BatchResult, error) {
+	if len(items) > MaxBatchSize {
+		return nil, ErrTooManyBatchItems
+	}
+
+	// Check the IDs before touching the store
+	results := make([]BatchResult, len(items))
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		var err error
+		if ids[i], err = uuid.Parse(items[i].ID); err != nil {
+			if atomic {
+				return nil, &BatchError{Index: i, Err: models.ErrInvalidID}
+			}
+			results[i].Err = models.ErrInvalidID
+		}
+	}
+
+	return s.runBatch(ctx, results, atomic, func(tx *store.Store, i int) (batchChange, error, error) {
+		event, itemErr, err := getBatchEvent(tx, ids[i], items[i].ExpectedVersion)
+		if itemErr != nil || err != nil {
+			return batchChange{}, itemErr, err
+		}
+		return batchChange{changeType: models.ChangeDeleted, event: event}, nil, nil
+	})
+}
+
+// getBatchEvent reads an event to update or delete in tx, checking its
+// version when expectedVersion is positive
+func getBatchEvent(tx *store.Store, id uuid.UUID, expectedVersion int64) (*models.LiveEvent, error, error) {
+	event, err := tx.Events.GetByID(id)
+	if errors.Is(err, models.ErrEventNotFound) {
+		return nil, err, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if expectedVersion > 0 && event.Version != expectedVersion {
+		return nil, models.ErrVersionConflict, nil
+	}
+	return event, nil, nil
+}
+
+// runBatch checks and applies the events of a batch whose results are not
+// failed yet in a single transaction, then audits and publishes the
+// committed changes like individual edits. Events are only written once check has found nothing
+// wrong with them, so that failures never leave the transaction unusable;
+// errors writing them fail the batch whether it is atomic or not.
+func (s *EventService) runBatch(ctx context.Context, results []BatchResult, atomic bool, check batchCheck) ([]BatchResult, error) {
+	var changes []batchChange
+	err := s.transactor.InTx(func(tx *store.Store) error {
+		for i := range results {
+			if results[i].Err != nil {
+				continue
+			}
+
+			change, itemErr, err := check(tx, i)
+			if err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+			if itemErr != nil {
+				if atomic {
+					return &BatchError{Index: i, Err: itemErr}
+				}
+				results[i].Err = itemErr
+				continue
+			}
+
+			if err := s.applyBatchChange(ctx, tx, change); err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+			results[i].Event = change.event
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var updated bool
+	for _, change := range changes {
+		event := change.event
+		switch change.changeType {
+		case models.ChangeCreated:
+			s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetEvent, event.ID.String(), nil, event)
+		case models.ChangeUpdated:
+			s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetEvent, event.ID.String(), change.before, event)
+			updated = true
+		case models.ChangeDeleted:
+			s.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetEvent, event.ID.String(), event, nil)
+		}
+	}
+	s.publish()
+	if updated {
+		s.wakeScheduler()
+	}
+
+	return results, nil
+}
+
+// applyBatchChange writes a change of a batch in tx along with its outbox
+// entry and its revision
+func (s *EventService) applyBatchChange(ctx context.Context, tx *store.Store, change batchChange) error {
+	var action string
+	var err error
+	before := change.before
+	switch change.changeType {
+	case models.ChangeCreated:
+		action = models.RevisionActionCreate
+		err = tx.Events.Create(change.event)
+	case models.ChangeUpdated:
+		action = models.RevisionActionUpdate
+		err = tx.Events.Update(change.event)
+	case models.ChangeDeleted:
+		action = models.RevisionActionDelete
+		before = change.event
+		err = tx.Events.Delete(change.event.ID, change.event.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.recordChange(ctx, tx, change.changeType, action, before, change.event, 0)
+}
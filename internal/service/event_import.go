@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// MaxImportRows bounds the number of rows of a single import
+const MaxImportRows = 10000
+
+// ImportMode selects how an import treats rows that fail
+type ImportMode string
+
+const (
+	// ImportAtomic applies every row or none: a single failing row leaves
+	// all events untouched
+	ImportAtomic ImportMode = "atomic"
+	// ImportBestEffort applies every row that succeeds and reports the others
+	ImportBestEffort ImportMode = "best_effort"
+)
+
+// IsValid checks if the mode is known
+func (m ImportMode) IsValid() bool {
+	return m == ImportAtomic || m == ImportBestEffort
+}
+
+// ImportAction is the outcome of an import row
+type ImportAction string
+
+const (
+	// ImportCreated rows created an event
+	ImportCreated ImportAction = "created"
+	// ImportUpdated rows updated the event with their external ID or ID
+	ImportUpdated ImportAction = "updated"
+	// ImportUnchanged rows matched the event with their external ID or ID
+	ImportUnchanged ImportAction = "unchanged"
+	// ImportFailed rows were invalid or could not be applied
+	ImportFailed ImportAction = "failed"
+	// ImportSkipped rows were not applied because another row failed an
+	// atomic import
+	ImportSkipped ImportAction = "skipped"
+)
+
+// ErrTooManyImportRows is returned for imports of more than MaxImportRows rows
+var ErrTooManyImportRows = fmt.Errorf("imports are limited to %d rows", MaxImportRows)
+
+// errImportAborted rolls back the transaction of a dry run or of an atomic
+// import with a failing row
+var errImportAborted = errors.New("import aborted")
+
+// ImportRow is a decoded row of an import
+type ImportRow struct {
+	// ID is the event ID the row carries, as exports write it, if any
+	ID     uuid.UUID
+	Fields models.EventFields
+	// Err is set when the row could not be decoded
+	Err error
+}
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	Mode ImportMode
+	// DryRun validates every row, including against the stored events,
+	// without applying any
+	DryRun bool
+}
+
+// ImportResult reports the outcome of an import row. Rows are numbered from
+// 1 in the order they were read.
+type ImportResult struct {
+	Row        int          `json:"row"`
+	ExternalID string       `json:"external_id,omitempty"`
+	EventID    string       `json:"event_id,omitempty"`
+	Action     ImportAction `json:"action"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import. Committed tells whether the changes
+// were applied, which they are not for dry runs and atomic imports with a
+// failing row; the actions then tell what would have happened.
+type ImportReport struct {
+	Mode      ImportMode     `json:"mode"`
+	DryRun    bool           `json:"dry_run"`
+	Committed bool           `json:"committed"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Failed    int            `json:"failed"`
+	Rows      []ImportResult `json:"rows"`
+}
+
+// importChange is a row applied in a transaction, published once committed
+type importChange struct {
+	action ImportAction
+	before *models.LiveEvent
+	event  *models.LiveEvent
+}
+
+// ImportEvents creates or updates an event for every row. Rows with an
+// external ID update the event with that ID when there is one, and are left
+// alone when they would not change it, so that importing the same rows again
+// changes nothing. Rows without one but with an event ID, as exported, update
+// that event the same way, and fail when there is none. Other rows always
+// create events, as drafts. Failing rows are reported rather than returned as
+// errors.
+func (s *EventService) ImportEvents(ctx context.Context, rows []ImportRow, opts ImportOptions) (*ImportReport, error) {
+	if len(rows) > MaxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+	if opts.Mode == "" {
+		opts.Mode = ImportAtomic
+	}
+
+	report := &ImportReport{Mode: opts.Mode, DryRun: opts.DryRun, Rows: make([]ImportResult, len(rows))}
+
+	// Validate every row before touching the store
+	prepared := make([]ImportRow, len(rows))
+	seen := make(map[string]int)
+	seenIDs := make(map[uuid.UUID]int)
+	for i, row := range rows {
+		result := &report.Rows[i]
+		result.Row = i + 1
+		result.ExternalID = row.Fields.ExternalID
+
+		if row.Err != nil {
+			result.fail(row.Err)
+			continue
+		}
+		prepared[i] = row
+		if err := s.prepareImportRow(&prepared[i].Fields); err != nil {
+			result.fail(err)
+			continue
+		}
+
+		if id := row.Fields.ExternalID; id != "" {
+			if first, ok := seen[id]; ok {
+				result.fail(fmt.Errorf("%w: also on row %d", models.ErrExternalIDExists, first))
+				continue
+			}
+			seen[id] = result.Row
+		} else if row.ID != uuid.Nil {
+			if first, ok := seenIDs[row.ID]; ok {
+				result.fail(fmt.Errorf("event %s is also on row %d", row.ID, first))
+				continue
+			}
+			seenIDs[row.ID] = result.Row
+		}
+	}
+
+	var changes []importChange
+	if opts.Mode == ImportAtomic {
+		var err error
+		if changes, err = s.importAtomic(ctx, report, prepared, opts.DryRun); err != nil {
+			return nil, err
+		}
+	} else {
+		changes = s.importBestEffort(ctx, report, prepared, opts.DryRun)
+	}
+
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		if result.Action == "" {
+			result.Action = ImportSkipped
+		}
+		switch result.Action {
+		case ImportCreated:
+			report.Created++
+		case ImportUpdated:
+			report.Updated++
+		case ImportUnchanged:
+			report.Unchanged++
+		case ImportFailed:
+			report.Failed++
+		}
+	}
+	report.Committed = !opts.DryRun && (opts.Mode == ImportBestEffort || report.Failed == 0)
+
+	// Events that were not created have no ID to report
+	if !report.Committed {
+		for i := range report.Rows {
+			if report.Rows[i].Action == ImportCreated {
+				report.Rows[i].EventID = ""
+			}
+		}
+	}
+
+	// Record the committed changes like individual edits
+	var updated bool
+	for _, change := range changes {
+		switch change.action {
+		case ImportCreated:
+			s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetEvent, change.event.ID.String(), nil, change.event)
+		case ImportUpdated:
+			s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetEvent, change.event.ID.String(), change.before, change.event)
+			updated = true
+		}
+	}
+	s.publish()
+	if updated {
+		s.wakeScheduler()
+	}
+
+	return report, nil
+}
+
+// importAtomic applies the rows in a single transaction, committed unless a
+// row fails or this is a dry run. It returns the committed changes.
+func (s *EventService) importAtomic(ctx context.Context, report *ImportReport, rows []ImportRow, dryRun bool) ([]importChange, error) {
+	for _, result := range report.Rows {
+		if result.Action == ImportFailed {
+			return nil, nil
+		}
+	}
+
+	var changes []importChange
+	err := s.transactor.InTx(func(tx *store.Store) error {
+		for i := range rows {
+			change, err := s.importRow(ctx, tx, rows[i])
+			if err != nil {
+				report.Rows[i].fail(err)
+				return errImportAborted
+			}
+			report.Rows[i].applied(change)
+			changes = append(changes, change)
+		}
+		if dryRun {
+			return errImportAborted
+		}
+		return nil
+	})
+	if errors.Is(err, errImportAborted) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to import events: %w", err)
+	}
+
+	return changes, nil
+}
+
+// importBestEffort applies every valid row in a transaction of its own,
+// rolled back for dry runs. It returns the committed changes.
+func (s *EventService) importBestEffort(ctx context.Context, report *ImportReport, rows []ImportRow, dryRun bool) []importChange {
+	var changes []importChange
+	for i := range rows {
+		if report.Rows[i].Action == ImportFailed {
+			continue
+		}
+
+		var change importChange
+		err := s.transactor.InTx(func(tx *store.Store) error {
+			var err error
+			change, err = s.importRow(ctx, tx, rows[i])
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				return errImportAborted
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errImportAborted) {
+			report.Rows[i].fail(err)
+			continue
+		}
+
+		report.Rows[i].applied(change)
+		if !dryRun {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// prepareImportRow checks the fields of a row against the catalog and the
+// event rules
+func (s *EventService) prepareImportRow(fields *models.EventFields) error {
+	if fields.StartTime.IsZero() || fields.EndTime.IsZero() {
+		return errors.New("start_time and end_time are required")
+	}
+	if err := s.resolveRewards(fields); err != nil {
+		return err
+	}
+
+	event, err := models.NewLiveEvent(*fields)
+	if err != nil {
+		return err
+	}
+	return event.Validate()
+}
+
+// importRow creates or updates the event of a row in tx, recording the
+// change in the outbox and as a revision
+func (s *EventService) importRow(ctx context.Context, tx *store.Store, row ImportRow) (importChange, error) {
+	fields := row.Fields
+	if fields.ExternalID != "" {
+		event, err := tx.Events.GetByExternalID(fields.ExternalID)
+		if err == nil {
+			return s.importUpdate(ctx, tx, event, fields)
+		}
+		if !errors.Is(err, models.ErrEventNotFound) {
+			return importChange{}, err
+		}
+	} else if row.ID != uuid.Nil {
+		// Creating the row instead would duplicate the exported event
+		event, err := tx.Events.GetByID(row.ID)
+		if err != nil {
+			if errors.Is(err, models.ErrEventNotFound) {
+				return importChange{}, fmt.Errorf("%w: %s", models.ErrEventNotFound, row.ID)
+			}
+			return importChange{}, err
+		}
+		return s.importUpdate(ctx, tx, event, fields)
+	}
+
+	event, err := models.NewLiveEvent(fields)
+	if err != nil {
+		return importChange{}, fmt.Errorf("failed to create event: %w", err)
+	}
+	if err := tx.Events.Create(event); err != nil {
+		return importChange{}, err
+	}
+	if err := s.recordChange(ctx, tx, models.ChangeCreated, models.RevisionActionCreate, nil, event, 0); err != nil {
+		return importChange{}, err
+	}
+
+	return importChange{action: ImportCreated, event: event}, nil
+}
+
+// importUpdate replaces the fields of an existing event in tx, unless they
+// are already the same
+func (s *EventService) importUpdate(ctx context.Context, tx *store.Store, event *models.LiveEvent, fields models.EventFields) (importChange, error) {
+	before := *event
+	event.SetFields(fields)
+
+	changes, err := models.DiffEvents(utcTimes(&before), utcTimes(event))
+	if err != nil {
+		return importChange{}, fmt.Errorf("failed to compare event: %w", err)
+	}
+	if len(changes) == 0 {
+		return importChange{action: ImportUnchanged, event: event}, nil
+	}
+
+	if err := tx.Events.Update(event); err != nil {
+		return importChange{}, err
+	}
+	if err := s.recordChange(ctx, tx, models.ChangeUpdated, models.RevisionActionUpdate, &before, event, 0); err != nil {
+		return importChange{}, err
+	}
+
+	return importChange{action: ImportUpdated, before: &before, event: event}, nil
+}
+
+// utcTimes returns a copy of the event with its times in UTC, so that
+// events are compared on instants rather than time zones
+func utcTimes(event *models.LiveEvent) *models.LiveEvent {
+	c := *event
+	c.StartTime, c.EndTime = c.StartTime.UTC(), c.EndTime.UTC()
+	return &c
+}
+
+// fail marks the row as failed with err
+func (r *ImportResult) fail(err error) {
+	r.Action = ImportFailed
+	r.Error = err.Error()
+	r.EventID = ""
+}
+
+// applied marks the row with the outcome of its change
+func (r *ImportResult) applied(change importChange) {
+	r.Action = change.action
+	r.EventID = change.event.ID.String()
+}
+
+// ExportEvents calls emit with every event matching the filter, ordered by
+// start time, stopping at the first error emit returns
+func (s *EventService) ExportEvents(filter models.EventFilter, emit func(*models.LiveEvent) error) error {
+	var after *models.EventCursor
+	for {
+		batch, cursors, err := s.eventRepo.ListPage(filter, models.SortStartTimeAsc, after, MaxPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for _, event := range batch {
+			if !filter.Matches(event) {
+				continue
+			}
+			if err := emit(event); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < MaxPageSize {
+			return nil
+		}
+		after = &cursors[len(cursors)-1]
+	}
+}
@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/service"
+)
+
+func TestImportEventsMatchesExportedIDs(t *testing.T) {
+	start := time.Date(2025, 6, 2, 18, 0, 0, 0, time.UTC)
+	eventService, repos := newEventService(clock.Fixed(start.Add(-24 * time.Hour)))
+
+	fields := models.EventFields{Title: "Summer festival", StartTime: start, EndTime: start.Add(time.Hour)}
+	event, err := eventService.CreateEvent(context.Background(), fields)
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	// Rows carrying the ID of an event without an external ID update it, and
+	// importing them again changes nothing
+	edited := fields
+	edited.Title = "Summer festival finals"
+	for _, want := range []service.ImportAction{service.ImportUpdated, service.ImportUnchanged} {
+		report, err := eventService.ImportEvents(context.Background(), []service.ImportRow{{ID: event.ID, Fields: edited}}, service.ImportOptions{})
+		if err != nil {
+			t.Fatalf("ImportEvents failed: %v", err)
+		}
+		if got := report.Rows[0]; got.Action != want || got.EventID != event.ID.String() {
+			t.Fatalf("got row %+v, want %s for event %s", got, want, event.ID)
+		}
+	}
+
+	stored, err := repos.Events.GetByID(event.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.Title != edited.Title {
+		t.Errorf("got title %q, want %q", stored.Title, edited.Title)
+	}
+
+	// Rows naming an unknown event, or the same event twice, fail rather
+	// than create events
+	report, err := eventService.ImportEvents(context.Background(), []service.ImportRow{
+		{ID: uuid.New(), Fields: fields},
+		{ID: event.ID, Fields: fields},
+		{ID: event.ID, Fields: edited},
+	}, service.ImportOptions{Mode: service.ImportBestEffort})
+	if err != nil {
+		t.Fatalf("ImportEvents failed: %v", err)
+	}
+	for i, want := range []service.ImportAction{service.ImportFailed, service.ImportUpdated, service.ImportFailed} {
+		if got := report.Rows[i].Action; got != want {
+			t.Errorf("row %d: got %s, want %s", i+1, got, want)
+		}
+	}
+
+	events, err := repos.Events.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("got %d events, want 1", len(events))
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// RevisionDiff holds the fields that differ between two revisions of an
+// event
+type RevisionDiff struct {
+	EventID uuid.UUID            `json:"event_id"`
+	From    int                  `json:"from"`
+	To      int                  `json:"to"`
+	Changes []models.FieldChange `json:"changes"`
+}
+
+// ListEventRevisions returns a page of the revisions of an event, newest
+// first, and the token for the next page (empty on the last page). The
+// revisions of deleted events remain available.
+func (s *EventService) ListEventRevisions(id string, pageSize int, pageToken string) ([]*models.EventRevision, string, error) {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", models.ErrInvalidID
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var before int
+	if pageToken != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", models.ErrInvalidPageToken
+		}
+		before, err = strconv.Atoi(string(raw))
+		if err != nil || before <= 0 {
+			return nil, "", models.ErrInvalidPageToken
+		}
+	}
+
+	// Fetch one extra revision to know whether another page exists
+	revisions, err := s.revisionRepo.List(eventID, before, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list event revisions: %w", err)
+	}
+
+	var nextPageToken string
+	if len(revisions) > pageSize {
+		revisions = revisions[:pageSize]
+		last := revisions[len(revisions)-1].Revision
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(last)))
+	}
+
+	return revisions, nextPageToken, nil
+}
+
+// GetEventRevision retrieves a revision of an event
+func (s *EventService) GetEventRevision(id string, revision int) (*models.EventRevision, error) {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.ErrInvalidID
+	}
+
+	return s.revisionRepo.Get(eventID, revision)
+}
+
+// DiffEventRevisions compares two revisions of an event. A zero to compares
+// against the latest revision.
+func (s *EventService) DiffEventRevisions(id string, from, to int) (*RevisionDiff, error) {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, models.ErrInvalidID
+	}
+
+	fromRevision, err := s.revisionRepo.Get(eventID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var toRevision *models.EventRevision
+	if to == 0 {
+		latest, err := s.revisionRepo.List(eventID, 0, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list event revisions: %w", err)
+		}
+		toRevision = latest[0]
+	} else if toRevision, err = s.revisionRepo.Get(eventID, to); err != nil {
+		return nil, err
+	}
+
+	changes, err := models.DiffEvents(&fromRevision.Event, &toRevision.Event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare event revisions: %w", err)
+	}
+
+	return &RevisionDiff{
+		EventID: eventID,
+		From:    fromRevision.Revision,
+		To:      toRevision.Revision,
+		Changes: changes,
+	}, nil
+}
+
+// RevertEvent restores the editable fields of an event to those of one of its
+// revisions, recording the result as a new revision. The status is kept, so
+// reverting never moves an event through its lifecycle, and the restored
+// fields are validated like any update. Deleted events cannot be reverted.
+func (s *EventService) RevertEvent(ctx context.Context, id string, revision int) (*models.LiveEvent, error) {
+	target, err := s.GetEventRevision(id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, target.EventID, target.Event.Fields(), 0, models.RevisionActionRevert, target.Revision)
+}
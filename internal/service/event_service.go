@@ -1,30 +1,97 @@
 package service
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/tombombadilom/liveops/internal/db"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/clock"
 	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/targeting"
 )
 
+// Page size limits for ListEvents
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// writeAttempts bounds how often an update or deletion without an expected
+// version is retried when the event changes between reading and writing it
+const writeAttempts = 3
+
+// OutboxWaker is prompted whenever event changes are committed to the
+// outbox, so that they are dispatched without waiting for the next poll
+type OutboxWaker interface {
+	Wake()
+}
+
 // EventService handles business logic for events
 type EventService struct {
-	eventRepo *db.EventRepository
+	eventRepo     store.EventRepository
+	revisionRepo  store.EventRevisionRepository
+	transactor    store.Transactor
+	rewardService *RewardService
+	auditService  *audit.AuditService
+	broker        *ChangeBroker
+	outbox        OutboxWaker
+	clock         clock.Clock
+
+	// wake prompts the status scheduler to recompute its next deadline
+	wake chan struct{}
 }
 
-// NewEventService creates a new event service
-func NewEventService(eventRepo *db.EventRepository) *EventService {
+// NewEventService creates a new event service telling the time by clk and
+// recording the revisions of events in revisionRepo. Event mutations run in
+// transactions of transactor, which record every change in the outbox; the
+// outbox is woken after they commit unless it is nil. Watchers are sent the
+// changes read back from outboxRepo.
+func NewEventService(eventRepo store.EventRepository, revisionRepo store.EventRevisionRepository, outboxRepo store.OutboxRepository, transactor store.Transactor, rewardService *RewardService, auditService *audit.AuditService, clk clock.Clock, outbox OutboxWaker) *EventService {
 	return &EventService{
-		eventRepo: eventRepo,
+		eventRepo:     eventRepo,
+		revisionRepo:  revisionRepo,
+		transactor:    transactor,
+		rewardService: rewardService,
+		auditService:  auditService,
+		broker:        NewChangeBroker(outboxRepo),
+		outbox:        outbox,
+		clock:         clk,
+		wake:          make(chan struct{}, 1),
 	}
 }
 
+// EventWatch streams event changes to a single watcher. Initial holds either
+// a snapshot of all events terminated by a snapshot_complete marker, or the
+// changes missed since the resumed sequence number; Changes then delivers
+// new changes and is closed if the watcher falls behind.
+type EventWatch struct {
+	Initial []models.EventChange
+	Changes <-chan models.EventChange
+
+	sub *ChangeSubscription
+}
+
+// Close stops the watch
+func (w *EventWatch) Close() {
+	w.sub.Close()
+}
+
 // CreateEvent creates a new event
-func (s *EventService) CreateEvent(title, description string, startTime, endTime time.Time, rewards string) (*models.LiveEvent, error) {
+func (s *EventService) CreateEvent(ctx context.Context, fields models.EventFields) (*models.LiveEvent, error) {
+	// Check rewards against the catalog
+	if err := s.resolveRewards(&fields); err != nil {
+		return nil, err
+	}
+
 	// Create new event
-	event, err := models.NewLiveEvent(title, description, startTime, endTime, rewards)
+	event, err := models.NewLiveEvent(fields)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create event: %w", err)
 	}
@@ -34,14 +101,56 @@ func (s *EventService) CreateEvent(title, description string, startTime, endTime
 		return nil, err
 	}
 
-	// Save to database
-	if err := s.eventRepo.Create(event); err != nil {
-		return nil, fmt.Errorf("failed to save event: %w", err)
+	// Save to database along with the outbox entry and the revision
+	err = s.transactor.InTx(func(tx *store.Store) error {
+		if err := tx.Events.Create(event); err != nil {
+			return fmt.Errorf("failed to save event: %w", err)
+		}
+		return s.recordChange(ctx, tx, models.ChangeCreated, models.RevisionActionCreate, nil, event, 0)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetEvent, event.ID.String(), nil, event)
+	s.publish()
+
 	return event, nil
 }
 
+// resolveRewards validates the rewards in fields against the catalog.
+// Structured rewards replace the raw JSON, which is derived from them; raw
+// JSON in a shape ParseRewards recognizes also yields structured rewards,
+// while other raw JSON is kept as a legacy value without catalog checks.
+func (s *EventService) resolveRewards(fields *models.EventFields) error {
+	rewards := fields.StructuredRewards
+	derived := len(rewards) > 0
+
+	if !derived {
+		parsed, ok, err := models.ParseRewards(fields.Rewards)
+		if err != nil || !ok {
+			return err
+		}
+		rewards = parsed
+	}
+
+	resolved, err := s.rewardService.ResolveRewards(rewards)
+	if err != nil {
+		return err
+	}
+	fields.StructuredRewards = resolved
+
+	if derived {
+		data, err := json.Marshal(resolved)
+		if err != nil {
+			return fmt.Errorf("failed to encode rewards: %w", err)
+		}
+		fields.Rewards = string(data)
+	}
+
+	return nil
+}
+
 // GetEvent retrieves an event by ID
 func (s *EventService) GetEvent(id string) (*models.LiveEvent, error) {
 	// Parse UUID
@@ -59,63 +168,549 @@ func (s *EventService) GetEvent(id string) (*models.LiveEvent, error) {
 	return event, nil
 }
 
-// UpdateEvent updates an existing event
-func (s *EventService) UpdateEvent(id, title, description string, startTime, endTime time.Time, rewards string) (*models.LiveEvent, error) {
+// UpdateEvent updates an existing event. A positive expectedVersion makes
+// the update conditional: it fails with models.ErrVersionConflict unless the
+// event is still at that version.
+func (s *EventService) UpdateEvent(ctx context.Context, id string, fields models.EventFields, expectedVersion int64) (*models.LiveEvent, error) {
 	// Parse UUID
 	eventID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, models.ErrInvalidID
 	}
 
+	return s.update(ctx, eventID, fields, expectedVersion, models.RevisionActionUpdate, 0)
+}
+
+// update replaces the editable fields of an event, recording the change as a
+// revision with the given action. Without an expected version, changes made
+// concurrently to the event are overwritten.
+func (s *EventService) update(ctx context.Context, eventID uuid.UUID, fields models.EventFields, expectedVersion int64, action string, revertedFrom int) (*models.LiveEvent, error) {
+	for attempt := 1; ; attempt++ {
+		// Get existing event
+		event, err := s.eventRepo.GetByID(eventID)
+		if err != nil {
+			return nil, err
+		}
+		if expectedVersion > 0 && event.Version != expectedVersion {
+			return nil, models.ErrVersionConflict
+		}
+
+		// Check rewards against the catalog
+		if err := s.resolveRewards(&fields); err != nil {
+			return nil, err
+		}
+
+		before := *event
+
+		// Update fields
+		event.SetFields(fields)
+
+		// Validate event
+		if err := event.Validate(); err != nil {
+			return nil, err
+		}
+
+		// Save to database along with the outbox entry and the revision, only
+		// if the event has not changed since it was read
+		err = s.transactor.InTx(func(tx *store.Store) error {
+			if err := tx.Events.Update(event); err != nil {
+				return err
+			}
+			return s.recordChange(ctx, tx, models.ChangeUpdated, action, &before, event, revertedFrom)
+		})
+		if err != nil {
+			if errors.Is(err, models.ErrVersionConflict) && expectedVersion == 0 && attempt < writeAttempts {
+				continue
+			}
+			return nil, fmt.Errorf("failed to update event: %w", err)
+		}
+
+		s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetEvent, event.ID.String(), &before, event)
+		s.publish()
+		s.wakeScheduler()
+
+		return event, nil
+	}
+}
+
+// DeleteEvent removes an event by ID. A positive expectedVersion makes the
+// deletion conditional: it fails with models.ErrVersionConflict unless the
+// event is still at that version.
+func (s *EventService) DeleteEvent(ctx context.Context, id string, expectedVersion int64) error {
+	// Parse UUID
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return models.ErrInvalidID
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Get existing event for the audit log
+		event, err := s.eventRepo.GetByID(eventID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion > 0 && event.Version != expectedVersion {
+			return models.ErrVersionConflict
+		}
+
+		// Delete from database along with the outbox entry and the revision,
+		// only if the event has not changed since it was read
+		err = s.transactor.InTx(func(tx *store.Store) error {
+			if err := tx.Events.Delete(eventID, event.Version); err != nil {
+				return err
+			}
+			return s.recordChange(ctx, tx, models.ChangeDeleted, models.RevisionActionDelete, event, event, 0)
+		})
+		if err != nil {
+			if errors.Is(err, models.ErrVersionConflict) && expectedVersion == 0 && attempt < writeAttempts {
+				continue
+			}
+			return err
+		}
+
+		s.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetEvent, event.ID.String(), event, nil)
+		s.publish()
+
+		return nil
+	}
+}
+
+// PublishEvent makes a draft event visible to game clients
+func (s *EventService) PublishEvent(ctx context.Context, id string) (*models.LiveEvent, error) {
+	return s.transition(ctx, id, models.StatusScheduled)
+}
+
+// UnpublishEvent returns a scheduled event to draft
+func (s *EventService) UnpublishEvent(ctx context.Context, id string) (*models.LiveEvent, error) {
+	return s.transition(ctx, id, models.StatusDraft)
+}
+
+// CancelEvent withdraws an event that has not ended yet
+func (s *EventService) CancelEvent(ctx context.Context, id string) (*models.LiveEvent, error) {
+	return s.transition(ctx, id, models.StatusCancelled)
+}
+
+// ArchiveEvent archives an ended or cancelled event
+func (s *EventService) ArchiveEvent(ctx context.Context, id string) (*models.LiveEvent, error) {
+	return s.transition(ctx, id, models.StatusArchived)
+}
+
+// transition moves an event to a new status if the lifecycle allows it
+func (s *EventService) transition(ctx context.Context, id string, to models.EventStatus) (*models.LiveEvent, error) {
 	// Get existing event
-	event, err := s.eventRepo.GetByID(eventID)
+	event, err := s.GetEvent(id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update fields
-	event.Title = title
-	event.Description = description
-	event.StartTime = startTime
-	event.EndTime = endTime
-	event.Rewards = rewards
+	if !models.CanTransition(event.Status, to) {
+		return nil, fmt.Errorf("%w: %s to %s", models.ErrInvalidTransition, event.Status, to)
+	}
 
-	// Validate event
-	if err := event.Validate(); err != nil {
+	// Save to database along with the outbox entry and the revision
+	before := *event
+	err = s.transactor.InTx(func(tx *store.Store) error {
+		version, err := tx.Events.UpdateStatus(event.ID, event.Status, to)
+		if err != nil {
+			return err
+		}
+		event.Status = to
+		event.Version = version
+		return s.recordChange(ctx, tx, models.ChangeUpdated, models.RevisionActionStatus, &before, event, 0)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Save to database
-	if err := s.eventRepo.Update(event); err != nil {
-		return nil, fmt.Errorf("failed to update event: %w", err)
-	}
+	s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetEvent, event.ID.String(), &before, event)
+	s.publish()
+	s.wakeScheduler()
 
 	return event, nil
 }
 
-// DeleteEvent removes an event by ID
-func (s *EventService) DeleteEvent(id string) error {
-	// Parse UUID
-	eventID, err := uuid.Parse(id)
+// AdvanceStatuses applies time-based transitions: scheduled events whose
+// start time has passed go live, and live events past their final end time
+// end. It returns the number of events that changed status.
+//
+// Statuses follow a recurring event as a whole series: it goes live with its
+// first occurrence and ends after its final one, never for rules without
+// COUNT or UNTIL. The started and ended changes are therefore sent once per
+// series, not for each occurrence.
+func (s *EventService) AdvanceStatuses(now time.Time) (int, error) {
+	changed, _, err := s.advanceStatuses(now)
+	return changed, err
+}
+
+// advanceStatuses implements AdvanceStatuses and also returns the earliest
+// future time at which another transition is due, zero if none is
+func (s *EventService) advanceStatuses(now time.Time) (int, time.Time, error) {
+	events, err := s.eventRepo.ListByStatus(models.StatusScheduled, models.StatusLive)
 	if err != nil {
-		return models.ErrInvalidID
+		return 0, time.Time{}, fmt.Errorf("failed to list published events: %w", err)
 	}
 
-	// Delete from database
-	if err := s.eventRepo.Delete(eventID); err != nil {
-		return err
+	var changed int
+	var next time.Time
+	for _, event := range events {
+		from := event.Status
+		to := from
+
+		if from == models.StatusScheduled && !now.Before(event.StartTime) {
+			to = models.StatusLive
+		}
+		if to == models.StatusLive {
+			if end, ok := event.FinalEndTime(); ok && !now.Before(end) {
+				to = models.StatusEnded
+			}
+		}
+
+		// Remember when this event is next due
+		var due time.Time
+		if to == models.StatusScheduled {
+			due = event.StartTime
+		} else if end, ok := event.FinalEndTime(); ok && to == models.StatusLive {
+			due = end
+		}
+		if !due.IsZero() && (next.IsZero() || due.Before(next)) {
+			next = due
+		}
+
+		if to == from {
+			continue
+		}
+
+		before := *event
+
+		// Step through live so that every transition stays legal, recording
+		// both changes together. Time-based transitions are attributed to the
+		// system.
+		ctx := context.Background()
+		err := s.transactor.InTx(func(tx *store.Store) error {
+			previous := &before
+			if from == models.StatusScheduled && to == models.StatusEnded {
+				version, err := tx.Events.UpdateStatus(event.ID, from, models.StatusLive)
+				if err != nil {
+					return err
+				}
+				event.Status = models.StatusLive
+				event.Version = version
+				if err := s.recordChange(ctx, tx, models.ChangeStarted, models.RevisionActionStatus, previous, event, 0); err != nil {
+					return err
+				}
+				started := *event
+				previous = &started
+				from = models.StatusLive
+			}
+
+			version, err := tx.Events.UpdateStatus(event.ID, from, to)
+			if err != nil {
+				return err
+			}
+			event.Status = to
+			event.Version = version
+			return s.recordChange(ctx, tx, statusChange(to), models.RevisionActionStatus, previous, event, 0)
+		})
+		if err != nil {
+			return changed, next, err
+		}
+
+		s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetEvent, event.ID.String(), &before, event)
+		s.publish()
+		changed++
+	}
+
+	return changed, next, nil
+}
+
+// RunStatusScheduler advances event statuses until ctx is done. It wakes up
+// when the next start or end time is reached, when events are edited or
+// published, and at least every interval.
+func (s *EventService) RunStatusScheduler(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		now := s.clock.Now()
+		changed, next, err := s.advanceStatuses(now)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to advance event statuses")
+		} else if changed > 0 {
+			log.Info().Int("count", changed).Msg("Advanced event statuses")
+		}
+
+		wait := interval
+		if !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// RunChangeFeed sends the changes committed to the outbox, by this process
+// or by others sharing its database, to watchers until ctx is done. It reads
+// the outbox at least every interval, and as soon as this process commits a
+// change.
+func (s *EventService) RunChangeFeed(ctx context.Context, interval time.Duration) {
+	s.broker.Run(ctx, interval)
+}
+
+// statusChange returns the change type of a time-based transition to a
+// status
+func statusChange(to models.EventStatus) models.ChangeType {
+	if to == models.StatusLive {
+		return models.ChangeStarted
+	}
+	return models.ChangeEnded
+}
+
+// recordChange records a change in the transaction of the mutation making
+// it, so that they all commit or roll back together: the change is added to
+// the outbox, and the state of the event after it is appended as a revision
+// with the given action. An event without revisions predates revision
+// tracking, so its state before the change is first recorded as a baseline.
+func (s *EventService) recordChange(ctx context.Context, tx *store.Store, changeType models.ChangeType, action string, before, event *models.LiveEvent, revertedFrom int) error {
+	now := s.clock.Now()
+
+	if err := tx.Outbox.Create(models.NewOutboxEntry(changeType, event, now)); err != nil {
+		return fmt.Errorf("failed to record event change: %w", err)
+	}
+
+	if before != nil && action != models.RevisionActionCreate {
+		latest, err := tx.EventRevisions.List(event.ID, 0, 1)
+		if err != nil {
+			return fmt.Errorf("failed to read event revisions: %w", err)
+		}
+		if len(latest) == 0 {
+			baseline := &models.EventRevision{
+				EventID:   event.ID,
+				Action:    models.RevisionActionBaseline,
+				Event:     *before,
+				CreatedAt: now,
+			}
+			if err := tx.EventRevisions.Create(baseline); err != nil {
+				return fmt.Errorf("failed to record event baseline revision: %w", err)
+			}
+		}
+	}
+
+	actor := audit.ActorFromContext(ctx)
+	revision := &models.EventRevision{
+		EventID:        event.ID,
+		Action:         action,
+		Event:          *event,
+		AuthorUserID:   actor.UserID,
+		AuthorAPIKeyID: actor.APIKeyID,
+		RevertedFrom:   revertedFrom,
+		CreatedAt:      now,
+	}
+	if err := tx.EventRevisions.Create(revision); err != nil {
+		return fmt.Errorf("failed to record event revision: %w", err)
 	}
 
 	return nil
 }
 
-// ListEvents retrieves all events, optionally filtered by active status
-func (s *EventService) ListEvents(activeOnly bool) ([]*models.LiveEvent, error) {
-	// Get from database
-	events, err := s.eventRepo.List(activeOnly)
+// publish prompts the change feed of watchers and the outbox to pick up
+// committed changes
+func (s *EventService) publish() {
+	s.broker.Wake()
+	if s.outbox != nil {
+		s.outbox.Wake()
+	}
+}
+
+// wakeScheduler prompts the status scheduler to look for new deadlines
+func (s *EventService) wakeScheduler() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// WatchEvents starts a watch on event changes. A positive since resumes
+// after that sequence number when the changes are still available; otherwise
+// the watch starts with a snapshot of all events. Changes committed while the
+// snapshot is read may appear in both, so watchers should apply changes as
+// idempotent upserts.
+func (s *EventService) WatchEvents(since int64) (*EventWatch, error) {
+	sub, replay, seq, resumed, err := s.broker.Subscribe(since)
+	if err != nil {
+		return nil, err
+	}
+	watch := &EventWatch{Changes: sub.Changes, sub: sub}
+
+	if resumed {
+		watch.Initial = replay
+		return watch, nil
+	}
+
+	events, err := s.eventRepo.List()
 	if err != nil {
+		sub.Close()
 		return nil, fmt.Errorf("failed to list events: %w", err)
 	}
 
-	return events, nil
+	now := s.clock.Now()
+	for _, event := range events {
+		watch.Initial = append(watch.Initial, models.EventChange{
+			Seq:     seq,
+			Type:    models.ChangeSnapshot,
+			EventID: event.ID.String(),
+			Event:   event,
+			Time:    now,
+		})
+	}
+	watch.Initial = append(watch.Initial, models.EventChange{
+		Seq:  seq,
+		Type: models.ChangeSnapshotComplete,
+		Time: now,
+	})
+
+	return watch, nil
+}
+
+// ListEvents retrieves a page of events matching the filter in the given
+// order, and the token for the next page (empty on the last page)
+func (s *EventService) ListEvents(filter models.EventFilter, sort models.EventSort, pageSize int, pageToken string) ([]*models.LiveEvent, string, error) {
+	if sort == "" {
+		sort = models.DefaultEventSort
+	}
+	if !sort.IsValid() {
+		return nil, "", fmt.Errorf("%w: %q", models.ErrInvalidSort, sort)
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var after *models.EventCursor
+	if pageToken != "" {
+		var err error
+		after, err = decodePageToken(pageToken, sort)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Recurring events are filtered after they are read, so keep reading
+	// until the page is full or the listing is exhausted. One extra event
+	// tells whether another page exists.
+	var events []*models.LiveEvent
+	var cursors []models.EventCursor
+	for {
+		want := pageSize + 1 - len(events)
+		batch, batchCursors, err := s.eventRepo.ListPage(filter, sort, after, want)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for i, event := range batch {
+			if filter.Matches(event) {
+				events = append(events, event)
+				cursors = append(cursors, batchCursors[i])
+			}
+		}
+
+		if len(batch) < want || len(events) > pageSize {
+			break
+		}
+		after = &batchCursors[len(batchCursors)-1]
+	}
+
+	var nextPageToken string
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		nextPageToken = encodePageToken(sort, cursors[pageSize-1])
+	}
+
+	return events, nextPageToken, nil
+}
+
+// Now returns the current time by the clock of the service
+func (s *EventService) Now() time.Time {
+	return s.clock.Now()
+}
+
+// ActiveFilter returns the filter of the published events active at asOf, or
+// now when asOf is zero
+func (s *EventService) ActiveFilter(asOf time.Time) models.EventFilter {
+	if asOf.IsZero() {
+		asOf = s.clock.Now()
+	}
+
+	return models.EventFilter{
+		Statuses: []models.EventStatus{models.StatusScheduled, models.StatusLive},
+		ActiveAt: asOf,
+	}
+}
+
+// ListEligibleEvents retrieves a page of the published events active at asOf,
+// or now when asOf is zero, whose targeting matches the player, in start
+// time order
+func (s *EventService) ListEligibleEvents(player *targeting.Player, asOf time.Time, pageSize int, pageToken string) ([]*models.LiveEvent, string, error) {
+	filter := s.ActiveFilter(asOf)
+	filter.Player = player
+
+	return s.ListEvents(filter, models.DefaultEventSort, pageSize, pageToken)
+}
+
+// ListOccurrences expands the occurrences of an event overlapping [from, to),
+// returning at most limit entries
+func (s *EventService) ListOccurrences(id string, from, to time.Time, limit int) ([]models.Occurrence, error) {
+	if !from.Before(to) {
+		return nil, models.ErrInvalidTimeRange
+	}
+
+	event, err := s.GetEvent(id)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := event.Occurrences(from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return occurrences, nil
+}
+
+// pageToken is the decoded form of an event listing page token
+type pageToken struct {
+	Sort   models.EventSort   `json:"s"`
+	Cursor models.EventCursor `json:"c"`
+}
+
+// encodePageToken builds the opaque token continuing a listing after cursor
+func encodePageToken(sort models.EventSort, cursor models.EventCursor) string {
+	data, _ := json.Marshal(pageToken{Sort: sort, Cursor: cursor})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageToken parses a page token, which must come from a listing with
+// the same sort order
+func decodePageToken(token string, sort models.EventSort) (*models.EventCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, models.ErrInvalidPageToken
+	}
+
+	var decoded pageToken
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Cursor.ID == "" {
+		return nil, models.ErrInvalidPageToken
+	}
+	if decoded.Sort != sort {
+		return nil, fmt.Errorf("%w: token was issued for sort order %q", models.ErrInvalidPageToken, decoded.Sort)
+	}
+
+	return &decoded.Cursor, nil
 }
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/service"
+	"github.com/tombombadilom/liveops/internal/store"
+	"github.com/tombombadilom/liveops/internal/store/memory"
+)
+
+// newEventService returns an event service on a fresh memory store
+func newEventService(clk clock.Clock) (*service.EventService, *store.Store) {
+	repos := memory.New()
+	auditService := audit.NewAuditService(repos.Audit)
+	rewardService := service.NewRewardService(repos.RewardCatalog, auditService)
+	return service.NewEventService(repos.Events, repos.EventRevisions, repos.Outbox, repos.Transactor, rewardService, auditService, clk, nil), repos
+}
+
+// statusChanges returns the types of the started and ended changes recorded
+// in the outbox, oldest first
+func statusChanges(t *testing.T, repos *store.Store) []models.ChangeType {
+	t.Helper()
+
+	entries, err := repos.Outbox.ListAfter(0, 100)
+	if err != nil {
+		t.Fatalf("failed to list outbox entries: %v", err)
+	}
+
+	var changes []models.ChangeType
+	for _, entry := range entries {
+		if entry.Type == models.ChangeStarted || entry.Type == models.ChangeEnded {
+			changes = append(changes, entry.Type)
+		}
+	}
+	return changes
+}
+
+func TestRecurringEventsSignalTheSeriesLifecycle(t *testing.T) {
+	start := time.Date(2025, 6, 2, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		recurrence string
+		ends       bool
+	}{
+		{"count", "RRULE:FREQ=DAILY;COUNT=3", true},
+		{"until", "RRULE:FREQ=DAILY;UNTIL=20250604T180000Z", true},
+		{"endless", "RRULE:FREQ=DAILY", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eventService, repos := newEventService(clock.Fixed(start.Add(-time.Hour)))
+
+			event, err := eventService.CreateEvent(context.Background(), models.EventFields{
+				Title:      "Daily arena",
+				StartTime:  start,
+				EndTime:    start.Add(time.Hour),
+				Recurrence: test.recurrence,
+			})
+			if err != nil {
+				t.Fatalf("CreateEvent failed: %v", err)
+			}
+			if _, err := eventService.PublishEvent(context.Background(), event.ID.String()); err != nil {
+				t.Fatalf("PublishEvent failed: %v", err)
+			}
+
+			// Walk through the first occurrences, between them and past the
+			// last one
+			var steps []time.Time
+			for day := 0; day < 5; day++ {
+				occurrence := start.AddDate(0, 0, day)
+				steps = append(steps, occurrence, occurrence.Add(30*time.Minute), occurrence.Add(2*time.Hour))
+			}
+			for _, now := range steps {
+				if _, err := eventService.AdvanceStatuses(now); err != nil {
+					t.Fatalf("AdvanceStatuses(%v) failed: %v", now, err)
+				}
+			}
+
+			want := []models.ChangeType{models.ChangeStarted}
+			wantStatus := models.StatusLive
+			if test.ends {
+				want = append(want, models.ChangeEnded)
+				wantStatus = models.StatusEnded
+			}
+			if got := statusChanges(t, repos); !reflect.DeepEqual(got, want) {
+				t.Errorf("got status changes %v, want %v", got, want)
+			}
+
+			event, err = eventService.GetEvent(event.ID.String())
+			if err != nil {
+				t.Fatalf("GetEvent failed: %v", err)
+			}
+			if event.Status != wantStatus {
+				t.Errorf("got status %s, want %s", event.Status, wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// catalogIDPattern restricts catalog IDs to lowercase identifiers
+var catalogIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// RewardService manages the reward catalog and validates event rewards
+// against it
+type RewardService struct {
+	catalogRepo  store.RewardCatalogRepository
+	auditService *audit.AuditService
+}
+
+// NewRewardService creates a new reward service
+func NewRewardService(catalogRepo store.RewardCatalogRepository, auditService *audit.AuditService) *RewardService {
+	return &RewardService{
+		catalogRepo:  catalogRepo,
+		auditService: auditService,
+	}
+}
+
+// ListCatalog returns every item and currency events may grant
+func (s *RewardService) ListCatalog() ([]*models.RewardCatalogItem, error) {
+	return s.catalogRepo.List()
+}
+
+// AddCatalogItem adds an item or currency to the catalog
+func (s *RewardService) AddCatalogItem(ctx context.Context, id string, rewardType models.RewardType, name string) (*models.RewardCatalogItem, error) {
+	if !catalogIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: catalog ID %q must be a lowercase identifier", models.ErrInvalidRewards, id)
+	}
+	if !rewardType.IsValid() {
+		return nil, fmt.Errorf("%w: unknown type %q", models.ErrInvalidRewards, rewardType)
+	}
+
+	item := &models.RewardCatalogItem{
+		ID:        id,
+		Type:      rewardType,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.catalogRepo.Create(item); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetReward, item.ID, nil, item)
+
+	return item, nil
+}
+
+// RemoveCatalogItem removes an item or currency from the catalog. Events that
+// already grant it keep it, but it can no longer be added to events.
+func (s *RewardService) RemoveCatalogItem(ctx context.Context, id string) error {
+	item, err := s.catalogRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.catalogRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetReward, item.ID, item, nil)
+
+	return nil
+}
+
+// ResolveRewards checks rewards against the catalog and fills in the type
+// of rewards that did not specify one
+func (s *RewardService) ResolveRewards(rewards []models.Reward) ([]models.Reward, error) {
+	resolved := make([]models.Reward, len(rewards))
+
+	for i, reward := range rewards {
+		if err := reward.Validate(); err != nil {
+			return nil, err
+		}
+
+		item, err := s.catalogRepo.GetByID(reward.ID)
+		if err != nil {
+			if err == models.ErrUnknownReward {
+				return nil, fmt.Errorf("%w: %q", models.ErrUnknownReward, reward.ID)
+			}
+			return nil, err
+		}
+
+		if reward.Type == "" {
+			reward.Type = item.Type
+		} else if reward.Type != item.Type {
+			return nil, fmt.Errorf("%w: %q has type %s, not %s", models.ErrInvalidRewards, reward.ID, item.Type, reward.Type)
+		}
+
+		resolved[i] = reward
+	}
+
+	return resolved, nil
+}
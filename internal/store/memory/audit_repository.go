@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"slices"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// AuditRepository stores the audit log in memory
+type AuditRepository struct {
+	data *data
+}
+
+// Create appends an entry to the audit log
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	entry.Seq = int64(len(r.data.audit)) + 1
+	r.data.audit = append(r.data.audit, copyAuditEntry(entry))
+	return nil
+}
+
+// List retrieves audit entries matching the filter, newest first. Only
+// entries older than beforeSeq are returned when it is positive.
+func (r *AuditRepository) List(filter models.AuditFilter, beforeSeq int64, limit int) ([]*models.AuditEntry, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var entries []*models.AuditEntry
+
+	for i := len(r.data.audit) - 1; i >= 0 && len(entries) < limit; i-- {
+		entry := r.data.audit[i]
+
+		if beforeSeq > 0 && entry.Seq >= beforeSeq {
+			continue
+		}
+		if filter.ActorUserID != nil && (entry.ActorUserID == nil || *entry.ActorUserID != *filter.ActorUserID) {
+			continue
+		}
+		if filter.TargetType != "" && entry.TargetType != filter.TargetType {
+			continue
+		}
+		if filter.TargetID != "" && entry.TargetID != filter.TargetID {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.CreatedAt.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !entry.CreatedAt.Before(filter.Until) {
+			continue
+		}
+
+		entries = append(entries, copyAuditEntry(entry))
+	}
+
+	return entries, nil
+}
+
+// copyAuditEntry returns a deep copy of an audit entry
+func copyAuditEntry(entry *models.AuditEntry) *models.AuditEntry {
+	c := *entry
+	if entry.ActorUserID != nil {
+		id := *entry.ActorUserID
+		c.ActorUserID = &id
+	}
+	if entry.ActorAPIKeyID != nil {
+		id := *entry.ActorAPIKeyID
+		c.ActorAPIKeyID = &id
+	}
+	c.Before = slices.Clone(entry.Before)
+	c.After = slices.Clone(entry.After)
+	return &c
+}
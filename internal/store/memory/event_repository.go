@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// EventRepository stores events in memory
+type EventRepository struct {
+	data *data
+}
+
+// Create adds a new event
+func (r *EventRepository) Create(event *models.LiveEvent) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.events[event.ID]; ok {
+		return fmt.Errorf("failed to create event: duplicate ID %s", event.ID)
+	}
+	if r.externalIDTaken(event) {
+		return models.ErrExternalIDExists
+	}
+
+	event.Version = 1
+	r.data.events[event.ID] = copyEvent(event)
+	return nil
+}
+
+// GetByID retrieves an event by its ID
+func (r *EventRepository) GetByID(id uuid.UUID) (*models.LiveEvent, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	event, ok := r.data.events[id]
+	if !ok {
+		return nil, models.ErrEventNotFound
+	}
+
+	return copyEvent(event), nil
+}
+
+// GetByExternalID retrieves the event with a non-empty external ID
+func (r *EventRepository) GetByExternalID(externalID string) (*models.LiveEvent, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	if externalID != "" {
+		for _, event := range r.data.events {
+			if event.ExternalID == externalID {
+				return copyEvent(event), nil
+			}
+		}
+	}
+
+	return nil, models.ErrEventNotFound
+}
+
+// externalIDTaken reports whether another event has the external ID of
+// event. The caller must hold the lock.
+func (r *EventRepository) externalIDTaken(event *models.LiveEvent) bool {
+	if event.ExternalID == "" {
+		return false
+	}
+	for _, other := range r.data.events {
+		if other.ID != event.ID && other.ExternalID == event.ExternalID {
+			return true
+		}
+	}
+	return false
+}
+
+// Update updates an existing event at the version of event, leaving its
+// status untouched
+func (r *EventRepository) Update(event *models.LiveEvent) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.events[event.ID]
+	if !ok {
+		return models.ErrEventNotFound
+	}
+	if stored.Version != event.Version {
+		return models.ErrVersionConflict
+	}
+	if r.externalIDTaken(event) {
+		return models.ErrExternalIDExists
+	}
+
+	event.Version++
+	updated := copyEvent(event)
+	updated.Status = stored.Status
+	r.data.events[event.ID] = updated
+	return nil
+}
+
+// UpdateStatus moves an event from one status to another, if it is still in
+// the expected status
+func (r *EventRepository) UpdateStatus(id uuid.UUID, from, to models.EventStatus) (int64, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	event, ok := r.data.events[id]
+	if !ok || event.Status != from {
+		return 0, models.ErrInvalidTransition
+	}
+
+	event.Status = to
+	event.Version++
+	return event.Version, nil
+}
+
+// Delete removes an event by its ID, if it is still at the given version
+func (r *EventRepository) Delete(id uuid.UUID, version int64) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	event, ok := r.data.events[id]
+	if !ok {
+		return models.ErrEventNotFound
+	}
+	if event.Version != version {
+		return models.ErrVersionConflict
+	}
+
+	delete(r.data.events, id)
+	return nil
+}
+
+// List retrieves all events ordered by start time
+func (r *EventRepository) List() ([]*models.LiveEvent, error) {
+	return r.listWhere(func(*models.LiveEvent) bool { return true }), nil
+}
+
+// ListByStatus retrieves all events in any of the given statuses ordered by
+// start time
+func (r *EventRepository) ListByStatus(statuses ...models.EventStatus) ([]*models.LiveEvent, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	return r.listWhere(func(event *models.LiveEvent) bool {
+		return slices.Contains(statuses, event.Status)
+	}), nil
+}
+
+// listWhere returns copies of the events accepted by keep, ordered by start
+// time
+func (r *EventRepository) listWhere(keep func(*models.LiveEvent) bool) []*models.LiveEvent {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var events []*models.LiveEvent
+	for _, event := range r.data.events {
+		if keep(event) {
+			events = append(events, copyEvent(event))
+		}
+	}
+
+	slices.SortFunc(events, func(a, b *models.LiveEvent) int {
+		return compareEvents(sortValue(a, models.SortStartTimeAsc), a.ID.String(), sortValue(b, models.SortStartTimeAsc), b.ID.String())
+	})
+
+	return events
+}
+
+// ListPage retrieves up to limit events matching the filter in the given
+// order, starting after the cursor when it is set. Like the database
+// backends, time filters only look at the stored start and end times, so
+// recurring events must still be checked with EventFilter.Matches.
+func (r *EventRepository) ListPage(filter models.EventFilter, sort models.EventSort, after *models.EventCursor, limit int) ([]*models.LiveEvent, []models.EventCursor, error) {
+	if !sort.IsValid() {
+		return nil, nil, fmt.Errorf("invalid sort order %q", sort)
+	}
+
+	title := models.FoldTitle(filter.Title)
+	direction := 1
+	if sort.Descending() {
+		direction = -1
+	}
+
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	type candidate struct {
+		event  *models.LiveEvent
+		cursor models.EventCursor
+	}
+	var candidates []candidate
+
+	for _, event := range r.data.events {
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, event.Status) {
+			continue
+		}
+		if !filter.StartsAfter.IsZero() && event.StartTime.Before(filter.StartsAfter) {
+			continue
+		}
+		if !filter.EndsBefore.IsZero() && event.Recurrence == "" && event.EndTime.After(filter.EndsBefore) {
+			continue
+		}
+		if !filter.ActiveAt.IsZero() && (event.StartTime.After(filter.ActiveAt) ||
+			(event.Recurrence == "" && event.EndTime.Before(filter.ActiveAt))) {
+			continue
+		}
+		if title != "" && !strings.Contains(models.FoldTitle(event.Title), title) {
+			continue
+		}
+
+		cursor := models.EventCursor{Value: sortValue(event, sort), ID: event.ID.String()}
+		if after != nil && direction*compareEvents(cursor.Value, cursor.ID, after.Value, after.ID) <= 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{event: event, cursor: cursor})
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return direction * compareEvents(a.cursor.Value, a.cursor.ID, b.cursor.Value, b.cursor.ID)
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	events := make([]*models.LiveEvent, len(candidates))
+	cursors := make([]models.EventCursor, len(candidates))
+	for i, c := range candidates {
+		events[i] = copyEvent(c.event)
+		cursors[i] = c.cursor
+	}
+
+	return events, cursors, nil
+}
+
+// sortValue returns the value an event is sorted by
+func sortValue(event *models.LiveEvent, sort models.EventSort) string {
+	switch sort.Field() {
+	case "end_time":
+		return event.EndTime.UTC().Format(sortTimestampFormat)
+	case "title":
+		return event.Title
+	default:
+		return event.StartTime.UTC().Format(sortTimestampFormat)
+	}
+}
+
+// compareEvents orders events by sort value, then by ID
+func compareEvents(valueA, idA, valueB, idB string) int {
+	return cmp.Or(cmp.Compare(valueA, valueB), cmp.Compare(idA, idB))
+}
+
+// copyEvent returns a deep copy of an event
+func copyEvent(event *models.LiveEvent) *models.LiveEvent {
+	c := *event
+	c.StructuredRewards = slices.Clone(event.StructuredRewards)
+	return &c
+}
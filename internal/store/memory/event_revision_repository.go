@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// EventRevisionRepository stores event revisions in memory
+type EventRevisionRepository struct {
+	data *data
+}
+
+// Create appends a revision and sets its number
+func (r *EventRevisionRepository) Create(revision *models.EventRevision) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	revisions := r.data.revisions[revision.EventID]
+	revision.Revision = len(revisions) + 1
+	r.data.revisions[revision.EventID] = append(revisions, copyRevision(revision))
+	return nil
+}
+
+// Get retrieves a revision of an event
+func (r *EventRevisionRepository) Get(eventID uuid.UUID, revision int) (*models.EventRevision, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	revisions := r.data.revisions[eventID]
+	if revision < 1 || revision > len(revisions) {
+		return nil, models.ErrRevisionNotFound
+	}
+
+	return copyRevision(revisions[revision-1]), nil
+}
+
+// List retrieves revisions of an event, newest first. Only revisions older
+// than before are returned when it is positive.
+func (r *EventRevisionRepository) List(eventID uuid.UUID, before, limit int) ([]*models.EventRevision, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	revisions := r.data.revisions[eventID]
+	end := len(revisions)
+	if before > 0 && before-1 < end {
+		end = before - 1
+	}
+
+	var result []*models.EventRevision
+	for i := end - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, copyRevision(revisions[i]))
+	}
+
+	return result, nil
+}
+
+// copyRevision returns a deep copy of a revision
+func copyRevision(revision *models.EventRevision) *models.EventRevision {
+	c := *revision
+	c.Event = *copyEvent(&revision.Event)
+	if revision.AuthorUserID != nil {
+		id := *revision.AuthorUserID
+		c.AuthorUserID = &id
+	}
+	if revision.AuthorAPIKeyID != nil {
+		id := *revision.AuthorAPIKeyID
+		c.AuthorAPIKeyID = &id
+	}
+	return &c
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// idempotencyKey identifies the record of an idempotency key
+type idempotencyKey struct {
+	apiKeyID uuid.UUID
+	key      string
+}
+
+// IdempotencyRepository stores idempotency records in memory
+type IdempotencyRepository struct {
+	data *data
+}
+
+// Create adds a record unless its key is taken
+func (r *IdempotencyRepository) Create(record *models.IdempotencyRecord) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key := idempotencyKey{record.APIKeyID, record.Key}
+	if _, ok := r.data.idempotency[key]; ok {
+		return models.ErrIdempotencyExists
+	}
+
+	r.data.idempotency[key] = copyIdempotencyRecord(record)
+	return nil
+}
+
+// Get retrieves the record of a key of an API key
+func (r *IdempotencyRepository) Get(apiKeyID uuid.UUID, key string) (*models.IdempotencyRecord, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	record, ok := r.data.idempotency[idempotencyKey{apiKeyID, key}]
+	if !ok {
+		return nil, models.ErrIdempotencyMissing
+	}
+
+	return copyIdempotencyRecord(record), nil
+}
+
+// Complete saves the response of a record
+func (r *IdempotencyRepository) Complete(record *models.IdempotencyRecord) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.idempotency[idempotencyKey{record.APIKeyID, record.Key}]
+	if !ok {
+		return models.ErrIdempotencyMissing
+	}
+
+	stored.Completed = true
+	stored.StatusCode = record.StatusCode
+	stored.Header = maps.Clone(record.Header)
+	stored.Body = slices.Clone(record.Body)
+	stored.ExpiresAt = record.ExpiresAt
+	return nil
+}
+
+// Delete removes the record of a key of an API key
+func (r *IdempotencyRepository) Delete(apiKeyID uuid.UUID, key string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	k := idempotencyKey{apiKeyID, key}
+	if _, ok := r.data.idempotency[k]; !ok {
+		return models.ErrIdempotencyMissing
+	}
+
+	delete(r.data.idempotency, k)
+	return nil
+}
+
+// PurgeExpired removes the records that expired before the given time
+func (r *IdempotencyRepository) PurgeExpired(before time.Time) (int, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	purged := 0
+	for key, record := range r.data.idempotency {
+		if record.ExpiresAt.Before(before) {
+			delete(r.data.idempotency, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// copyIdempotencyRecord returns a copy of a record that shares no state
+// with it
+func copyIdempotencyRecord(record *models.IdempotencyRecord) *models.IdempotencyRecord {
+	c := *record
+	c.Header = maps.Clone(record.Header)
+	c.Body = slices.Clone(record.Body)
+	return &c
+}
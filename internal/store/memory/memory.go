@@ -0,0 +1,222 @@
+// Package memory implements the store repositories in process memory. Data
+// is lost when the process exits, which suits tests, demos and local
+// development. Repositories hand out copies, so callers never share state
+// with the store.
+package memory
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// sortTimestampFormat is a fixed-width UTC layout whose lexical order matches
+// chronological order, used for the sort values of event cursors
+const sortTimestampFormat = "2006-01-02T15:04:05.000000000Z"
+
+// data gives repositories access to the contents of a store. A single lock
+// guards it, since users and API keys reference each other; transactions
+// hold it throughout and hand their repositories a view that does not lock.
+type data struct {
+	mu locker
+	*state
+}
+
+// locker is the lock guarding a store
+type locker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+// noLock is the locker of repositories in a transaction, which already holds
+// the store's lock
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
+// state holds the contents of a store
+type state struct {
+	events     map[uuid.UUID]*models.LiveEvent
+	revisions  map[uuid.UUID][]*models.EventRevision
+	users      map[uuid.UUID]*models.User
+	apiKeys    map[uuid.UUID]*apiKeyRecord
+	audit      []*models.AuditEntry
+	rateLimits map[rateLimitKey]*models.RateLimitOverride
+	rewards    map[string]*models.RewardCatalogItem
+
+	webhooks    []*models.WebhookSubscription
+	deliveries  []*models.WebhookDelivery
+	deliverySeq int64
+	attempts    map[uuid.UUID][]*models.WebhookAttempt
+
+	outbox    []*models.OutboxEntry
+	outboxSeq int64
+
+	idempotency map[idempotencyKey]*models.IdempotencyRecord
+}
+
+// New creates an empty store holding only the default admin user and its
+// legacy API key
+func New() *store.Store {
+	d := &data{
+		mu: &sync.RWMutex{},
+		state: &state{
+			events:     make(map[uuid.UUID]*models.LiveEvent),
+			revisions:  make(map[uuid.UUID][]*models.EventRevision),
+			users:      make(map[uuid.UUID]*models.User),
+			apiKeys:    make(map[uuid.UUID]*apiKeyRecord),
+			rateLimits: make(map[rateLimitKey]*models.RateLimitOverride),
+			rewards:    make(map[string]*models.RewardCatalogItem),
+			attempts:   make(map[uuid.UUID][]*models.WebhookAttempt),
+
+			idempotency: make(map[idempotencyKey]*models.IdempotencyRecord),
+		},
+	}
+	d.seedAdmin()
+
+	s := newStore(d)
+	s.Transactor = &transactor{data: d}
+	return s
+}
+
+// newStore returns the repositories reading and writing d
+func newStore(d *data) *store.Store {
+	return &store.Store{
+		Events:         &EventRepository{data: d},
+		EventRevisions: &EventRevisionRepository{data: d},
+		Users:          &UserRepository{data: d},
+		APIKeys:        &APIKeyRepository{data: d},
+		Audit:          &AuditRepository{data: d},
+		RateLimits:     &RateLimitRepository{data: d},
+		RewardCatalog:  &RewardCatalogRepository{data: d},
+		Webhooks:       &WebhookRepository{data: d},
+		Outbox:         &OutboxRepository{data: d},
+		Idempotency:    &IdempotencyRepository{data: d},
+	}
+}
+
+// transactor runs transactions on a store. A transaction holds the store's
+// lock until it ends, so transactions and other operations see none of its
+// changes before it commits, and restores a copy of the contents taken when
+// it began to roll back.
+type transactor struct {
+	data *data
+}
+
+// InTx calls fn with repositories whose changes are discarded unless it
+// returns nil
+func (t *transactor) InTx(fn func(tx *store.Store) error) (err error) {
+	t.data.mu.Lock()
+	defer t.data.mu.Unlock()
+
+	snapshot := t.data.state.clone()
+	committed := false
+	defer func() {
+		if !committed {
+			*t.data.state = *snapshot
+		}
+	}()
+
+	tx := newStore(&data{mu: noLock{}, state: t.data.state})
+	tx.Transactor = store.Joined(tx)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
+// clone returns a copy of the state that later changes to s do not affect.
+// Records that repositories modify in place are copied; append-only records
+// are shared.
+func (s *state) clone() *state {
+	c := &state{
+		events:      make(map[uuid.UUID]*models.LiveEvent, len(s.events)),
+		revisions:   make(map[uuid.UUID][]*models.EventRevision, len(s.revisions)),
+		users:       make(map[uuid.UUID]*models.User, len(s.users)),
+		apiKeys:     make(map[uuid.UUID]*apiKeyRecord, len(s.apiKeys)),
+		audit:       slices.Clone(s.audit),
+		rateLimits:  make(map[rateLimitKey]*models.RateLimitOverride, len(s.rateLimits)),
+		rewards:     make(map[string]*models.RewardCatalogItem, len(s.rewards)),
+		webhooks:    slices.Clone(s.webhooks),
+		deliveries:  make([]*models.WebhookDelivery, len(s.deliveries)),
+		deliverySeq: s.deliverySeq,
+		attempts:    make(map[uuid.UUID][]*models.WebhookAttempt, len(s.attempts)),
+		outbox:      make([]*models.OutboxEntry, len(s.outbox)),
+		outboxSeq:   s.outboxSeq,
+		idempotency: make(map[idempotencyKey]*models.IdempotencyRecord, len(s.idempotency)),
+	}
+
+	for id, event := range s.events {
+		c.events[id] = copyEvent(event)
+	}
+	for id, revisions := range s.revisions {
+		c.revisions[id] = slices.Clone(revisions)
+	}
+	for id, user := range s.users {
+		userCopy := *user
+		c.users[id] = &userCopy
+	}
+	for id, record := range s.apiKeys {
+		recordCopy := *record
+		c.apiKeys[id] = &recordCopy
+	}
+	for key, override := range s.rateLimits {
+		overrideCopy := *override
+		c.rateLimits[key] = &overrideCopy
+	}
+	for id, item := range s.rewards {
+		itemCopy := *item
+		c.rewards[id] = &itemCopy
+	}
+	for i, delivery := range s.deliveries {
+		c.deliveries[i] = copyDelivery(delivery)
+	}
+	for id, attempts := range s.attempts {
+		c.attempts[id] = slices.Clone(attempts)
+	}
+	for i, entry := range s.outbox {
+		c.outbox[i] = copyOutboxEntry(entry)
+	}
+	for key, record := range s.idempotency {
+		c.idempotency[key] = copyIdempotencyRecord(record)
+	}
+
+	return c
+}
+
+// seedAdmin creates the default admin user and API key, like the database
+// backends do on an empty database
+func (d *data) seedAdmin() {
+	now := time.Now().UTC()
+	userID := uuid.MustParse(store.AdminUserID)
+
+	d.users[userID] = &models.User{
+		ID:        userID,
+		Username:  store.AdminUsername,
+		Role:      models.RoleAdmin,
+		CreatedAt: now,
+	}
+
+	keyID := uuid.MustParse(store.AdminAPIKeyID)
+	d.apiKeys[keyID] = &apiKeyRecord{
+		key: models.APIKey{
+			ID:        keyID,
+			UserID:    userID,
+			CreatedAt: now,
+			ExpiresAt: now.AddDate(0, 0, 365),
+			LastUsed:  now,
+		},
+		legacyKey: store.AdminAPIKey,
+	}
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// OutboxRepository stores outbox entries in memory
+type OutboxRepository struct {
+	data *data
+}
+
+// Create adds an entry and sets its sequence number
+func (r *OutboxRepository) Create(entry *models.OutboxEntry) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.data.outboxSeq++
+	entry.Seq = r.data.outboxSeq
+	r.data.outbox = append(r.data.outbox, copyOutboxEntry(entry))
+	return nil
+}
+
+// Get retrieves an entry by its sequence number
+func (r *OutboxRepository) Get(seq int64) (*models.OutboxEntry, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	if entry := r.find(seq); entry != nil {
+		return copyOutboxEntry(entry), nil
+	}
+
+	return nil, models.ErrOutboxNotFound
+}
+
+// Update saves the progress of an entry
+func (r *OutboxRepository) Update(entry *models.OutboxEntry) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored := r.find(entry.Seq)
+	if stored == nil {
+		return models.ErrOutboxNotFound
+	}
+
+	stored.Status = entry.Status
+	stored.Attempts = entry.Attempts
+	stored.NextAttemptAt = entry.NextAttemptAt
+	stored.LastError = entry.LastError
+	stored.UpdatedAt = entry.UpdatedAt
+	return nil
+}
+
+// ClaimDue retrieves up to limit pending entries due at now, in sequence
+// order, leaving out those behind an earlier undelivered entry of their event,
+// and leases them until leaseUntil
+func (r *OutboxRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]*models.OutboxEntry, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	var entries []*models.OutboxEntry
+	blocked := make(map[uuid.UUID]bool)
+
+	for _, entry := range r.data.outbox {
+		if len(entries) >= limit {
+			break
+		}
+		if entry.Status == models.OutboxDelivered {
+			continue
+		}
+		if blocked[entry.EventID] {
+			continue
+		}
+		blocked[entry.EventID] = true
+		if entry.Status != models.OutboxPending {
+			continue
+		}
+
+		if !entry.NextAttemptAt.After(now) {
+			entry.NextAttemptAt = leaseUntil
+			entries = append(entries, copyOutboxEntry(entry))
+		}
+	}
+
+	return entries, nil
+}
+
+// List retrieves entries matching the filter, newest first. Only entries
+// older than beforeSeq are returned when it is positive.
+func (r *OutboxRepository) List(filter models.OutboxFilter, beforeSeq int64, limit int) ([]*models.OutboxEntry, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var entries []*models.OutboxEntry
+
+	for i := len(r.data.outbox) - 1; i >= 0 && len(entries) < limit; i-- {
+		entry := r.data.outbox[i]
+
+		if beforeSeq > 0 && entry.Seq >= beforeSeq {
+			continue
+		}
+		if filter.Status != "" && entry.Status != filter.Status {
+			continue
+		}
+		if filter.EventID != nil && entry.EventID != *filter.EventID {
+			continue
+		}
+
+		entries = append(entries, copyOutboxEntry(entry))
+	}
+
+	return entries, nil
+}
+
+// ListAfter retrieves up to limit entries newer than afterSeq, oldest first
+func (r *OutboxRepository) ListAfter(afterSeq int64, limit int) ([]*models.OutboxEntry, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var entries []*models.OutboxEntry
+
+	for _, entry := range r.data.outbox {
+		if len(entries) >= limit {
+			break
+		}
+		if entry.Seq > afterSeq {
+			entries = append(entries, copyOutboxEntry(entry))
+		}
+	}
+
+	return entries, nil
+}
+
+// PurgeDelivered removes the delivered entries last updated before the
+// given time
+func (r *OutboxRepository) PurgeDelivered(before time.Time) (int, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	count := len(r.data.outbox)
+	r.data.outbox = slices.DeleteFunc(r.data.outbox, func(entry *models.OutboxEntry) bool {
+		return entry.Status == models.OutboxDelivered && entry.UpdatedAt.Before(before)
+	})
+
+	return count - len(r.data.outbox), nil
+}
+
+// find returns the stored entry with the given sequence number, or nil. The
+// caller must hold the lock.
+func (r *OutboxRepository) find(seq int64) *models.OutboxEntry {
+	i, found := slices.BinarySearchFunc(r.data.outbox, seq, func(entry *models.OutboxEntry, seq int64) int {
+		switch {
+		case entry.Seq < seq:
+			return -1
+		case entry.Seq > seq:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if !found {
+		return nil
+	}
+	return r.data.outbox[i]
+}
+
+// copyOutboxEntry returns a deep copy of an entry
+func copyOutboxEntry(entry *models.OutboxEntry) *models.OutboxEntry {
+	c := *entry
+	if entry.Event != nil {
+		c.Event = copyEvent(entry.Event)
+	}
+	return &c
+}
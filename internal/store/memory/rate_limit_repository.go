@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// rateLimitKey identifies the subject of a rate limit override
+type rateLimitKey struct {
+	subjectType models.RateLimitSubject
+	subject     string
+}
+
+// RateLimitRepository stores rate limit overrides in memory
+type RateLimitRepository struct {
+	data *data
+}
+
+// List retrieves all rate limit overrides
+func (r *RateLimitRepository) List() ([]*models.RateLimitOverride, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var overrides []*models.RateLimitOverride
+	for _, override := range r.data.rateLimits {
+		c := *override
+		overrides = append(overrides, &c)
+	}
+
+	slices.SortFunc(overrides, func(a, b *models.RateLimitOverride) int {
+		return cmp.Or(cmp.Compare(a.SubjectType, b.SubjectType), cmp.Compare(a.Subject, b.Subject))
+	})
+
+	return overrides, nil
+}
+
+// Get retrieves the override for a subject
+func (r *RateLimitRepository) Get(subjectType models.RateLimitSubject, subject string) (*models.RateLimitOverride, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	override, ok := r.data.rateLimits[rateLimitKey{subjectType, subject}]
+	if !ok {
+		return nil, models.ErrRateLimitNotFound
+	}
+
+	c := *override
+	return &c, nil
+}
+
+// Set creates or replaces the override for a subject
+func (r *RateLimitRepository) Set(override *models.RateLimitOverride) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	c := *override
+	r.data.rateLimits[rateLimitKey{override.SubjectType, override.Subject}] = &c
+	return nil
+}
+
+// Delete removes the override for a subject
+func (r *RateLimitRepository) Delete(subjectType models.RateLimitSubject, subject string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key := rateLimitKey{subjectType, subject}
+	if _, ok := r.data.rateLimits[key]; !ok {
+		return models.ErrRateLimitNotFound
+	}
+
+	delete(r.data.rateLimits, key)
+	return nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// RewardCatalogRepository stores the reward catalog in memory
+type RewardCatalogRepository struct {
+	data *data
+}
+
+// Create adds an item or currency to the catalog
+func (r *RewardCatalogRepository) Create(item *models.RewardCatalogItem) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.rewards[item.ID]; ok {
+		return models.ErrRewardExists
+	}
+
+	c := *item
+	r.data.rewards[item.ID] = &c
+	return nil
+}
+
+// GetByID retrieves a catalog entry by its ID
+func (r *RewardCatalogRepository) GetByID(id string) (*models.RewardCatalogItem, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	item, ok := r.data.rewards[id]
+	if !ok {
+		return nil, models.ErrUnknownReward
+	}
+
+	c := *item
+	return &c, nil
+}
+
+// List retrieves the whole catalog ordered by ID
+func (r *RewardCatalogRepository) List() ([]*models.RewardCatalogItem, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var items []*models.RewardCatalogItem
+	for _, item := range r.data.rewards {
+		c := *item
+		items = append(items, &c)
+	}
+
+	slices.SortFunc(items, func(a, b *models.RewardCatalogItem) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return items, nil
+}
+
+// Delete removes an entry from the catalog. Events that already grant it
+// are left unchanged.
+func (r *RewardCatalogRepository) Delete(id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.rewards[id]; !ok {
+		return models.ErrUnknownReward
+	}
+
+	delete(r.data.rewards, id)
+	return nil
+}
@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// UserRepository stores users in memory
+type UserRepository struct {
+	data *data
+}
+
+// CreateUser adds a new user. Usernames are unique.
+func (r *UserRepository) CreateUser(user *models.User) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.users[user.ID]; ok {
+		return fmt.Errorf("failed to create user: duplicate ID %s", user.ID)
+	}
+
+	for _, existing := range r.data.users {
+		if existing.Username == user.Username {
+			return models.ErrUsernameExists
+		}
+	}
+
+	c := *user
+	r.data.users[user.ID] = &c
+	return nil
+}
+
+// GetUserByID retrieves a user by ID
+func (r *UserRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	user, ok := r.data.users[id]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	c := *user
+	return &c, nil
+}
+
+// GetUserByUsername retrieves a user by username
+func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, user := range r.data.users {
+		if user.Username == username {
+			c := *user
+			return &c, nil
+		}
+	}
+
+	return nil, models.ErrUserNotFound
+}
+
+// ListUsers retrieves all users ordered by username
+func (r *UserRepository) ListUsers() ([]*models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.data.users))
+	for _, user := range r.data.users {
+		c := *user
+		users = append(users, &c)
+	}
+
+	slices.SortFunc(users, func(a, b *models.User) int {
+		return cmp.Compare(a.Username, b.Username)
+	})
+
+	return users, nil
+}
+
+// UpdateUserRole changes the role of a user
+func (r *UserRepository) UpdateUserRole(id uuid.UUID, role models.Role) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	user, ok := r.data.users[id]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+
+	user.Role = role
+	return nil
+}
+
+// CountUsersByRole returns the number of users with the given role
+func (r *UserRepository) CountUsersByRole(role models.Role) (int, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	count := 0
+	for _, user := range r.data.users {
+		if user.Role == role {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// DeleteUser removes a user by ID. Their API keys are removed with them.
+func (r *UserRepository) DeleteUser(id uuid.UUID) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.users[id]; !ok {
+		return models.ErrUserNotFound
+	}
+
+	delete(r.data.users, id)
+	for keyID, record := range r.data.apiKeys {
+		if record.key.UserID == id {
+			delete(r.data.apiKeys, keyID)
+		}
+	}
+
+	return nil
+}
+
+// apiKeyRecord is a stored API key, along with the plaintext of legacy keys
+// that have not been hashed yet
+type apiKeyRecord struct {
+	key       models.APIKey
+	legacyKey string
+}
+
+// APIKeyRepository stores API keys in memory
+type APIKeyRepository struct {
+	data *data
+}
+
+// CreateAPIKey adds a new API key for an existing user. Only its prefix and
+// hash are stored.
+func (r *APIKeyRepository) CreateAPIKey(apiKey *models.APIKey) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.users[apiKey.UserID]; !ok {
+		return models.ErrUserNotFound
+	}
+
+	for id, record := range r.data.apiKeys {
+		if id == apiKey.ID || (apiKey.KeyHash != "" && record.key.KeyHash == apiKey.KeyHash) {
+			return models.ErrAPIKeyExists
+		}
+	}
+
+	stored := copyAPIKey(apiKey)
+	stored.Key = ""
+	r.data.apiKeys[apiKey.ID] = &apiKeyRecord{key: *stored}
+	return nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of its key string
+func (r *APIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	if keyHash != "" {
+		for _, record := range r.data.apiKeys {
+			if record.key.KeyHash == keyHash {
+				return copyAPIKey(&record.key), nil
+			}
+		}
+	}
+
+	return nil, models.ErrInvalidAPIKey
+}
+
+// GetAPIKeyByID retrieves an API key by ID
+func (r *APIKeyRepository) GetAPIKeyByID(id uuid.UUID) (*models.APIKey, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	record, ok := r.data.apiKeys[id]
+	if !ok {
+		return nil, models.ErrAPIKeyNotFound
+	}
+
+	return copyAPIKey(&record.key), nil
+}
+
+// ListLegacyAPIKeys returns the plaintext of keys that are not hashed yet,
+// keyed by API key ID
+func (r *APIKeyRepository) ListLegacyAPIKeys() (map[uuid.UUID]string, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	keys := make(map[uuid.UUID]string)
+	for id, record := range r.data.apiKeys {
+		if record.legacyKey != "" {
+			keys[id] = record.legacyKey
+		}
+	}
+
+	return keys, nil
+}
+
+// SetAPIKeyHash stores the prefix and hash of a legacy key and erases its plaintext
+func (r *APIKeyRepository) SetAPIKeyHash(id uuid.UUID, prefix, keyHash string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if record, ok := r.data.apiKeys[id]; ok {
+		record.key.Prefix = prefix
+		record.key.KeyHash = keyHash
+		record.legacyKey = ""
+	}
+
+	return nil
+}
+
+// UpdateAPIKeyLastUsed updates the last used time of an API key
+func (r *APIKeyRepository) UpdateAPIKeyLastUsed(id uuid.UUID, lastUsed time.Time) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if record, ok := r.data.apiKeys[id]; ok {
+		record.key.LastUsed = lastUsed
+	}
+
+	return nil
+}
+
+// DeleteAPIKey removes an API key by ID
+func (r *APIKeyRepository) DeleteAPIKey(id uuid.UUID) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.apiKeys[id]; !ok {
+		return models.ErrAPIKeyNotFound
+	}
+
+	delete(r.data.apiKeys, id)
+	return nil
+}
+
+// ListAPIKeysByUserID retrieves all API keys of a user, newest first
+func (r *APIKeyRepository) ListAPIKeysByUserID(userID uuid.UUID) ([]*models.APIKey, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var apiKeys []*models.APIKey
+	for _, record := range r.data.apiKeys {
+		if record.key.UserID == userID {
+			apiKeys = append(apiKeys, copyAPIKey(&record.key))
+		}
+	}
+
+	slices.SortFunc(apiKeys, func(a, b *models.APIKey) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return apiKeys, nil
+}
+
+// copyAPIKey returns a deep copy of an API key
+func copyAPIKey(apiKey *models.APIKey) *models.APIKey {
+	c := *apiKey
+	c.Scopes = slices.Clone(apiKey.Scopes)
+	if len(c.Scopes) == 0 {
+		c.Scopes = nil
+	}
+	return &c
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// WebhookRepository stores webhook subscriptions, deliveries and attempts in
+// memory
+type WebhookRepository struct {
+	data *data
+}
+
+// CreateSubscription adds a subscription
+func (r *WebhookRepository) CreateSubscription(subscription *models.WebhookSubscription) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.data.webhooks = append(r.data.webhooks, copySubscription(subscription))
+	return nil
+}
+
+// GetSubscription retrieves a subscription by its ID
+func (r *WebhookRepository) GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, subscription := range r.data.webhooks {
+		if subscription.ID == id {
+			return copySubscription(subscription), nil
+		}
+	}
+
+	return nil, models.ErrWebhookNotFound
+}
+
+// ListSubscriptions retrieves every subscription, oldest first
+func (r *WebhookRepository) ListSubscriptions() ([]*models.WebhookSubscription, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	subscriptions := make([]*models.WebhookSubscription, len(r.data.webhooks))
+	for i, subscription := range r.data.webhooks {
+		subscriptions[i] = copySubscription(subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription along with its deliveries and
+// their attempts
+func (r *WebhookRepository) DeleteSubscription(id uuid.UUID) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	i := slices.IndexFunc(r.data.webhooks, func(subscription *models.WebhookSubscription) bool {
+		return subscription.ID == id
+	})
+	if i < 0 {
+		return models.ErrWebhookNotFound
+	}
+	r.data.webhooks = slices.Delete(r.data.webhooks, i, i+1)
+
+	r.data.deliveries = slices.DeleteFunc(r.data.deliveries, func(delivery *models.WebhookDelivery) bool {
+		if delivery.SubscriptionID != id {
+			return false
+		}
+		delete(r.data.attempts, delivery.ID)
+		return true
+	})
+
+	return nil
+}
+
+// CreateDelivery queues a delivery and sets its sequence number
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if !slices.ContainsFunc(r.data.webhooks, func(subscription *models.WebhookSubscription) bool {
+		return subscription.ID == delivery.SubscriptionID
+	}) {
+		return models.ErrWebhookNotFound
+	}
+
+	r.data.deliverySeq++
+	delivery.Seq = r.data.deliverySeq
+	r.data.deliveries = append(r.data.deliveries, copyDelivery(delivery))
+	return nil
+}
+
+// GetDelivery retrieves a delivery by its ID
+func (r *WebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	if delivery := r.findDelivery(id); delivery != nil {
+		return copyDelivery(delivery), nil
+	}
+
+	return nil, models.ErrDeliveryNotFound
+}
+
+// UpdateDelivery saves the progress of a delivery
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored := r.findDelivery(delivery.ID)
+	if stored == nil {
+		return models.ErrDeliveryNotFound
+	}
+
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastError = delivery.LastError
+	stored.UpdatedAt = delivery.UpdatedAt
+	return nil
+}
+
+// ClaimDueDeliveries retrieves up to limit pending deliveries due at now,
+// those due the longest first, and leases them until leaseUntil. They are
+// returned in queueing order.
+func (r *WebhookRepository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	var due []*models.WebhookDelivery
+	for _, delivery := range r.data.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	// Longest due first, by next attempt time and then by queueing order
+	slices.SortStableFunc(due, func(a, b *models.WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	slices.SortFunc(due, func(a, b *models.WebhookDelivery) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	deliveries := make([]*models.WebhookDelivery, len(due))
+	for i, delivery := range due {
+		delivery.NextAttemptAt = leaseUntil
+		deliveries[i] = copyDelivery(delivery)
+	}
+
+	return deliveries, nil
+}
+
+// ListDeliveries retrieves deliveries matching the filter, newest first. Only
+// deliveries older than beforeSeq are returned when it is positive.
+func (r *WebhookRepository) ListDeliveries(filter models.WebhookDeliveryFilter, beforeSeq int64, limit int) ([]*models.WebhookDelivery, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var deliveries []*models.WebhookDelivery
+
+	for i := len(r.data.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		delivery := r.data.deliveries[i]
+
+		if beforeSeq > 0 && delivery.Seq >= beforeSeq {
+			continue
+		}
+		if filter.SubscriptionID != nil && delivery.SubscriptionID != *filter.SubscriptionID {
+			continue
+		}
+		if filter.Status != "" && delivery.Status != filter.Status {
+			continue
+		}
+		if filter.EventID != "" && delivery.EventID != filter.EventID {
+			continue
+		}
+
+		deliveries = append(deliveries, copyDelivery(delivery))
+	}
+
+	return deliveries, nil
+}
+
+// CreateAttempt logs an attempt at posting a delivery
+func (r *WebhookRepository) CreateAttempt(attempt *models.WebhookAttempt) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if r.findDelivery(attempt.DeliveryID) == nil {
+		return models.ErrDeliveryNotFound
+	}
+
+	c := *attempt
+	r.data.attempts[attempt.DeliveryID] = append(r.data.attempts[attempt.DeliveryID], &c)
+	return nil
+}
+
+// ListAttempts retrieves the attempts of a delivery, oldest first
+func (r *WebhookRepository) ListAttempts(deliveryID uuid.UUID) ([]*models.WebhookAttempt, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	var attempts []*models.WebhookAttempt
+	for _, attempt := range r.data.attempts[deliveryID] {
+		c := *attempt
+		attempts = append(attempts, &c)
+	}
+
+	return attempts, nil
+}
+
+// findDelivery returns the stored delivery with the given ID, or nil. The
+// caller must hold the lock.
+func (r *WebhookRepository) findDelivery(id uuid.UUID) *models.WebhookDelivery {
+	for _, delivery := range r.data.deliveries {
+		if delivery.ID == id {
+			return delivery
+		}
+	}
+	return nil
+}
+
+// copySubscription returns a deep copy of a subscription
+func copySubscription(subscription *models.WebhookSubscription) *models.WebhookSubscription {
+	c := *subscription
+	c.EventTypes = slices.Clone(subscription.EventTypes)
+	return &c
+}
+
+// copyDelivery returns a deep copy of a delivery
+func copyDelivery(delivery *models.WebhookDelivery) *models.WebhookDelivery {
+	c := *delivery
+	c.Payload = slices.Clone(delivery.Payload)
+	return &c
+}
@@ -0,0 +1,280 @@
+// Package store defines the repositories the services persist their data
+// through. The db package implements them on SQLite and the memory package
+// keeps everything in process memory for ephemeral servers; both must pass
+// the storetest conformance suite.
+package store
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+)
+
+// Store bundles the repositories of a backend along with the means to use
+// them in a transaction
+type Store struct {
+	Events         EventRepository
+	EventRevisions EventRevisionRepository
+	Users          UserRepository
+	APIKeys        APIKeyRepository
+	Audit          AuditRepository
+	RateLimits     RateLimitRepository
+	RewardCatalog  RewardCatalogRepository
+	Webhooks       WebhookRepository
+	Outbox         OutboxRepository
+	Idempotency    IdempotencyRepository
+
+	Transactor Transactor
+}
+
+// Transactor runs functions in transactions
+type Transactor interface {
+	// InTx calls fn with repositories whose changes are committed together
+	// when fn returns nil and discarded when it returns an error, which InTx
+	// then returns. Transactions started from the repositories given to fn
+	// join the enclosing one.
+	InTx(fn func(tx *Store) error) error
+}
+
+// Joined returns the Transactor of repositories that are already in a
+// transaction, running nested transactions as part of it
+func Joined(tx *Store) Transactor {
+	return joined{tx: tx}
+}
+
+// joined runs nested transactions in the enclosing one
+type joined struct {
+	tx *Store
+}
+
+// InTx calls fn with the repositories of the enclosing transaction
+func (j joined) InTx(fn func(tx *Store) error) error {
+	return fn(j.tx)
+}
+
+// Seeded admin account, created by every backend when no admin exists. Its
+// key is stored in plaintext and hashed by the auth service on startup.
+const (
+	AdminUserID   = "00000000-0000-0000-0000-000000000000"
+	AdminUsername = "admin"
+	AdminAPIKeyID = "00000000-0000-0000-0000-000000000001"
+	AdminAPIKey   = "admin-api-key-00000000-0000-0000-0000-000000000000"
+)
+
+// EventRepository stores live events
+type EventRepository interface {
+	// Create adds a new event at version 1, setting its version. It returns
+	// models.ErrExternalIDExists if another event has its external ID.
+	Create(event *models.LiveEvent) error
+	// GetByID retrieves an event, or returns models.ErrEventNotFound
+	GetByID(id uuid.UUID) (*models.LiveEvent, error)
+	// GetByExternalID retrieves the event with a non-empty external ID, or
+	// returns models.ErrEventNotFound
+	GetByExternalID(externalID string) (*models.LiveEvent, error)
+	// Update replaces the editable fields of an event, leaving its status
+	// untouched, and increments its version. It returns
+	// models.ErrEventNotFound, models.ErrVersionConflict if the stored
+	// version is no longer event.Version, or models.ErrExternalIDExists.
+	Update(event *models.LiveEvent) error
+	// UpdateStatus moves an event from one status to another and returns its
+	// new version, or returns models.ErrInvalidTransition if it is no longer
+	// in the expected status
+	UpdateStatus(id uuid.UUID, from, to models.EventStatus) (int64, error)
+	// Delete removes an event at the given version. It returns
+	// models.ErrEventNotFound, or models.ErrVersionConflict if the event has
+	// changed since.
+	Delete(id uuid.UUID, version int64) error
+	// List retrieves all events ordered by start time
+	List() ([]*models.LiveEvent, error)
+	// ListByStatus retrieves the events in any of the statuses ordered by
+	// start time
+	ListByStatus(statuses ...models.EventStatus) ([]*models.LiveEvent, error)
+	// ListPage retrieves up to limit events matching the status, title and
+	// stored time filters in the given order, starting after the cursor when
+	// it is set, along with the cursor of each event. Recurring events and
+	// player targeting must still be checked with EventFilter.Matches.
+	// Cursors are only meaningful to the backend that issued them.
+	ListPage(filter models.EventFilter, sort models.EventSort, after *models.EventCursor, limit int) ([]*models.LiveEvent, []models.EventCursor, error)
+}
+
+// EventRevisionRepository stores the append-only revisions of events
+type EventRevisionRepository interface {
+	// Create appends a revision and sets its number, one more than the
+	// latest revision of its event
+	Create(revision *models.EventRevision) error
+	// Get retrieves a revision of an event, or returns
+	// models.ErrRevisionNotFound
+	Get(eventID uuid.UUID, revision int) (*models.EventRevision, error)
+	// List retrieves up to limit revisions of an event, newest first, only
+	// returning revisions older than before when it is positive
+	List(eventID uuid.UUID, before, limit int) ([]*models.EventRevision, error)
+}
+
+// UserRepository stores users
+type UserRepository interface {
+	// CreateUser adds a new user, or returns models.ErrUsernameExists
+	CreateUser(user *models.User) error
+	// GetUserByID retrieves a user, or returns models.ErrUserNotFound
+	GetUserByID(id uuid.UUID) (*models.User, error)
+	// GetUserByUsername retrieves a user, or returns models.ErrUserNotFound
+	GetUserByUsername(username string) (*models.User, error)
+	// ListUsers retrieves all users ordered by username
+	ListUsers() ([]*models.User, error)
+	// UpdateUserRole changes the role of a user, or returns
+	// models.ErrUserNotFound
+	UpdateUserRole(id uuid.UUID, role models.Role) error
+	// CountUsersByRole returns the number of users with a role
+	CountUsersByRole(role models.Role) (int, error)
+	// DeleteUser removes a user along with their API keys, or returns
+	// models.ErrUserNotFound
+	DeleteUser(id uuid.UUID) error
+}
+
+// APIKeyRepository stores API keys. Only the prefix and hash of keys are
+// stored, except for legacy keys waiting to be hashed.
+type APIKeyRepository interface {
+	// CreateAPIKey adds a new key, or returns models.ErrUserNotFound if its
+	// user does not exist and models.ErrAPIKeyExists if its hash is taken
+	CreateAPIKey(apiKey *models.APIKey) error
+	// GetAPIKeyByHash retrieves a key by the hash of its secret, or returns
+	// models.ErrInvalidAPIKey
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	// GetAPIKeyByID retrieves a key, or returns models.ErrAPIKeyNotFound
+	GetAPIKeyByID(id uuid.UUID) (*models.APIKey, error)
+	// ListLegacyAPIKeys returns the plaintext of keys that are not hashed
+	// yet, keyed by API key ID
+	ListLegacyAPIKeys() (map[uuid.UUID]string, error)
+	// SetAPIKeyHash stores the prefix and hash of a legacy key and erases
+	// its plaintext
+	SetAPIKeyHash(id uuid.UUID, prefix, keyHash string) error
+	// UpdateAPIKeyLastUsed records when a key was last used
+	UpdateAPIKeyLastUsed(id uuid.UUID, lastUsed time.Time) error
+	// DeleteAPIKey removes a key, or returns models.ErrAPIKeyNotFound
+	DeleteAPIKey(id uuid.UUID) error
+	// ListAPIKeysByUserID retrieves the keys of a user, newest first
+	ListAPIKeysByUserID(userID uuid.UUID) ([]*models.APIKey, error)
+}
+
+// AuditRepository stores the append-only audit log
+type AuditRepository interface {
+	// Create appends an entry and sets its sequence number, which increases
+	// with every entry
+	Create(entry *models.AuditEntry) error
+	// List retrieves up to limit entries matching the filter, newest first,
+	// only returning entries older than beforeSeq when it is positive
+	List(filter models.AuditFilter, beforeSeq int64, limit int) ([]*models.AuditEntry, error)
+}
+
+// RateLimitRepository stores rate limit overrides
+type RateLimitRepository interface {
+	// List retrieves all overrides ordered by subject type and subject
+	List() ([]*models.RateLimitOverride, error)
+	// Get retrieves the override of a subject, or returns
+	// models.ErrRateLimitNotFound
+	Get(subjectType models.RateLimitSubject, subject string) (*models.RateLimitOverride, error)
+	// Set creates or replaces the override of a subject
+	Set(override *models.RateLimitOverride) error
+	// Delete removes the override of a subject, or returns
+	// models.ErrRateLimitNotFound
+	Delete(subjectType models.RateLimitSubject, subject string) error
+}
+
+// RewardCatalogRepository stores the reward catalog
+type RewardCatalogRepository interface {
+	// Create adds an entry, or returns models.ErrRewardExists
+	Create(item *models.RewardCatalogItem) error
+	// GetByID retrieves an entry, or returns models.ErrUnknownReward
+	GetByID(id string) (*models.RewardCatalogItem, error)
+	// List retrieves the whole catalog ordered by ID
+	List() ([]*models.RewardCatalogItem, error)
+	// Delete removes an entry, or returns models.ErrUnknownReward
+	Delete(id string) error
+}
+
+// WebhookRepository stores webhook subscriptions, their queued deliveries and
+// the log of delivery attempts
+type WebhookRepository interface {
+	// CreateSubscription adds a subscription
+	CreateSubscription(subscription *models.WebhookSubscription) error
+	// GetSubscription retrieves a subscription, or returns
+	// models.ErrWebhookNotFound
+	GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error)
+	// ListSubscriptions retrieves every subscription, oldest first
+	ListSubscriptions() ([]*models.WebhookSubscription, error)
+	// DeleteSubscription removes a subscription along with its deliveries,
+	// or returns models.ErrWebhookNotFound
+	DeleteSubscription(id uuid.UUID) error
+
+	// CreateDelivery queues a delivery and sets its sequence number, or
+	// returns models.ErrWebhookNotFound if its subscription is gone
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	// GetDelivery retrieves a delivery, or returns models.ErrDeliveryNotFound
+	GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error)
+	// UpdateDelivery saves the status, attempt count, next attempt time,
+	// last error and update time of a delivery, or returns
+	// models.ErrDeliveryNotFound
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+	// ClaimDueDeliveries retrieves up to limit pending deliveries whose next
+	// attempt is due at now, those due the longest first, and moves their
+	// next attempt to leaseUntil so that other dispatchers leave them alone
+	// while they are sent. They are returned in queueing order.
+	ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*models.WebhookDelivery, error)
+	// ListDeliveries retrieves deliveries matching the filter, newest first.
+	// Only deliveries older than beforeSeq are returned when it is positive.
+	ListDeliveries(filter models.WebhookDeliveryFilter, beforeSeq int64, limit int) ([]*models.WebhookDelivery, error)
+
+	// CreateAttempt logs an attempt at posting a delivery, or returns
+	// models.ErrDeliveryNotFound if the delivery is gone
+	CreateAttempt(attempt *models.WebhookAttempt) error
+	// ListAttempts retrieves the attempts of a delivery, oldest first
+	ListAttempts(deliveryID uuid.UUID) ([]*models.WebhookAttempt, error)
+}
+
+// OutboxRepository stores the changes waiting to be sent to the outbox
+// sinks. Entries are written in the same transaction as the changes they
+// record.
+type OutboxRepository interface {
+	// Create adds an entry and sets its sequence number
+	Create(entry *models.OutboxEntry) error
+	// Get retrieves an entry, or returns models.ErrOutboxNotFound
+	Get(seq int64) (*models.OutboxEntry, error)
+	// Update saves the status, attempt count, next attempt time, last error
+	// and update time of an entry, or returns models.ErrOutboxNotFound
+	Update(entry *models.OutboxEntry) error
+	// ClaimDue retrieves up to limit pending entries whose next attempt is
+	// due at now, in sequence order, and moves their next attempt to
+	// leaseUntil so that other dispatchers leave them alone while they are
+	// sent. Entries are left out while an earlier entry of the same event is
+	// pending or failed, so that the changes of an event are sent in order.
+	ClaimDue(now, leaseUntil time.Time, limit int) ([]*models.OutboxEntry, error)
+	// List retrieves entries matching the filter, newest first. Only entries
+	// older than beforeSeq are returned when it is positive.
+	List(filter models.OutboxFilter, beforeSeq int64, limit int) ([]*models.OutboxEntry, error)
+	// ListAfter retrieves up to limit entries newer than afterSeq, whatever
+	// their status, oldest first
+	ListAfter(afterSeq int64, limit int) ([]*models.OutboxEntry, error)
+	// PurgeDelivered removes the delivered entries last updated before the
+	// given time and returns how many were removed
+	PurgeDelivered(before time.Time) (int, error)
+}
+
+// IdempotencyRepository stores the requests made with idempotency keys and
+// their responses
+type IdempotencyRepository interface {
+	// Create adds a record, or returns models.ErrIdempotencyExists if its API
+	// key already has a record with its key, expired or not
+	Create(record *models.IdempotencyRecord) error
+	// Get retrieves the record of a key of an API key, or returns
+	// models.ErrIdempotencyMissing
+	Get(apiKeyID uuid.UUID, key string) (*models.IdempotencyRecord, error)
+	// Complete saves the response and expiry time of a record, marking it
+	// completed, or returns models.ErrIdempotencyMissing
+	Complete(record *models.IdempotencyRecord) error
+	// Delete removes the record of a key of an API key, or returns
+	// models.ErrIdempotencyMissing
+	Delete(apiKeyID uuid.UUID, key string) error
+	// PurgeExpired removes the records that expired before the given time
+	// and returns how many were removed
+	PurgeExpired(before time.Time) (int, error)
+}
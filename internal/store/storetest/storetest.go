@@ -0,0 +1,1266 @@
+// Package storetest is a conformance suite for store backends. Every backend
+// must pass it, so that services behave identically whichever one the server
+// runs on; each backend package runs it from its tests with Run.
+package storetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// baseTime anchors the times used by the suite. It is in the future so that
+// nothing created by the suite is affected by the current time.
+var baseTime = time.Date(2030, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// check is a named conformance check run against a fresh store
+type check struct {
+	name string
+	run  func(t *tester, s *store.Store)
+}
+
+var checks = []check{
+	{"seed", checkSeed},
+	{"events/crud", checkEventCRUD},
+	{"events/list", checkEventList},
+	{"events/page", checkEventPage},
+	{"events/external_id", checkEventExternalID},
+	{"time_zones", checkTimeZones},
+	{"users", checkUsers},
+	{"api_keys", checkAPIKeys},
+	{"audit", checkAudit},
+	{"event_revisions", checkEventRevisions},
+	{"rate_limits", checkRateLimits},
+	{"reward_catalog", checkRewardCatalog},
+	{"webhooks", checkWebhooks},
+	{"transactions", checkTransactions},
+	{"outbox", checkOutbox},
+	{"idempotency", checkIdempotency},
+}
+
+// Run runs every check as a subtest of t, calling newStore to get a fresh,
+// freshly seeded store for each. newStore fails the test itself when the
+// store cannot be created, and registers its own cleanup.
+func Run(t *testing.T, newStore func(t *testing.T) *store.Store) {
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			c.run(&tester{t}, newStore(t))
+		})
+	}
+}
+
+// tester reports the failures of a check
+type tester struct {
+	t *testing.T
+}
+
+// errorf records a failure
+func (t *tester) errorf(format string, args ...interface{}) {
+	t.t.Helper()
+	t.t.Errorf(format, args...)
+}
+
+// ok records err as a failure and reports whether it was nil
+func (t *tester) ok(err error, op string) bool {
+	t.t.Helper()
+	if err != nil {
+		t.errorf("%s: unexpected error: %v", op, err)
+		return false
+	}
+	return true
+}
+
+// is records a failure unless err matches target
+func (t *tester) is(err, target error, op string) {
+	t.t.Helper()
+	if !errors.Is(err, target) {
+		t.errorf("%s: got error %v, want %v", op, err, target)
+	}
+}
+
+// checkSeed verifies the default admin user and its legacy key
+func checkSeed(t *tester, s *store.Store) {
+	user, err := s.Users.GetUserByUsername(store.AdminUsername)
+	if t.ok(err, "GetUserByUsername") {
+		if user.ID.String() != store.AdminUserID || user.Role != models.RoleAdmin {
+			t.errorf("seeded admin is %s with role %s", user.ID, user.Role)
+		}
+	}
+
+	legacy, err := s.APIKeys.ListLegacyAPIKeys()
+	if t.ok(err, "ListLegacyAPIKeys") && legacy[uuid.MustParse(store.AdminAPIKeyID)] != store.AdminAPIKey {
+		t.errorf("seeded admin key is not listed as legacy: %v", legacy)
+	}
+}
+
+// newEvent returns an event starting offset hours after baseTime and lasting
+// an hour
+func newEvent(title string, offset int, status models.EventStatus) *models.LiveEvent {
+	start := baseTime.Add(time.Duration(offset) * time.Hour)
+	return &models.LiveEvent{
+		ID:        uuid.New(),
+		Title:     title,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Rewards:   "{}",
+		Status:    status,
+	}
+}
+
+// equalEvents compares events, ignoring the location of their times
+func equalEvents(a, b *models.LiveEvent) bool {
+	ac, bc := *a, *b
+	if !ac.StartTime.Equal(bc.StartTime) || !ac.EndTime.Equal(bc.EndTime) {
+		return false
+	}
+	ac.StartTime, ac.EndTime, bc.StartTime, bc.EndTime = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+	return reflect.DeepEqual(ac, bc)
+}
+
+// titles returns the titles of events, in order
+func titles(events []*models.LiveEvent) []string {
+	result := make([]string, len(events))
+	for i, event := range events {
+		result[i] = event.Title
+	}
+	return result
+}
+
+// checkEventCRUD verifies creating, reading, updating and deleting events
+func checkEventCRUD(t *tester, s *store.Store) {
+	event := newEvent("Spring Festival", 0, models.StatusDraft)
+	event.Description = "Seasonal event"
+	event.StructuredRewards = []models.Reward{{Type: models.RewardTypeCurrency, ID: "gems", Quantity: 100}}
+	event.Recurrence = "RRULE:FREQ=DAILY;COUNT=3"
+	event.Targeting = `region == "EU"`
+	if !t.ok(s.Events.Create(event), "Create") {
+		return
+	}
+	if event.Version != 1 {
+		t.errorf("Create set version %d, want 1", event.Version)
+	}
+
+	got, err := s.Events.GetByID(event.ID)
+	if t.ok(err, "GetByID") && !equalEvents(got, event) {
+		t.errorf("GetByID returned %+v, want %+v", got, event)
+	}
+
+	// Returned events must not share state with the store
+	if got != nil {
+		got.Title = "Changed"
+		if again, err := s.Events.GetByID(event.ID); err == nil && again.Title != event.Title {
+			t.errorf("modifying a returned event changed the stored event")
+		}
+	}
+
+	// Update leaves the status alone and increments the version
+	updated := *event
+	updated.Title = "Summer Festival"
+	updated.StructuredRewards = nil
+	updated.Status = models.StatusLive
+	if t.ok(s.Events.Update(&updated), "Update") {
+		if updated.Version != 2 {
+			t.errorf("Update set version %d, want 2", updated.Version)
+		}
+		got, err := s.Events.GetByID(event.ID)
+		if t.ok(err, "GetByID") {
+			if got.Title != "Summer Festival" || got.StructuredRewards != nil || got.Version != 2 {
+				t.errorf("Update did not apply: %+v", got)
+			}
+			if got.Status != models.StatusDraft {
+				t.errorf("Update changed the status to %s", got.Status)
+			}
+		}
+	}
+
+	// Updates from a stale version are rejected
+	stale := *event
+	stale.Title = "Stale"
+	t.is(s.Events.Update(&stale), models.ErrVersionConflict, "Update from stale version")
+	if got, err := s.Events.GetByID(event.ID); err == nil && got.Title != "Summer Festival" {
+		t.errorf("Update from stale version applied: %+v", got)
+	}
+
+	// Status transitions only apply from the expected status
+	_, err = s.Events.UpdateStatus(event.ID, models.StatusScheduled, models.StatusLive)
+	t.is(err, models.ErrInvalidTransition, "UpdateStatus from wrong status")
+	version, err := s.Events.UpdateStatus(event.ID, models.StatusDraft, models.StatusScheduled)
+	if t.ok(err, "UpdateStatus") {
+		if version != 3 {
+			t.errorf("UpdateStatus returned version %d, want 3", version)
+		}
+		if got, err := s.Events.GetByID(event.ID); err == nil && (got.Status != models.StatusScheduled || got.Version != 3) {
+			t.errorf("UpdateStatus left status %s at version %d", got.Status, got.Version)
+		}
+	}
+	_, err = s.Events.UpdateStatus(uuid.New(), models.StatusDraft, models.StatusScheduled)
+	t.is(err, models.ErrInvalidTransition, "UpdateStatus of missing event")
+
+	// Missing events
+	missing := newEvent("Missing", 0, models.StatusDraft)
+	_, err = s.Events.GetByID(missing.ID)
+	t.is(err, models.ErrEventNotFound, "GetByID of missing event")
+	t.is(s.Events.Update(missing), models.ErrEventNotFound, "Update of missing event")
+	t.is(s.Events.Delete(missing.ID, 1), models.ErrEventNotFound, "Delete of missing event")
+
+	t.is(s.Events.Delete(event.ID, 2), models.ErrVersionConflict, "Delete at stale version")
+	if t.ok(s.Events.Delete(event.ID, 3), "Delete") {
+		_, err := s.Events.GetByID(event.ID)
+		t.is(err, models.ErrEventNotFound, "GetByID after Delete")
+	}
+}
+
+// checkEventExternalID verifies looking events up by external ID and keeping
+// external IDs unique
+func checkEventExternalID(t *tester, s *store.Store) {
+	event := newEvent("Spring Festival", 0, models.StatusDraft)
+	event.ExternalID = "spring-2030"
+	other := newEvent("Summer Festival", 1, models.StatusDraft)
+	unset := newEvent("Unnamed", 2, models.StatusDraft)
+	if !t.ok(s.Events.Create(event), "Create") || !t.ok(s.Events.Create(other), "Create") || !t.ok(s.Events.Create(unset), "Create") {
+		return
+	}
+
+	got, err := s.Events.GetByExternalID("spring-2030")
+	if t.ok(err, "GetByExternalID") && !equalEvents(got, event) {
+		t.errorf("GetByExternalID returned %+v, want %+v", got, event)
+	}
+	_, err = s.Events.GetByExternalID("winter-2030")
+	t.is(err, models.ErrEventNotFound, "GetByExternalID of missing external ID")
+	_, err = s.Events.GetByExternalID("")
+	t.is(err, models.ErrEventNotFound, "GetByExternalID of empty external ID")
+
+	duplicate := newEvent("Spring Festival Again", 3, models.StatusDraft)
+	duplicate.ExternalID = "spring-2030"
+	t.is(s.Events.Create(duplicate), models.ErrExternalIDExists, "Create with taken external ID")
+	other.ExternalID = "spring-2030"
+	t.is(s.Events.Update(other), models.ErrExternalIDExists, "Update to taken external ID")
+
+	// External IDs can be moved once released
+	event.ExternalID = ""
+	if t.ok(s.Events.Update(event), "Update") && t.ok(s.Events.Update(other), "Update") {
+		got, err := s.Events.GetByExternalID("spring-2030")
+		if t.ok(err, "GetByExternalID") && got.ID != other.ID {
+			t.errorf("GetByExternalID returned event %s, want %s", got.ID, other.ID)
+		}
+	}
+}
+
+// checkEventList verifies the unpaginated listings
+func checkEventList(t *tester, s *store.Store) {
+	for _, event := range []*models.LiveEvent{
+		newEvent("Third", 3, models.StatusLive),
+		newEvent("First", 1, models.StatusDraft),
+		newEvent("Second", 2, models.StatusScheduled),
+	} {
+		if !t.ok(s.Events.Create(event), "Create") {
+			return
+		}
+	}
+
+	events, err := s.Events.List()
+	if t.ok(err, "List") && !reflect.DeepEqual(titles(events), []string{"First", "Second", "Third"}) {
+		t.errorf("List returned %v", titles(events))
+	}
+
+	events, err = s.Events.ListByStatus(models.StatusScheduled, models.StatusLive)
+	if t.ok(err, "ListByStatus") && !reflect.DeepEqual(titles(events), []string{"Second", "Third"}) {
+		t.errorf("ListByStatus returned %v", titles(events))
+	}
+
+	events, err = s.Events.ListByStatus()
+	if t.ok(err, "ListByStatus without statuses") && len(events) != 0 {
+		t.errorf("ListByStatus without statuses returned %v", titles(events))
+	}
+}
+
+// checkEventPage verifies filtering, ordering and keyset pagination
+func checkEventPage(t *tester, s *store.Store) {
+	recurring := newEvent("Daily Login", 0, models.StatusScheduled)
+	recurring.Recurrence = "RRULE:FREQ=DAILY;COUNT=10"
+
+	for _, event := range []*models.LiveEvent{
+		newEvent("Boss Rush", 4, models.StatusScheduled),
+		newEvent("arena cup", 2, models.StatusDraft),
+		newEvent("Arena League", 2, models.StatusLive),
+		newEvent("Cup Finals", 6, models.StatusScheduled),
+		newEvent("100% Bonus", 8, models.StatusEnded),
+		recurring,
+	} {
+		if !t.ok(s.Events.Create(event), "Create") {
+			return
+		}
+	}
+
+	// Walking every page in each order must visit all events exactly once
+	for _, sort := range []models.EventSort{
+		models.SortStartTimeAsc, models.SortStartTimeDesc,
+		models.SortEndTimeAsc, models.SortEndTimeDesc,
+		models.SortTitleAsc, models.SortTitleDesc,
+	} {
+		all, _, err := s.Events.ListPage(models.EventFilter{}, sort, nil, 100)
+		if !t.ok(err, fmt.Sprintf("ListPage %s", sort)) {
+			continue
+		}
+		if len(all) != 6 {
+			t.errorf("ListPage %s returned %d events, want 6", sort, len(all))
+			continue
+		}
+
+		for i := 1; i < len(all); i++ {
+			if !inOrder(all[i-1], all[i], sort) {
+				t.errorf("ListPage %s returned %v out of order", sort, titles(all))
+				break
+			}
+		}
+
+		var paged []*models.LiveEvent
+		var after *models.EventCursor
+		for pages := 0; pages < 10; pages++ {
+			events, cursors, err := s.Events.ListPage(models.EventFilter{}, sort, after, 4)
+			if !t.ok(err, fmt.Sprintf("ListPage %s page %d", sort, pages)) {
+				break
+			}
+			if len(cursors) != len(events) {
+				t.errorf("ListPage %s returned %d cursors for %d events", sort, len(cursors), len(events))
+				break
+			}
+			paged = append(paged, events...)
+			if len(events) < 4 {
+				break
+			}
+			after = &cursors[len(cursors)-1]
+		}
+		if !reflect.DeepEqual(titles(paged), titles(all)) {
+			t.errorf("paging %s returned %v, want %v", sort, titles(paged), titles(all))
+		}
+	}
+
+	listTitles := func(filter models.EventFilter) []string {
+		events, _, err := s.Events.ListPage(filter, models.SortTitleAsc, nil, 100)
+		if !t.ok(err, "ListPage") {
+			return nil
+		}
+		return titles(events)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.EventFilter
+		want   []string
+	}{
+		{"statuses", models.EventFilter{Statuses: []models.EventStatus{models.StatusDraft, models.StatusEnded}}, []string{"100% Bonus", "arena cup"}},
+		{"title ignoring case", models.EventFilter{Title: "ARENA"}, []string{"Arena League", "arena cup"}},
+		{"title with wildcard", models.EventFilter{Title: "0%"}, []string{"100% Bonus"}},
+		{"starts after", models.EventFilter{StartsAfter: baseTime.Add(4 * time.Hour)}, []string{"100% Bonus", "Boss Rush", "Cup Finals"}},
+		{"ends before", models.EventFilter{EndsBefore: baseTime.Add(3 * time.Hour)}, []string{"Arena League", "Daily Login", "arena cup"}},
+		{"active at", models.EventFilter{ActiveAt: baseTime.Add(4*time.Hour + 30*time.Minute)}, []string{"Boss Rush", "Daily Login"}},
+	}
+	for _, test := range tests {
+		if got := listTitles(test.filter); !reflect.DeepEqual(got, test.want) {
+			t.errorf("ListPage filtered by %s returned %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if _, _, err := s.Events.ListPage(models.EventFilter{}, models.EventSort("priority"), nil, 10); err == nil {
+		t.errorf("ListPage accepted an invalid sort order")
+	}
+
+	// Title filters only ignore the case of ASCII letters, which every
+	// backend folds alike
+	for _, event := range []*models.LiveEvent{
+		newEvent("Été Festival", 10, models.StatusDraft),
+		newEvent("CRÈME Week", 10, models.StatusDraft),
+	} {
+		if !t.ok(s.Events.Create(event), "Create") {
+			return
+		}
+	}
+	for _, test := range []struct {
+		title string
+		want  []string
+	}{
+		{"été", []string{}},
+		{"Été fest", []string{"Été Festival"}},
+		{"crème", []string{}},
+		{"crÈme", []string{"CRÈME Week"}},
+	} {
+		if got := listTitles(models.EventFilter{Title: test.title}); !reflect.DeepEqual(got, test.want) {
+			t.errorf("ListPage filtered by title %q returned %v, want %v", test.title, got, test.want)
+		}
+	}
+}
+
+// inOrder reports whether a sorts before b
+func inOrder(a, b *models.LiveEvent, sort models.EventSort) bool {
+	var c int
+	switch sort.Field() {
+	case "end_time":
+		c = a.EndTime.Compare(b.EndTime)
+	case "title":
+		switch {
+		case a.Title < b.Title:
+			c = -1
+		case a.Title > b.Title:
+			c = 1
+		}
+	default:
+		c = a.StartTime.Compare(b.StartTime)
+	}
+	if sort.Descending() {
+		c = -c
+	}
+	return c <= 0
+}
+
+// checkTimeZones verifies that times written with any offset are stored as
+// the same instants, compared and sorted chronologically and read back with
+// their fractional seconds
+func checkTimeZones(t *tester, s *store.Store) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	newYork := time.FixedZone("EST", -5*60*60)
+	india := time.FixedZone("IST", 5*60*60+30*60)
+
+	// Chronologically Tokyo (12:00Z) starts first, then UTC (13:00Z) and New
+	// York (13:30Z), which is the reverse of the order of their local times
+	events := []*models.LiveEvent{
+		newEvent("New York", 0, models.StatusLive),
+		newEvent("Tokyo", 0, models.StatusLive),
+		newEvent("UTC", 0, models.StatusLive),
+	}
+	events[0].StartTime = time.Date(2030, time.January, 1, 8, 30, 0, 0, newYork)
+	events[0].EndTime = time.Date(2030, time.January, 1, 9, 0, 0, 0, newYork)
+	events[1].StartTime = time.Date(2030, time.January, 1, 21, 0, 0, 0, tokyo)
+	events[1].EndTime = time.Date(2030, time.January, 1, 23, 0, 0, 0, tokyo)
+	events[2].StartTime = baseTime.Add(time.Hour)
+	events[2].EndTime = baseTime.Add(75*time.Minute + 500*time.Millisecond + 250)
+
+	for _, event := range events {
+		if !t.ok(s.Events.Create(event), "Create") {
+			return
+		}
+		if got, err := s.Events.GetByID(event.ID); t.ok(err, "GetByID") && !equalEvents(got, event) {
+			t.errorf("GetByID returned %s to %s, want %s to %s", got.StartTime, got.EndTime, event.StartTime, event.EndTime)
+		}
+	}
+
+	all, err := s.Events.List()
+	if t.ok(err, "List") && !reflect.DeepEqual(titles(all), []string{"Tokyo", "UTC", "New York"}) {
+		t.errorf("List returned %v, want events in chronological order", titles(all))
+	}
+
+	var paged []*models.LiveEvent
+	var after *models.EventCursor
+	for range events {
+		page, cursors, err := s.Events.ListPage(models.EventFilter{}, models.SortStartTimeAsc, after, 1)
+		if !t.ok(err, "ListPage") || len(page) == 0 {
+			break
+		}
+		paged = append(paged, page...)
+		after = &cursors[0]
+	}
+	if !reflect.DeepEqual(titles(paged), []string{"Tokyo", "UTC", "New York"}) {
+		t.errorf("paging by start time returned %v, want events in chronological order", titles(paged))
+	}
+
+	tests := []struct {
+		name   string
+		filter models.EventFilter
+		want   []string
+	}{
+		{"active at", models.EventFilter{ActiveAt: time.Date(2030, time.January, 1, 19, 15, 0, 0, india)}, []string{"Tokyo", "New York"}},
+		{"starts after", models.EventFilter{StartsAfter: time.Date(2030, time.January, 1, 8, 0, 0, 0, newYork)}, []string{"UTC", "New York"}},
+		{"ends before", models.EventFilter{EndsBefore: events[2].EndTime.In(tokyo)}, []string{"UTC"}},
+		{"ends before the fraction", models.EventFilter{EndsBefore: events[2].EndTime.Add(-time.Microsecond)}, []string{}},
+	}
+	for _, test := range tests {
+		got, _, err := s.Events.ListPage(test.filter, models.SortStartTimeAsc, nil, 10)
+		if t.ok(err, "ListPage") && !reflect.DeepEqual(titles(got), test.want) {
+			t.errorf("ListPage filtered by %s returned %v, want %v", test.name, titles(got), test.want)
+		}
+	}
+
+	// Users and API keys keep their instants too
+	createdAt := time.Date(2030, time.January, 1, 21, 0, 0, 123456789, tokyo)
+	user := &models.User{ID: uuid.New(), Username: "tokyo", Role: models.RoleViewer, CreatedAt: createdAt}
+	if t.ok(s.Users.CreateUser(user), "CreateUser") {
+		if got, err := s.Users.GetUserByID(user.ID); t.ok(err, "GetUserByID") && !got.CreatedAt.Equal(createdAt) {
+			t.errorf("GetUserByID returned creation time %s, want %s", got.CreatedAt, createdAt)
+		}
+	}
+
+	key := newAPIKey(uuid.MustParse(store.AdminUserID), "hash-tokyo", createdAt)
+	if t.ok(s.APIKeys.CreateAPIKey(key), "CreateAPIKey") {
+		lastUsed := time.Date(2030, time.January, 1, 8, 0, 0, 5, newYork)
+		if t.ok(s.APIKeys.UpdateAPIKeyLastUsed(key.ID, lastUsed), "UpdateAPIKeyLastUsed") {
+			got, err := s.APIKeys.GetAPIKeyByID(key.ID)
+			if t.ok(err, "GetAPIKeyByID") && (!got.CreatedAt.Equal(key.CreatedAt) || !got.ExpiresAt.Equal(key.ExpiresAt) || !got.LastUsed.Equal(lastUsed)) {
+				t.errorf("GetAPIKeyByID returned times %s, %s, %s, want %s, %s, %s",
+					got.CreatedAt, got.ExpiresAt, got.LastUsed, key.CreatedAt, key.ExpiresAt, lastUsed)
+			}
+		}
+	}
+}
+
+// checkUsers verifies user management
+func checkUsers(t *tester, s *store.Store) {
+	editor := &models.User{ID: uuid.New(), Username: "editor", Role: models.RoleEditor, CreatedAt: baseTime}
+	viewer := &models.User{ID: uuid.New(), Username: "viewer", Role: models.RoleViewer, CreatedAt: baseTime}
+	if !t.ok(s.Users.CreateUser(viewer), "CreateUser") || !t.ok(s.Users.CreateUser(editor), "CreateUser") {
+		return
+	}
+
+	duplicate := &models.User{ID: uuid.New(), Username: "editor", Role: models.RoleViewer, CreatedAt: baseTime}
+	t.is(s.Users.CreateUser(duplicate), models.ErrUsernameExists, "CreateUser with a taken username")
+
+	got, err := s.Users.GetUserByID(editor.ID)
+	if t.ok(err, "GetUserByID") && (got.Username != "editor" || got.Role != models.RoleEditor || !got.CreatedAt.Equal(baseTime)) {
+		t.errorf("GetUserByID returned %+v", got)
+	}
+
+	got, err = s.Users.GetUserByUsername("viewer")
+	if t.ok(err, "GetUserByUsername") && got.ID != viewer.ID {
+		t.errorf("GetUserByUsername returned %s, want %s", got.ID, viewer.ID)
+	}
+
+	_, err = s.Users.GetUserByID(uuid.New())
+	t.is(err, models.ErrUserNotFound, "GetUserByID of missing user")
+	_, err = s.Users.GetUserByUsername("nobody")
+	t.is(err, models.ErrUserNotFound, "GetUserByUsername of missing user")
+
+	users, err := s.Users.ListUsers()
+	if t.ok(err, "ListUsers") {
+		var names []string
+		for _, user := range users {
+			names = append(names, user.Username)
+		}
+		if !reflect.DeepEqual(names, []string{"admin", "editor", "viewer"}) {
+			t.errorf("ListUsers returned %v", names)
+		}
+	}
+
+	if t.ok(s.Users.UpdateUserRole(viewer.ID, models.RoleAdmin), "UpdateUserRole") {
+		count, err := s.Users.CountUsersByRole(models.RoleAdmin)
+		if t.ok(err, "CountUsersByRole") && count != 2 {
+			t.errorf("CountUsersByRole returned %d admins, want 2", count)
+		}
+	}
+	t.is(s.Users.UpdateUserRole(uuid.New(), models.RoleAdmin), models.ErrUserNotFound, "UpdateUserRole of missing user")
+
+	// Deleting a user removes their keys
+	key := newAPIKey(editor.ID, "hash-editor", baseTime)
+	if t.ok(s.APIKeys.CreateAPIKey(key), "CreateAPIKey") && t.ok(s.Users.DeleteUser(editor.ID), "DeleteUser") {
+		_, err := s.APIKeys.GetAPIKeyByID(key.ID)
+		t.is(err, models.ErrAPIKeyNotFound, "GetAPIKeyByID after deleting its user")
+		_, err = s.Users.GetUserByID(editor.ID)
+		t.is(err, models.ErrUserNotFound, "GetUserByID after DeleteUser")
+	}
+	t.is(s.Users.DeleteUser(editor.ID), models.ErrUserNotFound, "DeleteUser of missing user")
+}
+
+// newAPIKey returns a hashed API key of a user
+func newAPIKey(userID uuid.UUID, keyHash string, createdAt time.Time) *models.APIKey {
+	return &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Prefix:    "lo_" + keyHash,
+		KeyHash:   keyHash,
+		Scopes:    []models.Scope{models.ScopeEventsRead},
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.AddDate(0, 0, 30),
+		LastUsed:  createdAt,
+	}
+}
+
+// checkAPIKeys verifies API key management
+func checkAPIKeys(t *tester, s *store.Store) {
+	adminID := uuid.MustParse(store.AdminUserID)
+
+	older := newAPIKey(adminID, "hash-older", baseTime)
+	newer := newAPIKey(adminID, "hash-newer", baseTime.Add(time.Hour))
+	newer.Scopes = nil
+	if !t.ok(s.APIKeys.CreateAPIKey(older), "CreateAPIKey") || !t.ok(s.APIKeys.CreateAPIKey(newer), "CreateAPIKey") {
+		return
+	}
+
+	t.is(s.APIKeys.CreateAPIKey(newAPIKey(uuid.New(), "hash-orphan", baseTime)), models.ErrUserNotFound, "CreateAPIKey for missing user")
+	t.is(s.APIKeys.CreateAPIKey(newAPIKey(adminID, "hash-older", baseTime)), models.ErrAPIKeyExists, "CreateAPIKey with a taken hash")
+
+	got, err := s.APIKeys.GetAPIKeyByHash("hash-older")
+	if t.ok(err, "GetAPIKeyByHash") {
+		if got.ID != older.ID || got.Prefix != older.Prefix || !reflect.DeepEqual(got.Scopes, older.Scopes) ||
+			!got.ExpiresAt.Equal(older.ExpiresAt) || got.Key != "" {
+			t.errorf("GetAPIKeyByHash returned %+v, want %+v", got, older)
+		}
+	}
+	_, err = s.APIKeys.GetAPIKeyByHash("hash-missing")
+	t.is(err, models.ErrInvalidAPIKey, "GetAPIKeyByHash of missing key")
+	_, err = s.APIKeys.GetAPIKeyByHash("")
+	t.is(err, models.ErrInvalidAPIKey, "GetAPIKeyByHash of empty hash")
+
+	got, err = s.APIKeys.GetAPIKeyByID(newer.ID)
+	if t.ok(err, "GetAPIKeyByID") && (got.KeyHash != "hash-newer" || got.Scopes != nil) {
+		t.errorf("GetAPIKeyByID returned %+v", got)
+	}
+	_, err = s.APIKeys.GetAPIKeyByID(uuid.New())
+	t.is(err, models.ErrAPIKeyNotFound, "GetAPIKeyByID of missing key")
+
+	lastUsed := baseTime.Add(2 * time.Hour)
+	if t.ok(s.APIKeys.UpdateAPIKeyLastUsed(older.ID, lastUsed), "UpdateAPIKeyLastUsed") {
+		if got, err := s.APIKeys.GetAPIKeyByID(older.ID); err == nil && !got.LastUsed.Equal(lastUsed) {
+			t.errorf("UpdateAPIKeyLastUsed left %s", got.LastUsed)
+		}
+	}
+
+	// Legacy keys become regular keys once hashed
+	legacyID := uuid.MustParse(store.AdminAPIKeyID)
+	if t.ok(s.APIKeys.SetAPIKeyHash(legacyID, "lo_legacy", "hash-legacy"), "SetAPIKeyHash") {
+		legacy, err := s.APIKeys.ListLegacyAPIKeys()
+		if t.ok(err, "ListLegacyAPIKeys") && len(legacy) != 0 {
+			t.errorf("ListLegacyAPIKeys still returns %v", legacy)
+		}
+		if got, err := s.APIKeys.GetAPIKeyByHash("hash-legacy"); t.ok(err, "GetAPIKeyByHash of hashed legacy key") && got.ID != legacyID {
+			t.errorf("GetAPIKeyByHash returned %s, want %s", got.ID, legacyID)
+		}
+	}
+
+	keys, err := s.APIKeys.ListAPIKeysByUserID(adminID)
+	if t.ok(err, "ListAPIKeysByUserID") {
+		if len(keys) != 3 || keys[0].ID != newer.ID || keys[1].ID != older.ID {
+			t.errorf("ListAPIKeysByUserID did not return keys newest first")
+		}
+	}
+
+	if t.ok(s.APIKeys.DeleteAPIKey(older.ID), "DeleteAPIKey") {
+		_, err := s.APIKeys.GetAPIKeyByHash("hash-older")
+		t.is(err, models.ErrInvalidAPIKey, "GetAPIKeyByHash after DeleteAPIKey")
+	}
+	t.is(s.APIKeys.DeleteAPIKey(older.ID), models.ErrAPIKeyNotFound, "DeleteAPIKey of missing key")
+}
+
+// checkAudit verifies the audit log
+func checkAudit(t *tester, s *store.Store) {
+	actor := uuid.MustParse(store.AdminUserID)
+
+	var entries []*models.AuditEntry
+	for i, action := range []string{models.AuditActionCreate, models.AuditActionUpdate, models.AuditActionDelete, models.AuditActionCreate} {
+		entry := &models.AuditEntry{
+			ID:         uuid.New(),
+			Action:     action,
+			TargetType: models.AuditTargetEvent,
+			TargetID:   fmt.Sprintf("event-%d", i%2),
+			After:      json.RawMessage(`{"title":"Spring"}`),
+			Transport:  models.TransportHTTP,
+			CreatedAt:  baseTime.Add(time.Duration(i) * time.Minute),
+		}
+		if i%2 == 0 {
+			entry.ActorUserID = &actor
+		}
+		if !t.ok(s.Audit.Create(entry), "Create") {
+			return
+		}
+		if len(entries) > 0 && entry.Seq <= entries[len(entries)-1].Seq {
+			t.errorf("Create assigned sequence %d after %d", entry.Seq, entries[len(entries)-1].Seq)
+		}
+		entries = append(entries, entry)
+	}
+
+	seqs := func(filter models.AuditFilter, beforeSeq int64, limit int) []int64 {
+		list, err := s.Audit.List(filter, beforeSeq, limit)
+		if !t.ok(err, "List") {
+			return nil
+		}
+		result := make([]int64, len(list))
+		for i, entry := range list {
+			result[i] = entry.Seq
+		}
+		return result
+	}
+
+	e := entries
+	tests := []struct {
+		name      string
+		filter    models.AuditFilter
+		beforeSeq int64
+		limit     int
+		want      []int64
+	}{
+		{"everything", models.AuditFilter{}, 0, 10, []int64{e[3].Seq, e[2].Seq, e[1].Seq, e[0].Seq}},
+		{"limit", models.AuditFilter{}, 0, 2, []int64{e[3].Seq, e[2].Seq}},
+		{"before", models.AuditFilter{}, e[2].Seq, 10, []int64{e[1].Seq, e[0].Seq}},
+		{"actor", models.AuditFilter{ActorUserID: &actor}, 0, 10, []int64{e[2].Seq, e[0].Seq}},
+		{"target", models.AuditFilter{TargetType: models.AuditTargetEvent, TargetID: "event-1"}, 0, 10, []int64{e[3].Seq, e[1].Seq}},
+		{"action", models.AuditFilter{Action: models.AuditActionCreate}, 0, 10, []int64{e[3].Seq, e[0].Seq}},
+		{"time range", models.AuditFilter{Since: baseTime.Add(time.Minute), Until: baseTime.Add(3 * time.Minute)}, 0, 10, []int64{e[2].Seq, e[1].Seq}},
+	}
+	for _, test := range tests {
+		if got := seqs(test.filter, test.beforeSeq, test.limit); !reflect.DeepEqual(got, test.want) {
+			t.errorf("List filtered by %s returned %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	list, err := s.Audit.List(models.AuditFilter{}, 0, 1)
+	if t.ok(err, "List") && len(list) == 1 {
+		got := list[0]
+		if got.ID != e[3].ID || got.Action != e[3].Action || string(got.After) != string(e[3].After) ||
+			got.Before != nil || got.ActorUserID != nil || !got.CreatedAt.Equal(e[3].CreatedAt) {
+			t.errorf("List returned %+v, want %+v", got, e[3])
+		}
+	}
+}
+
+// checkEventRevisions verifies that revisions are numbered per event and
+// listed newest first
+func checkEventRevisions(t *tester, s *store.Store) {
+	author := uuid.MustParse(store.AdminUserID)
+	event := newEvent("Spring", 0, models.StatusDraft)
+	event.StructuredRewards = []models.Reward{{Type: "currency", ID: "gold", Quantity: 100}}
+	other := newEvent("Summer", 1, models.StatusDraft)
+
+	var revisions []*models.EventRevision
+	for i, action := range []string{models.RevisionActionCreate, models.RevisionActionUpdate, models.RevisionActionStatus} {
+		revision := &models.EventRevision{
+			EventID:   event.ID,
+			Action:    action,
+			Event:     *event,
+			CreatedAt: baseTime.Add(time.Duration(i) * time.Minute),
+		}
+		revision.Event.Title = fmt.Sprintf("Spring %d", i)
+		if i == 1 {
+			revision.AuthorUserID = &author
+		}
+		if !t.ok(s.EventRevisions.Create(revision), "Create") {
+			return
+		}
+		if revision.Revision != i+1 {
+			t.errorf("Create numbered revision %d, want %d", revision.Revision, i+1)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	// Every event is numbered on its own
+	first := &models.EventRevision{EventID: other.ID, Action: models.RevisionActionCreate, Event: *other, CreatedAt: baseTime}
+	if t.ok(s.EventRevisions.Create(first), "Create") && first.Revision != 1 {
+		t.errorf("Create numbered the first revision of another event %d", first.Revision)
+	}
+
+	got, err := s.EventRevisions.Get(event.ID, 2)
+	if t.ok(err, "Get") {
+		want := revisions[1]
+		if got.EventID != want.EventID || got.Revision != 2 || got.Action != want.Action || got.AuthorUserID == nil ||
+			*got.AuthorUserID != author || got.AuthorAPIKeyID != nil || !got.CreatedAt.Equal(want.CreatedAt) ||
+			!equalEvents(&got.Event, &want.Event) {
+			t.errorf("Get returned %+v, want %+v", got, want)
+		}
+	}
+
+	_, err = s.EventRevisions.Get(event.ID, 4)
+	t.is(err, models.ErrRevisionNotFound, "Get of missing revision")
+	_, err = s.EventRevisions.Get(uuid.New(), 1)
+	t.is(err, models.ErrRevisionNotFound, "Get of revision of missing event")
+
+	numbers := func(before, limit int) []int {
+		list, err := s.EventRevisions.List(event.ID, before, limit)
+		if !t.ok(err, "List") {
+			return nil
+		}
+		result := make([]int, len(list))
+		for i, revision := range list {
+			result[i] = revision.Revision
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		before int
+		limit  int
+		want   []int
+	}{
+		{"everything", 0, 10, []int{3, 2, 1}},
+		{"limit", 0, 2, []int{3, 2}},
+		{"before", 3, 10, []int{2, 1}},
+		{"before the first", 1, 10, []int{}},
+	}
+	for _, test := range tests {
+		if got := numbers(test.before, test.limit); !reflect.DeepEqual(got, test.want) {
+			t.errorf("List %s returned %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// checkRateLimits verifies rate limit overrides
+func checkRateLimits(t *tester, s *store.Store) {
+	keyID := uuid.New().String()
+	overrides := []*models.RateLimitOverride{
+		{SubjectType: models.RateLimitSubjectRole, Subject: "viewer", RequestsPerMin: 10, UpdatedAt: baseTime},
+		{SubjectType: models.RateLimitSubjectAPIKey, Subject: keyID, RequestsPerMin: 20, UpdatedAt: baseTime},
+		{SubjectType: models.RateLimitSubjectRole, Subject: "editor", RequestsPerMin: 30, UpdatedAt: baseTime},
+	}
+	for _, override := range overrides {
+		if !t.ok(s.RateLimits.Set(override), "Set") {
+			return
+		}
+	}
+
+	// Set replaces existing overrides
+	replaced := &models.RateLimitOverride{SubjectType: models.RateLimitSubjectRole, Subject: "viewer", RequestsPerMin: 15, UpdatedAt: baseTime.Add(time.Minute)}
+	if t.ok(s.RateLimits.Set(replaced), "Set") {
+		got, err := s.RateLimits.Get(models.RateLimitSubjectRole, "viewer")
+		if t.ok(err, "Get") && (got.RequestsPerMin != 15 || !got.UpdatedAt.Equal(replaced.UpdatedAt)) {
+			t.errorf("Get returned %+v, want %+v", got, replaced)
+		}
+	}
+
+	list, err := s.RateLimits.List()
+	if t.ok(err, "List") {
+		var subjects []string
+		for _, override := range list {
+			subjects = append(subjects, string(override.SubjectType)+":"+override.Subject)
+		}
+		want := []string{"api_key:" + keyID, "role:editor", "role:viewer"}
+		if !reflect.DeepEqual(subjects, want) {
+			t.errorf("List returned %v, want %v", subjects, want)
+		}
+	}
+
+	_, err = s.RateLimits.Get(models.RateLimitSubjectRole, "admin")
+	t.is(err, models.ErrRateLimitNotFound, "Get of missing override")
+
+	if t.ok(s.RateLimits.Delete(models.RateLimitSubjectAPIKey, keyID), "Delete") {
+		_, err := s.RateLimits.Get(models.RateLimitSubjectAPIKey, keyID)
+		t.is(err, models.ErrRateLimitNotFound, "Get after Delete")
+	}
+	t.is(s.RateLimits.Delete(models.RateLimitSubjectAPIKey, keyID), models.ErrRateLimitNotFound, "Delete of missing override")
+}
+
+// checkRewardCatalog verifies the reward catalog
+func checkRewardCatalog(t *tester, s *store.Store) {
+	for _, item := range []*models.RewardCatalogItem{
+		{ID: "gems", Type: models.RewardTypeCurrency, Name: "Gems", CreatedAt: baseTime},
+		{ID: "dragon_skin", Type: models.RewardTypeItem, Name: "Dragon Skin", CreatedAt: baseTime},
+	} {
+		if !t.ok(s.RewardCatalog.Create(item), "Create") {
+			return
+		}
+	}
+
+	t.is(s.RewardCatalog.Create(&models.RewardCatalogItem{ID: "gems", Type: models.RewardTypeCurrency, Name: "More Gems", CreatedAt: baseTime}),
+		models.ErrRewardExists, "Create with a taken ID")
+
+	got, err := s.RewardCatalog.GetByID("gems")
+	if t.ok(err, "GetByID") && (got.Name != "Gems" || got.Type != models.RewardTypeCurrency || !got.CreatedAt.Equal(baseTime)) {
+		t.errorf("GetByID returned %+v", got)
+	}
+	_, err = s.RewardCatalog.GetByID("missing")
+	t.is(err, models.ErrUnknownReward, "GetByID of missing entry")
+
+	items, err := s.RewardCatalog.List()
+	if t.ok(err, "List") && (len(items) != 2 || items[0].ID != "dragon_skin" || items[1].ID != "gems") {
+		t.errorf("List did not return the catalog ordered by ID")
+	}
+
+	if t.ok(s.RewardCatalog.Delete("gems"), "Delete") {
+		_, err := s.RewardCatalog.GetByID("gems")
+		t.is(err, models.ErrUnknownReward, "GetByID after Delete")
+	}
+	t.is(s.RewardCatalog.Delete("gems"), models.ErrUnknownReward, "Delete of missing entry")
+}
+
+// checkWebhooks verifies subscriptions, the delivery queue and the attempt
+// log, including the cascade when a subscription is deleted
+func checkWebhooks(t *tester, s *store.Store) {
+	subscription := &models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        "https://hooks.example.com/liveops",
+		EventTypes: []models.ChangeType{models.ChangeCreated, models.ChangeEnded},
+		Secret:     "s3cret",
+		CreatedAt:  baseTime,
+	}
+	other := &models.WebhookSubscription{ID: uuid.New(), URL: "https://other.example.com", Secret: "other", CreatedAt: baseTime.Add(time.Minute)}
+	if !t.ok(s.Webhooks.CreateSubscription(subscription), "CreateSubscription") || !t.ok(s.Webhooks.CreateSubscription(other), "CreateSubscription") {
+		return
+	}
+
+	got, err := s.Webhooks.GetSubscription(subscription.ID)
+	if t.ok(err, "GetSubscription") && (got.URL != subscription.URL || got.Secret != "s3cret" ||
+		!reflect.DeepEqual(got.EventTypes, subscription.EventTypes) || !got.CreatedAt.Equal(baseTime)) {
+		t.errorf("GetSubscription returned %+v", got)
+	}
+	got, err = s.Webhooks.GetSubscription(other.ID)
+	if t.ok(err, "GetSubscription") && len(got.EventTypes) != 0 {
+		t.errorf("GetSubscription returned event types %v for a subscription to every change", got.EventTypes)
+	}
+	_, err = s.Webhooks.GetSubscription(uuid.New())
+	t.is(err, models.ErrWebhookNotFound, "GetSubscription of missing subscription")
+
+	subscriptions, err := s.Webhooks.ListSubscriptions()
+	if t.ok(err, "ListSubscriptions") && (len(subscriptions) != 2 || subscriptions[0].ID != subscription.ID || subscriptions[1].ID != other.ID) {
+		t.errorf("ListSubscriptions did not return the subscriptions oldest first")
+	}
+
+	// Queue deliveries due at increasing times
+	var deliveries []*models.WebhookDelivery
+	for i, sub := range []*models.WebhookSubscription{subscription, subscription, other} {
+		delivery := &models.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventType:      models.ChangeCreated,
+			EventID:        fmt.Sprintf("event-%d", i),
+			Payload:        json.RawMessage(`{"type":"created"}`),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  baseTime.Add(time.Duration(i) * time.Minute),
+			CreatedAt:      baseTime,
+			UpdatedAt:      baseTime,
+		}
+		if !t.ok(s.Webhooks.CreateDelivery(delivery), "CreateDelivery") {
+			return
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if !(deliveries[0].Seq < deliveries[1].Seq && deliveries[1].Seq < deliveries[2].Seq) {
+		t.errorf("CreateDelivery assigned non-increasing sequence numbers")
+	}
+	t.is(s.Webhooks.CreateDelivery(&models.WebhookDelivery{ID: uuid.New(), SubscriptionID: uuid.New(), Status: models.DeliveryPending, Payload: json.RawMessage(`{}`),
+		NextAttemptAt: baseTime, CreatedAt: baseTime, UpdatedAt: baseTime}), models.ErrWebhookNotFound, "CreateDelivery for missing subscription")
+
+	delivery, err := s.Webhooks.GetDelivery(deliveries[0].ID)
+	if t.ok(err, "GetDelivery") && (delivery.EventID != "event-0" || string(delivery.Payload) != `{"type":"created"}` ||
+		delivery.Status != models.DeliveryPending || delivery.Seq != deliveries[0].Seq || !delivery.NextAttemptAt.Equal(baseTime)) {
+		t.errorf("GetDelivery returned %+v", delivery)
+	}
+	_, err = s.Webhooks.GetDelivery(uuid.New())
+	t.is(err, models.ErrDeliveryNotFound, "GetDelivery of missing delivery")
+
+	// Claimed deliveries are leased, so that they are not claimed twice
+	lease := baseTime.Add(10 * time.Minute)
+	due, err := s.Webhooks.ClaimDueDeliveries(baseTime.Add(time.Minute), lease, 10)
+	if t.ok(err, "ClaimDueDeliveries") {
+		if len(due) != 2 || due[0].ID != deliveries[0].ID || due[1].ID != deliveries[1].ID {
+			t.errorf("ClaimDueDeliveries did not return the due deliveries in queueing order")
+		} else if !due[0].NextAttemptAt.Equal(lease) {
+			t.errorf("ClaimDueDeliveries set the next attempt to %v, want %v", due[0].NextAttemptAt, lease)
+		}
+	}
+	due, err = s.Webhooks.ClaimDueDeliveries(baseTime.Add(time.Minute), lease, 10)
+	if t.ok(err, "ClaimDueDeliveries") && len(due) != 0 {
+		t.errorf("ClaimDueDeliveries returned %d leased deliveries", len(due))
+	}
+	if due, err := s.Webhooks.ClaimDueDeliveries(baseTime.Add(2*time.Minute), lease, 1); t.ok(err, "ClaimDueDeliveries") &&
+		(len(due) != 1 || due[0].ID != deliveries[2].ID) {
+		t.errorf("ClaimDueDeliveries did not return the newly due delivery")
+	}
+
+	// A failed attempt pushes the next one back
+	deliveries[0].Attempts = 1
+	deliveries[0].LastError = "unexpected status 500"
+	deliveries[0].NextAttemptAt = baseTime.Add(time.Hour)
+	deliveries[0].UpdatedAt = baseTime.Add(time.Second)
+	t.ok(s.Webhooks.UpdateDelivery(deliveries[0]), "UpdateDelivery")
+	deliveries[1].Status = models.DeliveryDelivered
+	t.ok(s.Webhooks.UpdateDelivery(deliveries[1]), "UpdateDelivery")
+	t.is(s.Webhooks.UpdateDelivery(&models.WebhookDelivery{ID: uuid.New(), Status: models.DeliveryDead}), models.ErrDeliveryNotFound, "UpdateDelivery of missing delivery")
+
+	delivery, err = s.Webhooks.GetDelivery(deliveries[0].ID)
+	if t.ok(err, "GetDelivery") && (delivery.Attempts != 1 || delivery.LastError != "unexpected status 500" || !delivery.NextAttemptAt.Equal(baseTime.Add(time.Hour))) {
+		t.errorf("GetDelivery after UpdateDelivery returned %+v", delivery)
+	}
+	due, err = s.Webhooks.ClaimDueDeliveries(lease, lease, 10)
+	if t.ok(err, "ClaimDueDeliveries") && (len(due) != 1 || due[0].ID != deliveries[2].ID) {
+		t.errorf("ClaimDueDeliveries returned retried or delivered deliveries")
+	}
+
+	tests := []struct {
+		name      string
+		filter    models.WebhookDeliveryFilter
+		beforeSeq int64
+		limit     int
+		want      []uuid.UUID
+	}{
+		{"everything", models.WebhookDeliveryFilter{}, 0, 10, []uuid.UUID{deliveries[2].ID, deliveries[1].ID, deliveries[0].ID}},
+		{"limit", models.WebhookDeliveryFilter{}, 0, 1, []uuid.UUID{deliveries[2].ID}},
+		{"before", models.WebhookDeliveryFilter{}, deliveries[2].Seq, 10, []uuid.UUID{deliveries[1].ID, deliveries[0].ID}},
+		{"subscription", models.WebhookDeliveryFilter{SubscriptionID: &subscription.ID}, 0, 10, []uuid.UUID{deliveries[1].ID, deliveries[0].ID}},
+		{"status", models.WebhookDeliveryFilter{Status: models.DeliveryDelivered}, 0, 10, []uuid.UUID{deliveries[1].ID}},
+		{"event", models.WebhookDeliveryFilter{EventID: "event-2"}, 0, 10, []uuid.UUID{deliveries[2].ID}},
+	}
+	for _, tt := range tests {
+		list, err := s.Webhooks.ListDeliveries(tt.filter, tt.beforeSeq, tt.limit)
+		if !t.ok(err, "ListDeliveries "+tt.name) {
+			continue
+		}
+		ids := make([]uuid.UUID, len(list))
+		for i, delivery := range list {
+			ids[i] = delivery.ID
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.errorf("ListDeliveries %s returned %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+
+	// Attempts are logged in order
+	for _, attempt := range []*models.WebhookAttempt{
+		{DeliveryID: deliveries[0].ID, StatusCode: 500, Error: "unexpected status 500", DurationMS: 12, CreatedAt: baseTime},
+		{DeliveryID: deliveries[0].ID, Error: "connection refused", DurationMS: 3, CreatedAt: baseTime.Add(time.Minute)},
+	} {
+		t.ok(s.Webhooks.CreateAttempt(attempt), "CreateAttempt")
+	}
+	t.is(s.Webhooks.CreateAttempt(&models.WebhookAttempt{DeliveryID: uuid.New(), CreatedAt: baseTime}), models.ErrDeliveryNotFound, "CreateAttempt for missing delivery")
+
+	attempts, err := s.Webhooks.ListAttempts(deliveries[0].ID)
+	if t.ok(err, "ListAttempts") && (len(attempts) != 2 || attempts[0].StatusCode != 500 || attempts[0].DurationMS != 12 ||
+		attempts[1].Error != "connection refused" || !attempts[1].CreatedAt.Equal(baseTime.Add(time.Minute))) {
+		t.errorf("ListAttempts returned %+v", attempts)
+	}
+
+	// Deleting a subscription drops its deliveries and their attempts
+	if t.ok(s.Webhooks.DeleteSubscription(subscription.ID), "DeleteSubscription") {
+		_, err := s.Webhooks.GetSubscription(subscription.ID)
+		t.is(err, models.ErrWebhookNotFound, "GetSubscription after DeleteSubscription")
+		_, err = s.Webhooks.GetDelivery(deliveries[0].ID)
+		t.is(err, models.ErrDeliveryNotFound, "GetDelivery after DeleteSubscription")
+		attempts, err := s.Webhooks.ListAttempts(deliveries[0].ID)
+		if t.ok(err, "ListAttempts after DeleteSubscription") && len(attempts) != 0 {
+			t.errorf("ListAttempts returned %d attempts of a deleted delivery", len(attempts))
+		}
+		if _, err := s.Webhooks.GetDelivery(deliveries[2].ID); err != nil {
+			t.errorf("GetDelivery of another subscription's delivery after DeleteSubscription: %v", err)
+		}
+	}
+	t.is(s.Webhooks.DeleteSubscription(subscription.ID), models.ErrWebhookNotFound, "DeleteSubscription of missing subscription")
+}
+
+// checkTransactions verifies that transactions commit or discard the changes
+// of every repository together
+func checkTransactions(t *tester, s *store.Store) {
+	kept := newEvent("Kept", 0, models.StatusDraft)
+	err := s.Transactor.InTx(func(tx *store.Store) error {
+		if err := tx.Events.Create(kept); err != nil {
+			return err
+		}
+		return tx.Outbox.Create(models.NewOutboxEntry(models.ChangeCreated, kept, baseTime))
+	})
+	if !t.ok(err, "InTx") {
+		return
+	}
+	if _, err := s.Events.GetByID(kept.ID); err != nil {
+		t.errorf("GetByID of an event created in a committed transaction: %v", err)
+	}
+
+	// An error discards every change, including those of nested transactions
+	discarded := newEvent("Discarded", 1, models.StatusDraft)
+	errAbort := errors.New("abort")
+	err = s.Transactor.InTx(func(tx *store.Store) error {
+		if err := tx.Events.Create(discarded); err != nil {
+			return err
+		}
+		if err := tx.Transactor.InTx(func(nested *store.Store) error {
+			return nested.Outbox.Create(models.NewOutboxEntry(models.ChangeCreated, discarded, baseTime))
+		}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	t.is(err, errAbort, "InTx returning an error")
+	_, err = s.Events.GetByID(discarded.ID)
+	t.is(err, models.ErrEventNotFound, "GetByID of an event created in a discarded transaction")
+
+	entries, err := s.Outbox.List(models.OutboxFilter{}, 0, 10)
+	if t.ok(err, "List") && (len(entries) != 1 || entries[0].EventID != kept.ID) {
+		t.errorf("List returned %d outbox entries, want only the committed one", len(entries))
+	}
+}
+
+// checkOutbox verifies recording, claiming and listing outbox entries
+func checkOutbox(t *tester, s *store.Store) {
+	first := newEvent("First", 0, models.StatusDraft)
+	second := newEvent("Second", 1, models.StatusDraft)
+
+	// Record two changes of the first event around one of the second
+	var entries []*models.OutboxEntry
+	for i, change := range []struct {
+		changeType models.ChangeType
+		event      *models.LiveEvent
+	}{
+		{models.ChangeCreated, first},
+		{models.ChangeCreated, second},
+		{models.ChangeUpdated, first},
+	} {
+		entry := models.NewOutboxEntry(change.changeType, change.event, baseTime.Add(time.Duration(i)*time.Second))
+		if !t.ok(s.Outbox.Create(entry), "Create") {
+			return
+		}
+		entries = append(entries, entry)
+	}
+	if !(entries[0].Seq < entries[1].Seq && entries[1].Seq < entries[2].Seq) {
+		t.errorf("Create assigned non-increasing sequence numbers")
+	}
+
+	entry, err := s.Outbox.Get(entries[0].Seq)
+	if t.ok(err, "Get") && (entry.Type != models.ChangeCreated || entry.EventID != first.ID || entry.Event == nil ||
+		!equalEvents(entry.Event, first) || entry.Status != models.OutboxPending || !entry.NextAttemptAt.Equal(baseTime)) {
+		t.errorf("Get returned %+v", entry)
+	}
+	_, err = s.Outbox.Get(entries[2].Seq + 100)
+	t.is(err, models.ErrOutboxNotFound, "Get of missing entry")
+
+	// Only the first entry of each event is claimed, and claiming leases it
+	lease := baseTime.Add(time.Minute)
+	claimed, err := s.Outbox.ClaimDue(baseTime.Add(10*time.Second), lease, 10)
+	if t.ok(err, "ClaimDue") {
+		if len(claimed) != 2 || claimed[0].Seq != entries[0].Seq || claimed[1].Seq != entries[1].Seq {
+			t.errorf("ClaimDue did not return the first entry of each event in order")
+		} else if !claimed[0].NextAttemptAt.Equal(lease) {
+			t.errorf("ClaimDue set the next attempt to %v, want %v", claimed[0].NextAttemptAt, lease)
+		}
+	}
+	claimed, err = s.Outbox.ClaimDue(baseTime.Add(10*time.Second), lease, 10)
+	if t.ok(err, "ClaimDue") && len(claimed) != 0 {
+		t.errorf("ClaimDue returned %d leased entries", len(claimed))
+	}
+
+	// Delivering the first change of the first event releases the next one
+	entries[0].Status = models.OutboxDelivered
+	entries[0].UpdatedAt = baseTime.Add(time.Minute)
+	t.ok(s.Outbox.Update(entries[0]), "Update")
+	entries[1].Status = models.OutboxFailed
+	entries[1].Attempts = 3
+	entries[1].LastError = "sink unavailable"
+	entries[1].UpdatedAt = baseTime.Add(time.Minute)
+	t.ok(s.Outbox.Update(entries[1]), "Update")
+	t.is(s.Outbox.Update(&models.OutboxEntry{Seq: entries[2].Seq + 100, Status: models.OutboxFailed}), models.ErrOutboxNotFound, "Update of missing entry")
+
+	entry, err = s.Outbox.Get(entries[1].Seq)
+	if t.ok(err, "Get") && (entry.Status != models.OutboxFailed || entry.Attempts != 3 || entry.LastError != "sink unavailable" ||
+		!entry.UpdatedAt.Equal(baseTime.Add(time.Minute))) {
+		t.errorf("Get after Update returned %+v", entry)
+	}
+	claimed, err = s.Outbox.ClaimDue(baseTime.Add(10*time.Second), lease, 10)
+	if t.ok(err, "ClaimDue") && (len(claimed) != 1 || claimed[0].Seq != entries[2].Seq) {
+		t.errorf("ClaimDue did not return the next entry of the first event")
+	}
+
+	tests := []struct {
+		name      string
+		filter    models.OutboxFilter
+		beforeSeq int64
+		limit     int
+		want      []int64
+	}{
+		{"everything", models.OutboxFilter{}, 0, 10, []int64{entries[2].Seq, entries[1].Seq, entries[0].Seq}},
+		{"limit", models.OutboxFilter{}, 0, 1, []int64{entries[2].Seq}},
+		{"before", models.OutboxFilter{}, entries[2].Seq, 10, []int64{entries[1].Seq, entries[0].Seq}},
+		{"status", models.OutboxFilter{Status: models.OutboxFailed}, 0, 10, []int64{entries[1].Seq}},
+		{"event", models.OutboxFilter{EventID: &first.ID}, 0, 10, []int64{entries[2].Seq, entries[0].Seq}},
+	}
+	for _, tt := range tests {
+		list, err := s.Outbox.List(tt.filter, tt.beforeSeq, tt.limit)
+		if !t.ok(err, "List "+tt.name) {
+			continue
+		}
+		seqs := make([]int64, len(list))
+		for i, entry := range list {
+			seqs[i] = entry.Seq
+		}
+		if !reflect.DeepEqual(seqs, tt.want) {
+			t.errorf("List %s returned %v, want %v", tt.name, seqs, tt.want)
+		}
+	}
+
+	// Changes are read back in order whatever their status
+	after, err := s.Outbox.ListAfter(entries[0].Seq, 10)
+	if t.ok(err, "ListAfter") && (len(after) != 2 || after[0].Seq != entries[1].Seq || after[1].Seq != entries[2].Seq ||
+		after[1].Type != models.ChangeUpdated || !equalEvents(after[1].Event, first)) {
+		t.errorf("ListAfter returned %+v, want the entries after the first one", after)
+	}
+	after, err = s.Outbox.ListAfter(0, 1)
+	if t.ok(err, "ListAfter") && (len(after) != 1 || after[0].Seq != entries[0].Seq) {
+		t.errorf("ListAfter with a limit returned %+v, want the first entry", after)
+	}
+
+	// A failed entry holds back the later changes of its event
+	held := models.NewOutboxEntry(models.ChangeDeleted, second, baseTime)
+	if t.ok(s.Outbox.Create(held), "Create") {
+		claimed, err = s.Outbox.ClaimDue(baseTime.Add(10*time.Second), lease, 10)
+		if t.ok(err, "ClaimDue") && len(claimed) != 0 {
+			t.errorf("ClaimDue returned an entry behind a failed entry of its event")
+		}
+	}
+
+	// Only delivered entries are purged
+	count, err := s.Outbox.PurgeDelivered(baseTime.Add(time.Hour))
+	if t.ok(err, "PurgeDelivered") && count != 1 {
+		t.errorf("PurgeDelivered removed %d entries, want 1", count)
+	}
+	_, err = s.Outbox.Get(entries[0].Seq)
+	t.is(err, models.ErrOutboxNotFound, "Get after PurgeDelivered")
+	if _, err := s.Outbox.Get(entries[1].Seq); err != nil {
+		t.errorf("Get of a failed entry after PurgeDelivered: %v", err)
+	}
+}
+
+// checkIdempotency verifies idempotency records
+func checkIdempotency(t *tester, s *store.Store) {
+	keyID := uuid.New()
+	record := &models.IdempotencyRecord{
+		APIKeyID:    keyID,
+		Key:         "retry-1",
+		Fingerprint: "abc",
+		CreatedAt:   baseTime,
+		ExpiresAt:   baseTime.Add(time.Hour),
+	}
+	if !t.ok(s.Idempotency.Create(record), "Create") {
+		return
+	}
+	t.is(s.Idempotency.Create(record), models.ErrIdempotencyExists, "Create of a taken key")
+
+	// Keys are scoped to their API key
+	other := *record
+	other.APIKeyID = uuid.New()
+	other.ExpiresAt = baseTime.Add(-time.Minute)
+	t.ok(s.Idempotency.Create(&other), "Create for another API key")
+
+	got, err := s.Idempotency.Get(keyID, "retry-1")
+	if t.ok(err, "Get") && (got.Completed || got.Fingerprint != "abc" || len(got.Body) != 0 || !got.ExpiresAt.Equal(record.ExpiresAt)) {
+		t.errorf("Get returned %+v, want a pending record", got)
+	}
+
+	record.StatusCode = 201
+	record.Header = map[string]string{"Content-Type": "application/json"}
+	record.Body = []byte(`{"id":"1"}`)
+	record.ExpiresAt = baseTime.Add(24 * time.Hour)
+	if t.ok(s.Idempotency.Complete(record), "Complete") {
+		got, err := s.Idempotency.Get(keyID, "retry-1")
+		if t.ok(err, "Get") && (!got.Completed || got.StatusCode != 201 || string(got.Body) != `{"id":"1"}` ||
+			!reflect.DeepEqual(got.Header, record.Header) || !got.CreatedAt.Equal(baseTime) || !got.ExpiresAt.Equal(record.ExpiresAt)) {
+			t.errorf("Get returned %+v, want %+v", got, record)
+		}
+	}
+
+	missing := *record
+	missing.Key = "retry-2"
+	t.is(s.Idempotency.Complete(&missing), models.ErrIdempotencyMissing, "Complete of missing record")
+	_, err = s.Idempotency.Get(keyID, "retry-2")
+	t.is(err, models.ErrIdempotencyMissing, "Get of missing record")
+
+	// Only expired records are purged
+	count, err := s.Idempotency.PurgeExpired(baseTime)
+	if t.ok(err, "PurgeExpired") && count != 1 {
+		t.errorf("PurgeExpired removed %d records, want 1", count)
+	}
+	_, err = s.Idempotency.Get(other.APIKeyID, "retry-1")
+	t.is(err, models.ErrIdempotencyMissing, "Get after PurgeExpired")
+
+	if t.ok(s.Idempotency.Delete(keyID, "retry-1"), "Delete") {
+		_, err := s.Idempotency.Get(keyID, "retry-1")
+		t.is(err, models.ErrIdempotencyMissing, "Get after Delete")
+	}
+	t.is(s.Idempotency.Delete(keyID, "retry-1"), models.ErrIdempotencyMissing, "Delete of missing record")
+}
@@ -0,0 +1,553 @@
+// Package targeting implements the rule expressions that restrict events to
+// segments of players.
+//
+// An expression combines comparisons over player attributes with "&&"
+// ("and"), "||" ("or") and "!" ("not"), for example:
+//
+//	level >= 20 && region == "EU" && platform in ["ios", "android"]
+//	app_version >= "2.4.0" and not "churned" in cohorts
+//
+// The attributes are level (a number), region, platform and app_version
+// (strings) and cohorts (a list of strings). Ordered comparisons between
+// strings compare dot-separated numeric segments numerically, so that
+// "2.10.0" > "2.9.1".
+package targeting
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxLength bounds the size of an expression
+const MaxLength = 1024
+
+// maxDepth bounds the nesting of an expression
+const maxDepth = 32
+
+// ErrInvalidExpression is returned for expressions that fail to parse or
+// type-check
+var ErrInvalidExpression = errors.New("invalid targeting expression")
+
+// Player holds the attributes expressions are evaluated against
+type Player struct {
+	Level      int      `json:"level" form:"level"`
+	Region     string   `json:"region" form:"region"`
+	Platform   string   `json:"platform" form:"platform"`
+	AppVersion string   `json:"app_version" form:"app_version"`
+	Cohorts    []string `json:"cohorts" form:"cohort"`
+}
+
+// valueType is the static type of an expression node
+type valueType int
+
+const (
+	typeBool valueType = iota
+	typeNumber
+	typeString
+	typeNumberList
+	typeStringList
+)
+
+// String returns the name of the type for error messages
+func (t valueType) String() string {
+	return [...]string{"boolean", "number", "string", "list of numbers", "list of strings"}[t]
+}
+
+// elementType returns the element type of a list type
+func (t valueType) elementType() (valueType, bool) {
+	switch t {
+	case typeNumberList:
+		return typeNumber, true
+	case typeStringList:
+		return typeString, true
+	default:
+		return 0, false
+	}
+}
+
+// attributes lists the player attributes and their types
+var attributes = map[string]valueType{
+	"level":       typeNumber,
+	"region":      typeString,
+	"platform":    typeString,
+	"app_version": typeString,
+	"cohorts":     typeStringList,
+}
+
+// attribute returns the value of a player attribute
+func (p *Player) attribute(name string) interface{} {
+	switch name {
+	case "level":
+		return float64(p.Level)
+	case "region":
+		return p.Region
+	case "platform":
+		return p.Platform
+	case "app_version":
+		return p.AppVersion
+	case "cohorts":
+		list := make([]interface{}, len(p.Cohorts))
+		for i, cohort := range p.Cohorts {
+			list[i] = cohort
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// node is a type-checked expression tree node
+type node interface {
+	typ() valueType
+	eval(p *Player) interface{}
+}
+
+// Expression is a parsed targeting rule
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses and type-checks a targeting expression
+func Parse(source string) (*Expression, error) {
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidExpression, MaxLength)
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExpression, err)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+	if err == nil && root.typ() != typeBool {
+		err = fmt.Errorf("expression is a %s, not a condition", root.typ())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExpression, err)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// Validate checks that a targeting expression is well-formed. An empty
+// expression is valid and matches every player.
+func Validate(source string) error {
+	if strings.TrimSpace(source) == "" {
+		return nil
+	}
+	_, err := Parse(source)
+	return err
+}
+
+// Matches evaluates the expression for a player
+func (e *Expression) Matches(p *Player) bool {
+	return e.root.eval(p).(bool)
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Match parses a targeting expression and evaluates it for a player. An empty
+// expression matches every player and an invalid one matches none.
+func Match(source string, p *Player) bool {
+	if strings.TrimSpace(source) == "" {
+		return true
+	}
+
+	expr, err := Parse(source)
+	if err != nil {
+		return false
+	}
+	return expr.Matches(p)
+}
+
+// parser is a recursive descent parser over lexer tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// peek returns the current token
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes the current token
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// acceptOperator consumes the current token if it is one of the operators
+func (p *parser) acceptOperator(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.next()
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// parseOr parses a disjunction
+func (p *parser) parseOr(depth int) (node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.acceptOperator("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newLogical("||", left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseAnd parses a conjunction
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.acceptOperator("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newLogical("&&", left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseNot parses a negation or a comparison
+func (p *parser) parseNot(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("expression nested deeper than %d levels", maxDepth)
+	}
+
+	if _, ok := p.acceptOperator("!"); ok {
+		operand, err := p.parseNot(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if operand.typ() != typeBool {
+			return nil, fmt.Errorf("cannot negate a %s", operand.typ())
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison(depth)
+}
+
+// parseComparison parses an operand optionally compared to another
+func (p *parser) parseComparison(depth int) (node, error) {
+	left, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.acceptOperator("==", "!=", "<", "<=", ">", ">=", "in")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return newComparison(op, left, right)
+}
+
+// parseOperand parses an attribute, a literal, a list or a parenthesized
+// expression
+func (p *parser) parseOperand(depth int) (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenIdent:
+		attrType, ok := attributes[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute %q at position %d", t.text, t.pos)
+		}
+		return &attributeNode{name: t.text, attrType: attrType}, nil
+
+	case tokenString:
+		return &literalNode{value: t.value, valueType: typeString}, nil
+
+	case tokenNumber:
+		return &literalNode{value: t.value, valueType: typeNumber}, nil
+
+	case tokenLParen:
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at position %d", closing.pos)
+		}
+		return inner, nil
+
+	case tokenLBracket:
+		return p.parseList(t)
+
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+}
+
+// parseList parses a list of literals of a single type
+func (p *parser) parseList(open token) (node, error) {
+	list := &literalNode{valueType: typeStringList}
+	var values []interface{}
+	var elemType valueType
+
+	for i := 0; ; i++ {
+		if i == 0 && p.peek().kind == tokenRBracket {
+			p.next()
+			break
+		}
+
+		t := p.next()
+		var tType valueType
+		switch t.kind {
+		case tokenString:
+			tType = typeString
+		case tokenNumber:
+			tType = typeNumber
+		default:
+			return nil, fmt.Errorf("expected a string or number in list at position %d", t.pos)
+		}
+		if i > 0 && tType != elemType {
+			return nil, fmt.Errorf("list at position %d mixes strings and numbers", open.pos)
+		}
+		elemType = tType
+		values = append(values, t.value)
+
+		sep := p.next()
+		if sep.kind == tokenRBracket {
+			break
+		}
+		if sep.kind != tokenComma {
+			return nil, fmt.Errorf("expected \",\" or \"]\" at position %d", sep.pos)
+		}
+	}
+
+	if elemType == typeNumber && len(values) > 0 {
+		list.valueType = typeNumberList
+	}
+	list.value = values
+	return list, nil
+}
+
+// newLogical builds a conjunction or disjunction of two conditions
+func newLogical(op string, left, right node) (node, error) {
+	if left.typ() != typeBool || right.typ() != typeBool {
+		return nil, fmt.Errorf("%q needs conditions on both sides", op)
+	}
+	return &logicalNode{op: op, left: left, right: right}, nil
+}
+
+// newComparison type-checks and builds a comparison
+func newComparison(op string, left, right node) (node, error) {
+	if op == "in" {
+		elemType, ok := right.typ().elementType()
+		if !ok {
+			return nil, fmt.Errorf("right side of \"in\" must be a list, not a %s", right.typ())
+		}
+		if left.typ() != elemType && !isEmptyList(right) {
+			return nil, fmt.Errorf("cannot look for a %s in a %s", left.typ(), right.typ())
+		}
+		return &comparisonNode{op: op, left: left, right: right}, nil
+	}
+
+	if left.typ() != right.typ() || (left.typ() != typeNumber && left.typ() != typeString) {
+		return nil, fmt.Errorf("cannot compare a %s with a %s using %q", left.typ(), right.typ(), op)
+	}
+	return &comparisonNode{op: op, left: left, right: right}, nil
+}
+
+// isEmptyList reports whether a node is an empty list literal, which can
+// hold values of any type
+func isEmptyList(n node) bool {
+	literal, ok := n.(*literalNode)
+	if !ok {
+		return false
+	}
+	values, ok := literal.value.([]interface{})
+	return ok && len(values) == 0
+}
+
+// literalNode is a string, number or list constant
+type literalNode struct {
+	value     interface{}
+	valueType valueType
+}
+
+func (n *literalNode) typ() valueType           { return n.valueType }
+func (n *literalNode) eval(*Player) interface{} { return n.value }
+
+// attributeNode reads a player attribute
+type attributeNode struct {
+	name     string
+	attrType valueType
+}
+
+func (n *attributeNode) typ() valueType             { return n.attrType }
+func (n *attributeNode) eval(p *Player) interface{} { return p.attribute(n.name) }
+
+// notNode negates a condition
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) typ() valueType             { return typeBool }
+func (n *notNode) eval(p *Player) interface{} { return !n.operand.eval(p).(bool) }
+
+// logicalNode combines two conditions, short-circuiting like Go
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) typ() valueType { return typeBool }
+
+func (n *logicalNode) eval(p *Player) interface{} {
+	left := n.left.eval(p).(bool)
+	if n.op == "&&" {
+		return left && n.right.eval(p).(bool)
+	}
+	return left || n.right.eval(p).(bool)
+}
+
+// comparisonNode compares two values or tests list membership
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func (n *comparisonNode) typ() valueType { return typeBool }
+
+func (n *comparisonNode) eval(p *Player) interface{} {
+	left, right := n.left.eval(p), n.right.eval(p)
+
+	if n.op == "in" {
+		for _, element := range right.([]interface{}) {
+			if compare(left, element) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	c := compare(left, right)
+	switch n.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compare orders two values of the same type. Values of different types are
+// never equal.
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return 1
+		}
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 1
+		}
+		return compareVersions(a, b)
+	default:
+		return 1
+	}
+}
+
+// compareVersions compares strings segment by segment, splitting on dots.
+// Segments that are both integers compare numerically, others compare
+// case-insensitively as text, so plain strings keep their usual equality.
+func compareVersions(a, b string) int {
+	if strings.EqualFold(a, b) {
+		return 0
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		if aErr == nil && bErr == nil {
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+
+		if c := strings.Compare(strings.ToLower(as[i]), strings.ToLower(bs[i])); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
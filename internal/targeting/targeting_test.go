@@ -0,0 +1,165 @@
+package targeting_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tombombadilom/liveops/internal/targeting"
+)
+
+// player is the player expressions are matched against unless a test says
+// otherwise
+var player = targeting.Player{
+	Level:      25,
+	Region:     "EU",
+	Platform:   "ios",
+	AppVersion: "2.10.0",
+	Cohorts:    []string{"beta", "whales"},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// Empty expressions match every player
+		{"", true},
+		{"  ", true},
+
+		// Numbers
+		{"level == 25", true},
+		{"level != 25", false},
+		{"level >= 25 && level <= 25", true},
+		{"level > 24.5", true},
+		{"level < 10", false},
+
+		// Strings ignore case and may use either quote
+		{`region == "EU"`, true},
+		{`region == 'eu'`, true},
+		{`region != "NA"`, true},
+		{`platform in ["android", "IOS"]`, true},
+		{`platform in []`, false},
+		{`"whales" in cohorts`, true},
+		{`"churned" in cohorts`, false},
+		{`level in [10, 25]`, true},
+
+		// Logic, with keywords in any case
+		{`level > 30 || region == "EU"`, true},
+		{`level > 30 or region == "EU"`, true},
+		{`level > 20 AND NOT "churned" in cohorts`, true},
+		{`!(level > 20)`, false},
+		{`not not level > 20`, true},
+		{`level > 30 || region == "EU" && platform == "android"`, false},
+		{`(level > 30 || region == "EU") && platform == "ios"`, true},
+
+		// Versions compare numerically by segment
+		{`app_version > "2.9.1"`, true},
+		{`app_version >= "2.10"`, true},
+		{`app_version < "2.10.1"`, true},
+		{`app_version == "2.10.0"`, true},
+		{`app_version == "2.10"`, false},
+		{`app_version < "10"`, true},
+		{`app_version > "2.10.0-beta"`, false},
+	}
+	for _, test := range tests {
+		if got := targeting.Match(test.expr, &player); got != test.want {
+			t.Errorf("Match(%q) = %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestMatchComparesVersions(t *testing.T) {
+	tests := []struct {
+		version string
+		expr    string
+		want    bool
+	}{
+		{"2.10.0", `app_version > "2.9.9"`, true},
+		{"2.9.9", `app_version < "2.10.0"`, true},
+		{"1.0", `app_version == "1.0"`, true},
+		// A version with more segments is greater, even if they are zeros
+		{"1.0", `app_version > "1"`, true},
+		{"1", `app_version < "1.0"`, true},
+		{"1.0", `app_version == "1"`, false},
+		// Leading zeros do not count
+		{"1.02", `app_version == "1.2"`, true},
+		// Segments that are not numbers compare as text, ignoring case
+		{"1.0-RC1", `app_version == "1.0-rc1"`, true},
+		{"1.0-rc2", `app_version > "1.0-rc1"`, true},
+		{"1.b", `app_version > "1.a"`, true},
+		{"1.10", `app_version > "1.9a"`, false},
+		// Players without a version have the empty string
+		{"", `app_version < "0.1"`, true},
+		{"", `app_version == ""`, true},
+	}
+	for _, test := range tests {
+		p := targeting.Player{AppVersion: test.version}
+		if got := targeting.Match(test.expr, &p); got != test.want {
+			t.Errorf("Match(%q) for version %q = %v, want %v", test.expr, test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		// Lexing
+		{`region == "EU`, "unterminated string"},
+		{`region == 'EU"`, "unterminated string"},
+		{`level == 1.2.3`, "invalid number"},
+		{`level = 20`, "unexpected character"},
+		{`level >= 20 & region == "EU"`, "unexpected character"},
+		{`level >= 20 | region == "EU"`, "unexpected character"},
+		{`level >= -1`, "unexpected character"},
+		{`région == "EU"`, "unexpected character"},
+
+		// Parsing
+		{`level >=`, "unexpected end"},
+		{`level >= 20 &&`, "unexpected end"},
+		{`(level >= 20`, `expected ")"`},
+		{`level >= 20)`, `unexpected ")"`},
+		{`level >= 20 region == "EU"`, `unexpected "region"`},
+		{`level == == 20`, `unexpected "=="`},
+		{`level > 1 == region`, `unexpected "=="`},
+		{`platform in ["ios" "android"]`, `expected "," or "]"`},
+		{`platform in ["ios",]`, "expected a string or number"},
+		{`platform in [region]`, "expected a string or number"},
+		{`score > 10`, `unknown attribute "score"`},
+		{`Level > 10`, `unknown attribute "Level"`},
+		{strings.Repeat("(", 40) + "level > 1" + strings.Repeat(")", 40), "nested deeper"},
+		{strings.Repeat("!", 40) + "level > 1", "nested deeper"},
+		{`level > ` + strings.Repeat("1", targeting.MaxLength), "longer than"},
+
+		// Type checks
+		{`level`, "expression is a number"},
+		{`"EU"`, "expression is a string"},
+		{`cohorts`, "expression is a list of strings"},
+		{`level == "25"`, "cannot compare a number with a string"},
+		{`region > 3`, "cannot compare a string with a number"},
+		{`cohorts == ["beta"]`, "cannot compare a list of strings"},
+		{`(level > 1) == (region == "EU")`, "cannot compare a boolean"},
+		{`level in cohorts`, "cannot look for a number in a list of strings"},
+		{`platform in [1, 2]`, "cannot look for a string in a list of numbers"},
+		{`platform in ["ios", 2]`, "mixes strings and numbers"},
+		{`"beta" in platform`, `right side of "in" must be a list`},
+		{`!level`, "cannot negate a number"},
+		{`level && region == "EU"`, `"&&" needs conditions`},
+		{`region == "EU" || "NA"`, `"||" needs conditions`},
+	}
+	for _, test := range tests {
+		expr, err := targeting.Parse(test.expr)
+		if !errors.Is(err, targeting.ErrInvalidExpression) || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Parse(%q) = %v, %v, want an error containing %q", test.expr, expr, err, test.want)
+			continue
+		}
+		if targeting.Validate(test.expr) == nil {
+			t.Errorf("Validate(%q) succeeded", test.expr)
+		}
+		if targeting.Match(test.expr, &player) {
+			t.Errorf("Match(%q) succeeded", test.expr)
+		}
+	}
+}
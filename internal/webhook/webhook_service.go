@@ -0,0 +1,485 @@
+// Package webhook posts event changes to subscribed URLs. Changes taken from
+// the outbox are queued as deliveries in the store and sent by a background
+// dispatcher, which retries failures with exponential backoff until they
+// succeed or run out of attempts.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tombombadilom/liveops/internal/audit"
+	"github.com/tombombadilom/liveops/internal/clock"
+	"github.com/tombombadilom/liveops/internal/models"
+	"github.com/tombombadilom/liveops/internal/store"
+)
+
+// Page size limits for ListDeliveries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+const (
+	// MaxAttempts is the number of failed attempts after which a delivery
+	// is dead
+	MaxAttempts = 8
+	// initialBackoff is the wait after the first failed attempt; it doubles
+	// after every further failure
+	initialBackoff = 30 * time.Second
+	// maxBackoff caps the wait between attempts
+	maxBackoff = time.Hour
+	// requestTimeout bounds a single attempt
+	requestTimeout = 10 * time.Second
+	// dispatchBatchSize is the number of due deliveries sent concurrently
+	dispatchBatchSize = 20
+	// claimLease is how long claimed deliveries are left alone by other
+	// dispatchers; a dispatcher that stops while sending them leaves them to
+	// be claimed again once it passes
+	claimLease = 2 * time.Minute
+	// maxErrorBody is the number of response body bytes kept in errors
+	maxErrorBody = 256
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-LiveOps-Event"
+	HeaderDelivery  = "X-LiveOps-Delivery"
+	HeaderSignature = "X-LiveOps-Signature"
+)
+
+// ErrInvalidPageToken is returned for malformed pagination cursors
+var ErrInvalidPageToken = models.ErrInvalidPageToken
+
+// Service manages webhook subscriptions and delivers event changes to them
+type Service struct {
+	webhookRepo  store.WebhookRepository
+	auditService *audit.AuditService
+	clock        clock.Clock
+	client       *http.Client
+
+	// wake prompts the dispatcher to look for due deliveries
+	wake chan struct{}
+}
+
+// NewService creates a webhook service telling the time by clk and sending
+// deliveries with client, or a default client when it is nil
+func NewService(webhookRepo store.WebhookRepository, auditService *audit.AuditService, clk clock.Clock, client *http.Client) *Service {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+
+	return &Service{
+		webhookRepo:  webhookRepo,
+		auditService: auditService,
+		clock:        clk,
+		client:       client,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// CreateSubscription subscribes a URL to event changes of the given types,
+// or to every change when there are none. A random secret is generated when
+// secret is empty; the caller should hand it out, as it is not shown again.
+func (s *Service) CreateSubscription(ctx context.Context, url string, eventTypes []models.ChangeType, secret string) (*models.WebhookSubscription, error) {
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = generated
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		CreatedAt:  s.clock.Now(),
+	}
+
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.CreateSubscription(subscription); err != nil {
+		return nil, fmt.Errorf("failed to save webhook subscription: %w", err)
+	}
+
+	s.auditService.Record(ctx, models.AuditActionCreate, models.AuditTargetWebhook, subscription.ID.String(), nil, subscription)
+
+	return subscription, nil
+}
+
+// GetSubscription retrieves a subscription by its ID
+func (s *Service) GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error) {
+	return s.webhookRepo.GetSubscription(id)
+}
+
+// ListSubscriptions returns every subscription, oldest first
+func (s *Service) ListSubscriptions() ([]*models.WebhookSubscription, error) {
+	return s.webhookRepo.ListSubscriptions()
+}
+
+// DeleteSubscription removes a subscription and its delivery history
+func (s *Service) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	subscription, err := s.webhookRepo.GetSubscription(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.DeleteSubscription(id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionDelete, models.AuditTargetWebhook, id.String(), subscription, nil)
+
+	return nil
+}
+
+// Enqueue queues a delivery of an outbox entry for every subscription that
+// wants it. Deliveries are identified by the entry and the subscription, so
+// an entry enqueued again only queues the deliveries still missing.
+func (s *Service) Enqueue(entry *models.OutboxEntry) error {
+	subscriptions, err := s.webhookRepo.ListSubscriptions()
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	now := s.clock.Now()
+	queued := false
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(entry.Type) {
+			continue
+		}
+
+		id := deliveryID(subscription.ID, entry.Seq)
+		if _, err := s.webhookRepo.GetDelivery(id); err == nil {
+			continue
+		} else if !errors.Is(err, models.ErrDeliveryNotFound) {
+			return fmt.Errorf("failed to check webhook delivery: %w", err)
+		}
+
+		payload, err := json.Marshal(models.WebhookPayload{
+			ID:         id,
+			Type:       entry.Type,
+			EventID:    entry.EventID.String(),
+			Event:      entry.Event,
+			OccurredAt: entry.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+
+		delivery := &models.WebhookDelivery{
+			ID:             id,
+			SubscriptionID: subscription.ID,
+			EventType:      entry.Type,
+			EventID:        entry.EventID.String(),
+			Payload:        payload,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+
+		if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+			// The subscription was deleted in the meantime
+			if errors.Is(err, models.ErrWebhookNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+		queued = true
+	}
+
+	if queued {
+		s.wakeDispatcher()
+	}
+
+	return nil
+}
+
+// deliveryID returns the ID of the delivery of an outbox entry to a
+// subscription
+func deliveryID(subscriptionID uuid.UUID, seq int64) uuid.UUID {
+	return uuid.NewSHA1(subscriptionID, []byte(strconv.FormatInt(seq, 10)))
+}
+
+// ListDeliveries returns a page of deliveries matching the filter, newest
+// first, and the token for the next page (empty on the last page)
+func (s *Service) ListDeliveries(filter models.WebhookDeliveryFilter, pageSize int, pageToken string) ([]*models.WebhookDelivery, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var beforeSeq int64
+	if pageToken != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", ErrInvalidPageToken
+		}
+		beforeSeq, err = strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || beforeSeq <= 0 {
+			return nil, "", ErrInvalidPageToken
+		}
+	}
+
+	// Fetch one extra delivery to know whether another page exists
+	deliveries, err := s.webhookRepo.ListDeliveries(filter, beforeSeq, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	var nextPageToken string
+	if len(deliveries) > pageSize {
+		deliveries = deliveries[:pageSize]
+		last := deliveries[len(deliveries)-1].Seq
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(last, 10)))
+	}
+
+	return deliveries, nextPageToken, nil
+}
+
+// GetDelivery retrieves a delivery along with the log of its attempts
+func (s *Service) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, []*models.WebhookAttempt, error) {
+	delivery, err := s.webhookRepo.GetDelivery(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attempts, err := s.webhookRepo.ListAttempts(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+
+	return delivery, attempts, nil
+}
+
+// Redeliver queues a delivery for an immediate attempt with a fresh retry
+// budget, whatever its status. The original payload is sent again.
+func (s *Service) Redeliver(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *delivery
+	now := s.clock.Now()
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionUpdate, models.AuditTargetWebhookDelivery, id.String(), &before, delivery)
+	s.wakeDispatcher()
+
+	return delivery, nil
+}
+
+// RunDispatcher sends due deliveries until ctx is done. It wakes up when
+// changes are queued or redelivered, and at least every interval.
+func (s *Service) RunDispatcher(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		sent, err := s.DispatchDue(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to dispatch webhook deliveries")
+		} else if sent > 0 {
+			log.Debug().Int("count", sent).Msg("Dispatched webhook deliveries")
+		}
+
+		// Keep going while full batches are waiting
+		if err == nil && sent == dispatchBatchSize {
+			continue
+		}
+
+		timer.Reset(interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// DispatchDue claims the deliveries that are due, up to a batch, makes one
+// attempt at each and returns the number attempted. Claiming them keeps the
+// dispatchers of other replicas from sending them too.
+func (s *Service) DispatchDue(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(now, now.Add(claimLease), dispatchBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due webhook deliveries: %w", err)
+	}
+
+	// Deliveries of a batch are sent concurrently, so that a slow receiver
+	// does not hold up the others
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// wakeDispatcher prompts the dispatcher to look for due deliveries
+func (s *Service) wakeDispatcher() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// attempt posts a delivery once, logs the attempt and schedules a retry or
+// gives up when it fails
+func (s *Service) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	logger := log.With().Str("delivery_id", delivery.ID.String()).Str("subscription_id", delivery.SubscriptionID.String()).Logger()
+
+	subscription, err := s.webhookRepo.GetSubscription(delivery.SubscriptionID)
+	if err != nil {
+		// The subscription was deleted along with its deliveries
+		logger.Debug().Err(err).Msg("Skipping webhook delivery")
+		return
+	}
+
+	started := s.clock.Now()
+	statusCode, sendErr := s.send(ctx, subscription, delivery)
+	finished := s.clock.Now()
+
+	attempt := &models.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		StatusCode: statusCode,
+		DurationMS: finished.Sub(started).Milliseconds(),
+		CreatedAt:  finished,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.webhookRepo.CreateAttempt(attempt); err != nil {
+		logger.Error().Err(err).Msg("Failed to log webhook attempt")
+	}
+
+	delivery.UpdatedAt = finished
+	if sendErr == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.LastError = ""
+	} else {
+		delivery.Attempts++
+		delivery.LastError = sendErr.Error()
+		if delivery.Attempts >= MaxAttempts {
+			delivery.Status = models.DeliveryDead
+			logger.Warn().Err(sendErr).Int("attempts", delivery.Attempts).Msg("Webhook delivery is dead")
+		} else {
+			delivery.NextAttemptAt = finished.Add(Backoff(delivery.Attempts))
+			logger.Debug().Err(sendErr).Int("attempts", delivery.Attempts).Time("next_attempt_at", delivery.NextAttemptAt).Msg("Webhook delivery failed")
+		}
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		logger.Error().Err(err).Msg("Failed to update webhook delivery")
+	}
+}
+
+// send posts the payload of a delivery to its subscription and returns the
+// response status code, zero when no response was received. Any status other
+// than 2xx is an error.
+func (s *Service) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, s.clock.Now(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if len(body) > 0 {
+			return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		}
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// Drain the body so that the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+
+	return resp.StatusCode, nil
+}
+
+// Sign computes the signature header of a payload sent at t. The signature
+// is the hex HMAC-SHA256, keyed by the secret, of the Unix time, a dot and
+// the body; the time lets receivers reject replayed deliveries.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the wait before the next attempt after the given number
+// of failed attempts
+func Backoff(attempts int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
+
+// generateSecret returns a random signing secret
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -20,11 +20,25 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EventService_ListEvents_FullMethodName  = "/events.EventService/ListEvents"
-	EventService_GetEvent_FullMethodName    = "/events.EventService/GetEvent"
-	EventService_CreateEvent_FullMethodName = "/events.EventService/CreateEvent"
-	EventService_UpdateEvent_FullMethodName = "/events.EventService/UpdateEvent"
-	EventService_DeleteEvent_FullMethodName = "/events.EventService/DeleteEvent"
+	EventService_ListEvents_FullMethodName         = "/events.EventService/ListEvents"
+	EventService_ListEligibleEvents_FullMethodName = "/events.EventService/ListEligibleEvents"
+	EventService_GetEvent_FullMethodName           = "/events.EventService/GetEvent"
+	EventService_CreateEvent_FullMethodName        = "/events.EventService/CreateEvent"
+	EventService_UpdateEvent_FullMethodName        = "/events.EventService/UpdateEvent"
+	EventService_DeleteEvent_FullMethodName        = "/events.EventService/DeleteEvent"
+	EventService_PublishEvent_FullMethodName       = "/events.EventService/PublishEvent"
+	EventService_UnpublishEvent_FullMethodName     = "/events.EventService/UnpublishEvent"
+	EventService_CancelEvent_FullMethodName        = "/events.EventService/CancelEvent"
+	EventService_ArchiveEvent_FullMethodName       = "/events.EventService/ArchiveEvent"
+	EventService_ListOccurrences_FullMethodName    = "/events.EventService/ListOccurrences"
+	EventService_WatchEvents_FullMethodName        = "/events.EventService/WatchEvents"
+	EventService_ListEventRevisions_FullMethodName = "/events.EventService/ListEventRevisions"
+	EventService_GetEventRevision_FullMethodName   = "/events.EventService/GetEventRevision"
+	EventService_DiffEventRevisions_FullMethodName = "/events.EventService/DiffEventRevisions"
+	EventService_RevertEvent_FullMethodName        = "/events.EventService/RevertEvent"
+	EventService_BatchCreateEvents_FullMethodName  = "/events.EventService/BatchCreateEvents"
+	EventService_BatchUpdateEvents_FullMethodName  = "/events.EventService/BatchUpdateEvents"
+	EventService_BatchDeleteEvents_FullMethodName  = "/events.EventService/BatchDeleteEvents"
 )
 
 // EventServiceClient is the client API for EventService service.
@@ -35,6 +49,8 @@ const (
 type EventServiceClient interface {
 	// ListEvents returns all events
 	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	// ListEligibleEvents lists the active events targeting a player
+	ListEligibleEvents(ctx context.Context, in *ListEligibleEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
 	// GetEvent returns a specific event by ID
 	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error)
 	// CreateEvent creates a new event
@@ -43,6 +59,32 @@ type EventServiceClient interface {
 	UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*Event, error)
 	// DeleteEvent removes an event
 	DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// PublishEvent makes a draft event visible to game clients
+	PublishEvent(ctx context.Context, in *PublishEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// UnpublishEvent returns a scheduled event to draft
+	UnpublishEvent(ctx context.Context, in *UnpublishEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// CancelEvent withdraws an event that has not ended yet
+	CancelEvent(ctx context.Context, in *CancelEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// ArchiveEvent archives an ended or cancelled event
+	ArchiveEvent(ctx context.Context, in *ArchiveEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// ListOccurrences expands the occurrences of an event within a time window
+	ListOccurrences(ctx context.Context, in *ListOccurrencesRequest, opts ...grpc.CallOption) (*ListOccurrencesResponse, error)
+	// WatchEvents streams a snapshot of all events followed by their changes
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventChange], error)
+	// ListEventRevisions lists the revisions of an event, newest first
+	ListEventRevisions(ctx context.Context, in *ListEventRevisionsRequest, opts ...grpc.CallOption) (*ListEventRevisionsResponse, error)
+	// GetEventRevision returns a revision of an event
+	GetEventRevision(ctx context.Context, in *GetEventRevisionRequest, opts ...grpc.CallOption) (*EventRevision, error)
+	// DiffEventRevisions lists the fields that differ between two revisions
+	DiffEventRevisions(ctx context.Context, in *DiffEventRevisionsRequest, opts ...grpc.CallOption) (*DiffEventRevisionsResponse, error)
+	// RevertEvent restores the fields of an event from one of its revisions
+	RevertEvent(ctx context.Context, in *RevertEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// BatchCreateEvents creates many events in a single transaction
+	BatchCreateEvents(ctx context.Context, in *BatchCreateEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error)
+	// BatchUpdateEvents updates many events in a single transaction
+	BatchUpdateEvents(ctx context.Context, in *BatchUpdateEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error)
+	// BatchDeleteEvents removes many events in a single transaction
+	BatchDeleteEvents(ctx context.Context, in *BatchDeleteEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error)
 }
 
 type eventServiceClient struct {
@@ -63,6 +105,16 @@ func (c *eventServiceClient) ListEvents(ctx context.Context, in *ListEventsReque
 	return out, nil
 }
 
+func (c *eventServiceClient) ListEligibleEvents(ctx context.Context, in *ListEligibleEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_ListEligibleEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *eventServiceClient) GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Event)
@@ -103,6 +155,145 @@ func (c *eventServiceClient) DeleteEvent(ctx context.Context, in *DeleteEventReq
 	return out, nil
 }
 
+func (c *eventServiceClient) PublishEvent(ctx context.Context, in *PublishEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_PublishEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) UnpublishEvent(ctx context.Context, in *UnpublishEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_UnpublishEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) CancelEvent(ctx context.Context, in *CancelEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_CancelEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ArchiveEvent(ctx context.Context, in *ArchiveEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_ArchiveEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListOccurrences(ctx context.Context, in *ListOccurrencesRequest, opts ...grpc.CallOption) (*ListOccurrencesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOccurrencesResponse)
+	err := c.cc.Invoke(ctx, EventService_ListOccurrences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[0], EventService_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, EventChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventService_WatchEventsClient = grpc.ServerStreamingClient[EventChange]
+
+func (c *eventServiceClient) ListEventRevisions(ctx context.Context, in *ListEventRevisionsRequest, opts ...grpc.CallOption) (*ListEventRevisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventRevisionsResponse)
+	err := c.cc.Invoke(ctx, EventService_ListEventRevisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetEventRevision(ctx context.Context, in *GetEventRevisionRequest, opts ...grpc.CallOption) (*EventRevision, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventRevision)
+	err := c.cc.Invoke(ctx, EventService_GetEventRevision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) DiffEventRevisions(ctx context.Context, in *DiffEventRevisionsRequest, opts ...grpc.CallOption) (*DiffEventRevisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DiffEventRevisionsResponse)
+	err := c.cc.Invoke(ctx, EventService_DiffEventRevisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) RevertEvent(ctx context.Context, in *RevertEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_RevertEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) BatchCreateEvents(ctx context.Context, in *BatchCreateEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_BatchCreateEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) BatchUpdateEvents(ctx context.Context, in *BatchUpdateEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_BatchUpdateEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) BatchDeleteEvents(ctx context.Context, in *BatchDeleteEventsRequest, opts ...grpc.CallOption) (*BatchEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_BatchDeleteEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventServiceServer is the server API for EventService service.
 // All implementations must embed UnimplementedEventServiceServer
 // for forward compatibility.
@@ -111,6 +302,8 @@ func (c *eventServiceClient) DeleteEvent(ctx context.Context, in *DeleteEventReq
 type EventServiceServer interface {
 	// ListEvents returns all events
 	ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error)
+	// ListEligibleEvents lists the active events targeting a player
+	ListEligibleEvents(context.Context, *ListEligibleEventsRequest) (*ListEventsResponse, error)
 	// GetEvent returns a specific event by ID
 	GetEvent(context.Context, *GetEventRequest) (*Event, error)
 	// CreateEvent creates a new event
@@ -119,6 +312,32 @@ type EventServiceServer interface {
 	UpdateEvent(context.Context, *UpdateEventRequest) (*Event, error)
 	// DeleteEvent removes an event
 	DeleteEvent(context.Context, *DeleteEventRequest) (*emptypb.Empty, error)
+	// PublishEvent makes a draft event visible to game clients
+	PublishEvent(context.Context, *PublishEventRequest) (*Event, error)
+	// UnpublishEvent returns a scheduled event to draft
+	UnpublishEvent(context.Context, *UnpublishEventRequest) (*Event, error)
+	// CancelEvent withdraws an event that has not ended yet
+	CancelEvent(context.Context, *CancelEventRequest) (*Event, error)
+	// ArchiveEvent archives an ended or cancelled event
+	ArchiveEvent(context.Context, *ArchiveEventRequest) (*Event, error)
+	// ListOccurrences expands the occurrences of an event within a time window
+	ListOccurrences(context.Context, *ListOccurrencesRequest) (*ListOccurrencesResponse, error)
+	// WatchEvents streams a snapshot of all events followed by their changes
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[EventChange]) error
+	// ListEventRevisions lists the revisions of an event, newest first
+	ListEventRevisions(context.Context, *ListEventRevisionsRequest) (*ListEventRevisionsResponse, error)
+	// GetEventRevision returns a revision of an event
+	GetEventRevision(context.Context, *GetEventRevisionRequest) (*EventRevision, error)
+	// DiffEventRevisions lists the fields that differ between two revisions
+	DiffEventRevisions(context.Context, *DiffEventRevisionsRequest) (*DiffEventRevisionsResponse, error)
+	// RevertEvent restores the fields of an event from one of its revisions
+	RevertEvent(context.Context, *RevertEventRequest) (*Event, error)
+	// BatchCreateEvents creates many events in a single transaction
+	BatchCreateEvents(context.Context, *BatchCreateEventsRequest) (*BatchEventsResponse, error)
+	// BatchUpdateEvents updates many events in a single transaction
+	BatchUpdateEvents(context.Context, *BatchUpdateEventsRequest) (*BatchEventsResponse, error)
+	// BatchDeleteEvents removes many events in a single transaction
+	BatchDeleteEvents(context.Context, *BatchDeleteEventsRequest) (*BatchEventsResponse, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
 
@@ -132,6 +351,9 @@ type UnimplementedEventServiceServer struct{}
 func (UnimplementedEventServiceServer) ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
 }
+func (UnimplementedEventServiceServer) ListEligibleEvents(context.Context, *ListEligibleEventsRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEligibleEvents not implemented")
+}
 func (UnimplementedEventServiceServer) GetEvent(context.Context, *GetEventRequest) (*Event, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
 }
@@ -144,6 +366,45 @@ func (UnimplementedEventServiceServer) UpdateEvent(context.Context, *UpdateEvent
 func (UnimplementedEventServiceServer) DeleteEvent(context.Context, *DeleteEventRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEvent not implemented")
 }
+func (UnimplementedEventServiceServer) PublishEvent(context.Context, *PublishEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishEvent not implemented")
+}
+func (UnimplementedEventServiceServer) UnpublishEvent(context.Context, *UnpublishEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpublishEvent not implemented")
+}
+func (UnimplementedEventServiceServer) CancelEvent(context.Context, *CancelEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelEvent not implemented")
+}
+func (UnimplementedEventServiceServer) ArchiveEvent(context.Context, *ArchiveEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchiveEvent not implemented")
+}
+func (UnimplementedEventServiceServer) ListOccurrences(context.Context, *ListOccurrencesRequest) (*ListOccurrencesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOccurrences not implemented")
+}
+func (UnimplementedEventServiceServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[EventChange]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedEventServiceServer) ListEventRevisions(context.Context, *ListEventRevisionsRequest) (*ListEventRevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventRevisions not implemented")
+}
+func (UnimplementedEventServiceServer) GetEventRevision(context.Context, *GetEventRevisionRequest) (*EventRevision, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventRevision not implemented")
+}
+func (UnimplementedEventServiceServer) DiffEventRevisions(context.Context, *DiffEventRevisionsRequest) (*DiffEventRevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffEventRevisions not implemented")
+}
+func (UnimplementedEventServiceServer) RevertEvent(context.Context, *RevertEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertEvent not implemented")
+}
+func (UnimplementedEventServiceServer) BatchCreateEvents(context.Context, *BatchCreateEventsRequest) (*BatchEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateEvents not implemented")
+}
+func (UnimplementedEventServiceServer) BatchUpdateEvents(context.Context, *BatchUpdateEventsRequest) (*BatchEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateEvents not implemented")
+}
+func (UnimplementedEventServiceServer) BatchDeleteEvents(context.Context, *BatchDeleteEventsRequest) (*BatchEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteEvents not implemented")
+}
 func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
 func (UnimplementedEventServiceServer) testEmbeddedByValue()                      {}
 
@@ -183,6 +444,24 @@ func _EventService_ListEvents_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_ListEligibleEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEligibleEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListEligibleEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListEligibleEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListEligibleEvents(ctx, req.(*ListEligibleEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EventService_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetEventRequest)
 	if err := dec(in); err != nil {
@@ -255,6 +534,233 @@ func _EventService_DeleteEvent_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_PublishEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).PublishEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_PublishEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).PublishEvent(ctx, req.(*PublishEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_UnpublishEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnpublishEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).UnpublishEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_UnpublishEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).UnpublishEvent(ctx, req.(*UnpublishEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_CancelEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).CancelEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_CancelEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).CancelEvent(ctx, req.(*CancelEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ArchiveEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ArchiveEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ArchiveEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ArchiveEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ArchiveEvent(ctx, req.(*ArchiveEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListOccurrences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOccurrencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListOccurrences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListOccurrences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListOccurrences(ctx, req.(*ListOccurrencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventServiceServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, EventChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventService_WatchEventsServer = grpc.ServerStreamingServer[EventChange]
+
+func _EventService_ListEventRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListEventRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListEventRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListEventRevisions(ctx, req.(*ListEventRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetEventRevision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetEventRevision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetEventRevision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetEventRevision(ctx, req.(*GetEventRevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_DiffEventRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffEventRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).DiffEventRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_DiffEventRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).DiffEventRevisions(ctx, req.(*DiffEventRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_RevertEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).RevertEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_RevertEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).RevertEvent(ctx, req.(*RevertEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_BatchCreateEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).BatchCreateEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_BatchCreateEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).BatchCreateEvents(ctx, req.(*BatchCreateEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_BatchUpdateEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).BatchUpdateEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_BatchUpdateEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).BatchUpdateEvents(ctx, req.(*BatchUpdateEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_BatchDeleteEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).BatchDeleteEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_BatchDeleteEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).BatchDeleteEvents(ctx, req.(*BatchDeleteEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -266,6 +772,10 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListEvents",
 			Handler:    _EventService_ListEvents_Handler,
 		},
+		{
+			MethodName: "ListEligibleEvents",
+			Handler:    _EventService_ListEligibleEvents_Handler,
+		},
 		{
 			MethodName: "GetEvent",
 			Handler:    _EventService_GetEvent_Handler,
@@ -282,7 +792,61 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteEvent",
 			Handler:    _EventService_DeleteEvent_Handler,
 		},
+		{
+			MethodName: "PublishEvent",
+			Handler:    _EventService_PublishEvent_Handler,
+		},
+		{
+			MethodName: "UnpublishEvent",
+			Handler:    _EventService_UnpublishEvent_Handler,
+		},
+		{
+			MethodName: "CancelEvent",
+			Handler:    _EventService_CancelEvent_Handler,
+		},
+		{
+			MethodName: "ArchiveEvent",
+			Handler:    _EventService_ArchiveEvent_Handler,
+		},
+		{
+			MethodName: "ListOccurrences",
+			Handler:    _EventService_ListOccurrences_Handler,
+		},
+		{
+			MethodName: "ListEventRevisions",
+			Handler:    _EventService_ListEventRevisions_Handler,
+		},
+		{
+			MethodName: "GetEventRevision",
+			Handler:    _EventService_GetEventRevision_Handler,
+		},
+		{
+			MethodName: "DiffEventRevisions",
+			Handler:    _EventService_DiffEventRevisions_Handler,
+		},
+		{
+			MethodName: "RevertEvent",
+			Handler:    _EventService_RevertEvent_Handler,
+		},
+		{
+			MethodName: "BatchCreateEvents",
+			Handler:    _EventService_BatchCreateEvents_Handler,
+		},
+		{
+			MethodName: "BatchUpdateEvents",
+			Handler:    _EventService_BatchUpdateEvents_Handler,
+		},
+		{
+			MethodName: "BatchDeleteEvents",
+			Handler:    _EventService_BatchDeleteEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _EventService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "events.proto",
 }